@@ -0,0 +1,107 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+type DeadLetter_RequestBody struct {
+	Queue string `json:"queue"`
+	Limit int    `json:"limit"`
+}
+
+type DeadLetter struct {
+	Position   int    `json:"position"`
+	RetryCount int    `json:"retry_count"`
+	LastError  string `json:"last_error"`
+	Reason     string `json:"reason"`
+	Body       string `json:"body"`
+}
+
+type DeadLetterList_ResponseBody struct {
+	Queue    string       `json:"queue"`
+	Messages []DeadLetter `json:"messages"`
+}
+
+type DeadLetterCount_ResponseBody struct {
+	Queue string `json:"queue"`
+	Count int    `json:"count"`
+}
+
+// Longest message body shown by `admin dlq list`
+const deadLetterBodyPreview = 120
+
+// Send a dead-letter queue request and decode its response
+func postDeadLetterRequest(path string, queueName string, limit int, response interface{}) error {
+	var body = DeadLetter_RequestBody{Queue: queueName, Limit: limit}
+
+	rawData, err := PostRequest(BASE_URL+"/admin/dlq/"+path, body)
+	if err != nil {
+		return fmt.Errorf("error dead-letter queue %s: %w", path, err)
+	}
+	jsonBytes, err := json.Marshal(rawData)
+	if err != nil {
+		return fmt.Errorf("error dead-letter queue %s: %w", path, err)
+	}
+	if err := json.Unmarshal(jsonBytes, response); err != nil {
+		return fmt.Errorf("error dead-letter queue %s: %w", path, err)
+	}
+	return nil
+}
+
+// Format dead messages, one block per message
+func formatDeadLetters(response DeadLetterList_ResponseBody) string {
+	var out strings.Builder
+	for _, message := range response.Messages {
+		reason := message.LastError
+		if reason == "" {
+			reason = message.Reason
+		}
+		body := message.Body
+		if len(body) > deadLetterBodyPreview {
+			body = body[:deadLetterBodyPreview] + "..."
+		}
+		fmt.Fprintf(&out, "#%d\tretries: %d\terror: %s\n", message.Position, message.RetryCount, reason)
+		fmt.Fprintf(&out, "\t%s\n", body)
+	}
+	return out.String()
+}
+
+/* List dead messages of a queue */
+func ListDeadLetters(queueName string, limit int) error {
+	var response DeadLetterList_ResponseBody
+	if err := postDeadLetterRequest("list", queueName, limit, &response); err != nil {
+		return err
+	}
+
+	if len(response.Messages) == 0 {
+		log.Printf("No dead messages in %s.", response.Queue)
+		return nil
+	}
+	fmt.Print(formatDeadLetters(response))
+	return nil
+}
+
+/* Move dead messages back to their queue */
+func ReplayDeadLetters(queueName string, limit int) error {
+	var response DeadLetterCount_ResponseBody
+	if err := postDeadLetterRequest("replay", queueName, limit, &response); err != nil {
+		return err
+	}
+
+	log.Printf("Replayed %d message(s) from %s to %s.", response.Count, response.Queue, queueName)
+	return nil
+}
+
+/* Delete dead messages of a queue */
+func PurgeDeadLetters(queueName string) error {
+	var response DeadLetterCount_ResponseBody
+	if err := postDeadLetterRequest("purge", queueName, 0, &response); err != nil {
+		return err
+	}
+
+	log.Printf("Purged %d message(s) from %s.", response.Count, response.Queue)
+	return nil
+}
@@ -0,0 +1,23 @@
+package apis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatDeadLetters(t *testing.T) {
+	output := formatDeadLetters(DeadLetterList_ResponseBody{
+		Queue: "task_queue.dead",
+		Messages: []DeadLetter{
+			{Position: 1, RetryCount: 3, LastError: "failed to connect to database", Body: `{"id":"1"}`},
+			{Position: 2, Reason: "rejected", Body: strings.Repeat("x", 200)},
+		},
+	})
+
+	assert.Contains(t, output, "#1\tretries: 3\terror: failed to connect to database\n\t{\"id\":\"1\"}\n")
+	assert.Contains(t, output, "#2\tretries: 0\terror: rejected\n")
+	assert.Contains(t, output, strings.Repeat("x", deadLetterBodyPreview)+"...\n")
+	assert.NotContains(t, output, strings.Repeat("x", deadLetterBodyPreview+1))
+}
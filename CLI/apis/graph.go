@@ -0,0 +1,120 @@
+package apis
+
+import (
+	"cicd/pipeci/graph"
+	"cicd/pipeci/schema"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+/* Build the job dependency graph of a pipeline run */
+func buildExecutionGraph(response RequestExecutionStatus_ResponseBody) *graph.Graph {
+	g := &graph.Graph{Name: response.Pipeline.Name}
+	if response.Pipeline.StageOrder != "" {
+		g.Clusters = strings.Split(response.Pipeline.StageOrder, ",")
+	}
+
+	for _, stageName := range g.Clusters {
+		stage, ok := response.Stages[stageName]
+		if !ok {
+			continue
+		}
+		for _, job := range stage.Jobs {
+			node := graph.Node{
+				Id:      fmt.Sprintf("job_%d", job.JobId),
+				Label:   job.Name,
+				Cluster: stageName,
+				Status:  job.Status,
+			}
+			if !job.StartTime.IsZero() && job.EndTime.Valid {
+				node.Duration = job.EndTime.Time.Sub(job.StartTime)
+			}
+			g.Nodes = append(g.Nodes, node)
+
+			for _, parentId := range job.Needs {
+				g.Edges = append(g.Edges, graph.Edge{From: fmt.Sprintf("job_%d", parentId), To: node.Id})
+			}
+		}
+	}
+	return g
+}
+
+/*
+Report the job dependency graph of a past pipeline run in DOT or Mermaid format.
+The latest run is reported if runCounter is not specified.
+*/
+func ReportExecutionGraphLocal(repository schema.Repository, pipelineName string, runCounter int, format string) error {
+	var body = ReportPastExecutionsLocal_CurrentRepo_RequestBody{
+		Repository: schema.Repository{
+			Url:        removeTokenFromURL(repository.Url),
+			CommitHash: repository.CommitHash,
+		},
+		IPAddress:    "0.0.0.0",
+		PipelineName: strings.TrimSpace(pipelineName),
+		RunCounter:   runCounter,
+	}
+
+	rawData, err := PostRequest(BASE_URL+"/report/local/graph", body)
+	if err != nil {
+		return fmt.Errorf("error local execution graph report: %w", err)
+	}
+	if rawData == nil {
+		log.Println("No executions detected.")
+		return nil
+	}
+
+	response, err := convertToPipelineExecStatus(rawData)
+	if err != nil {
+		return fmt.Errorf("error local execution graph report: %w", err)
+	}
+
+	output, err := graph.Render(buildExecutionGraph(response), format)
+	if err != nil {
+		return err
+	}
+	fmt.Print(output)
+	return nil
+}
+
+type JobDuration_ResponseBody struct {
+	StageName      string  `json:"stage_name"`
+	JobName        string  `json:"job_name"`
+	AverageSeconds float64 `json:"average_seconds"`
+}
+
+/*
+Get average durations of past successful job runs of a pipeline.
+Returns a map of graph node id (stage/job) to duration.
+*/
+func GetJobDurationsLocal(repository schema.Repository, pipelineName string) (map[string]time.Duration, error) {
+	var body = ReportPastExecutionsLocal_CurrentRepo_RequestBody{
+		Repository: schema.Repository{
+			Url: removeTokenFromURL(repository.Url),
+		},
+		IPAddress:    "0.0.0.0",
+		PipelineName: strings.TrimSpace(pipelineName),
+	}
+
+	rawData, err := PostRequest(BASE_URL+"/report/local/durations", body)
+	if err != nil {
+		return nil, fmt.Errorf("error local job durations report: %w", err)
+	}
+
+	var response []JobDuration_ResponseBody
+	jsonBytes, err := json.Marshal(rawData)
+	if err != nil {
+		return nil, fmt.Errorf("error local job durations report: %w", err)
+	}
+	if err := json.Unmarshal(jsonBytes, &response); err != nil {
+		return nil, fmt.Errorf("error local job durations report: %w", err)
+	}
+
+	durations := make(map[string]time.Duration)
+	for _, duration := range response {
+		durations[graph.JobNodeId(duration.StageName, duration.JobName)] = time.Duration(duration.AverageSeconds * float64(time.Second))
+	}
+	return durations, nil
+}
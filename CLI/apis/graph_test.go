@@ -0,0 +1,37 @@
+package apis
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildExecutionGraph(t *testing.T) {
+	start := time.Date(2025, 3, 16, 7, 31, 0, 0, time.UTC)
+	response := RequestExecutionStatus_ResponseBody{
+		Pipeline: PipelineExecutionStatus{PipelineId: 1, Name: "sample", Status: "SUCCESS", StageOrder: "build,test"},
+		Stages: map[string]StageExecutionStatus{
+			"test": {StageId: 2, Name: "test", Status: "FAILED", Jobs: []JobExecutionStatus{
+				{JobId: 11, Name: "unit", Status: "SUCCESS", StartTime: start, EndTime: sql.NullTime{Time: start.Add(5 * time.Second), Valid: true}},
+				{JobId: 12, Name: "coverage", Status: "FAILED", StartTime: start, Needs: []int{11}},
+			}},
+			"build": {StageId: 1, Name: "build", Status: "SUCCESS", Jobs: []JobExecutionStatus{
+				{JobId: 10, Name: "compile", Status: "SUCCESS"},
+			}},
+		},
+	}
+
+	g := buildExecutionGraph(response)
+
+	assert.Equal(t, "sample", g.Name)
+	assert.Equal(t, []string{"build", "test"}, g.Clusters)
+	assert.Equal(t, 3, len(g.Nodes))
+	assert.Equal(t, "job_10", g.Nodes[0].Id)
+	assert.Equal(t, 5*time.Second, g.Nodes[1].Duration)
+	assert.Equal(t, time.Duration(0), g.Nodes[2].Duration)
+	assert.Equal(t, 1, len(g.Edges))
+	assert.Equal(t, "job_11", g.Edges[0].From)
+	assert.Equal(t, "job_12", g.Edges[0].To)
+}
@@ -24,6 +24,7 @@ type Report_ResponseBody struct {
 	StartTime time.Time    `json:"start_time"`
 	EndTime   sql.NullTime `json:"end_time"`
 	Status    string       `json:"status"`
+	Needs     []int        `json:"needs,omitempty"` // Parent job ids, only set for job reports
 	// RunCounter int          `json:"run_counter"`
 }
 
@@ -114,6 +115,16 @@ func logExecutionReport(input Report_ResponseBody) error {
 	// Colorize status
 	status := fmtStatus(input.Status)
 
+	// Parent jobs if any
+	needs := ""
+	if len(input.Needs) > 0 {
+		ids := make([]string, len(input.Needs))
+		for i, id := range input.Needs {
+			ids[i] = fmt.Sprintf("%d", id)
+		}
+		needs = "\n║ 🔗 Needs:      " + strings.Join(ids, ", ")
+	}
+
 	// Create the formatted output
 	output := fmt.Sprintf(`
 ╔═══════════════════════════════════════════════════
@@ -123,7 +134,7 @@ func logExecutionReport(input Report_ResponseBody) error {
 ║ 🆔 ID:         %v
 ║ 🏷️ Status:     %s
 ║ 🕒 Start Time: %s
-║ 🕓 End Time:   %s %s
+║ 🕓 End Time:   %s %s%s
 ╚═══════════════════════════════════════════════════`,
 		input.Name,
 		input.Id,
@@ -131,6 +142,7 @@ func logExecutionReport(input Report_ResponseBody) error {
 		input.StartTime.Format("2006-01-02 15:04:05 MST"),
 		input.EndTime.Time.Format("2006-01-02 15:04:05 MST"),
 		duration,
+		needs,
 	)
 
 	log.Println(output)
@@ -226,6 +238,17 @@ func mapToReport(data map[string]interface{}) (Report_ResponseBody, error) {
 		return report, fmt.Errorf("invalid status field")
 	}
 
+	// Extract Needs (optional)
+	if needs, ok := data["needs"].([]interface{}); ok {
+		for _, need := range needs {
+			if id, ok := need.(float64); ok {
+				report.Needs = append(report.Needs, int(id))
+			} else {
+				return report, fmt.Errorf("invalid needs field")
+			}
+		}
+	}
+
 	return report, nil
 }
 
@@ -2,26 +2,35 @@
 package apis
 
 import (
+	"cicd/pipeci/schema"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 )
 
 type RequestExecutionStatus_RequestBody struct {
 	ExecutionId string `json:"execution_id"`
 }
 
+type RequestLatestExecutionStatus_RequestBody struct {
+	Repository schema.Repository `json:"repository"`
+}
+
 type RequestExecutionStatus_ResponseBody struct {
 	Pipeline PipelineExecutionStatus         `json:"pipeline"`
 	Stages   map[string]StageExecutionStatus `json:"stages"`
 }
 
 type PipelineExecutionStatus struct {
-	PipelineId int    `json:"pipeline_id"`
-	Name       string `json:"name"`
-	Status     string `json:"status"`
-	StageOrder string `json:"stage_order"`
+	PipelineId    int    `json:"pipeline_id"`
+	ExecutionId   string `json:"execution_id"`
+	Name          string `json:"name"`
+	Status        string `json:"status"`
+	StageOrder    string `json:"stage_order"`
+	QueuePosition int    `json:"queue_position,omitempty"` // Position in the task queue while the run is pending
 }
 
 type StageExecutionStatus struct {
@@ -32,9 +41,12 @@ type StageExecutionStatus struct {
 }
 
 type JobExecutionStatus struct { // * put OrderBy created_at when query status
-	JobId  int    `json:"job_id"`
-	Name   string `json:"name"`
-	Status string `json:"status"`
+	JobId     int          `json:"job_id"`
+	Name      string       `json:"name"`
+	Status    string       `json:"status"`
+	StartTime time.Time    `json:"start_time"`
+	EndTime   sql.NullTime `json:"end_time"`
+	Needs     []int        `json:"needs"` // Parent job ids
 }
 
 // Convert interface{} to RequestExecutionStatus_ResponseBody
@@ -50,9 +62,21 @@ func convertToPipelineExecStatus(rawData interface{}) (RequestExecutionStatus_Re
 
 // Print pipeline execution status
 func printExecutionStatus(response RequestExecutionStatus_ResponseBody) {
+	// Waiting in the task queue, nothing executed yet
+	if response.Pipeline.QueuePosition > 0 {
+		fmt.Println(strings.Repeat("═", 60))
+		fmt.Printf("🚀 PIPELINE EXECUTION: %s\n", response.Pipeline.ExecutionId)
+		fmt.Printf("   Status: %s\t\tQueue position: %d\n", colorStatus(response.Pipeline.Status), response.Pipeline.QueuePosition)
+		fmt.Println(strings.Repeat("═", 60))
+		return
+	}
+
 	// Print pipeline header
 	fmt.Println(strings.Repeat("═", 60))
 	fmt.Printf("🚀 PIPELINE: %s (ID: %d)\n", response.Pipeline.Name, response.Pipeline.PipelineId)
+	if response.Pipeline.ExecutionId != "" {
+		fmt.Printf("   Execution ID: %s\n", response.Pipeline.ExecutionId)
+	}
 	fmt.Printf("   Status: %s\t\tStage Order: %s\n", colorStatus(response.Pipeline.Status), response.Pipeline.StageOrder)
 	fmt.Println(strings.Repeat("─", 60))
 
@@ -118,3 +142,24 @@ func GetExecutionStatus(execId string) error {
 
 	return generateStatusReport(rawData)
 }
+
+/* Get execution status of the most recent run of a repository at a commit */
+func GetLatestExecutionStatus(repository schema.Repository) error {
+	var body = RequestLatestExecutionStatus_RequestBody{
+		Repository: schema.Repository{
+			Url:        removeTokenFromURL(repository.Url),
+			CommitHash: repository.CommitHash,
+		},
+	}
+
+	rawData, err := PostRequest(BASE_URL+"/status/latest", body)
+	if err != nil {
+		return fmt.Errorf("error latest pipeline status: %w", err)
+	}
+	if rawData == nil {
+		log.Println("No executions detected.")
+		return nil
+	}
+
+	return generateStatusReport(rawData)
+}
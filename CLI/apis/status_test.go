@@ -54,6 +54,19 @@ func TestGenerateStatusReport(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Pending in the task queue",
+			input: map[string]interface{}{
+				"pipeline": map[string]interface{}{
+					"pipeline_id":    0.0,
+					"execution_id":   "3f1c9a2e-7b4d-4e8a-9c61-0d2f5b8e4a17",
+					"status":         "PENDING",
+					"queue_position": 3.0,
+				},
+				"stages": map[string]interface{}{},
+			},
+			wantErr: false,
+		},
 		{
 			name: "Missing pipeline field",
 			input: map[string]interface{}{
@@ -92,10 +105,11 @@ func TestConvertToPipelineExecStatus(t *testing.T) {
 			name: "Valid pipeline data",
 			input: map[string]interface{}{
 				"pipeline": map[string]interface{}{
-					"pipeline_id": 15.0,
-					"name":        "maven_project_1",
-					"status":      "SUCCESS",
-					"stage_order": "verify",
+					"pipeline_id":  15.0,
+					"execution_id": "3f1c9a2e-7b4d-4e8a-9c61-0d2f5b8e4a17",
+					"name":         "maven_project_1",
+					"status":       "SUCCESS",
+					"stage_order":  "verify",
 				},
 				"stages": map[string]interface{}{
 					"verify": map[string]interface{}{
@@ -111,10 +125,11 @@ func TestConvertToPipelineExecStatus(t *testing.T) {
 			},
 			want: RequestExecutionStatus_ResponseBody{
 				Pipeline: PipelineExecutionStatus{
-					PipelineId: 15,
-					Name:       "maven_project_1",
-					Status:     "SUCCESS",
-					StageOrder: "verify",
+					PipelineId:  15,
+					ExecutionId: "3f1c9a2e-7b4d-4e8a-9c61-0d2f5b8e4a17",
+					Name:        "maven_project_1",
+					Status:      "SUCCESS",
+					StageOrder:  "verify",
 				},
 				Stages: map[string]StageExecutionStatus{
 					"verify": {
@@ -130,6 +145,25 @@ func TestConvertToPipelineExecStatus(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Pending in the task queue",
+			input: map[string]interface{}{
+				"pipeline": map[string]interface{}{
+					"execution_id":   "3f1c9a2e-7b4d-4e8a-9c61-0d2f5b8e4a17",
+					"status":         "PENDING",
+					"queue_position": 3.0,
+				},
+				"stages": map[string]interface{}{},
+			},
+			want: RequestExecutionStatus_ResponseBody{
+				Pipeline: PipelineExecutionStatus{
+					ExecutionId:   "3f1c9a2e-7b4d-4e8a-9c61-0d2f5b8e4a17",
+					Status:        "PENDING",
+					QueuePosition: 3,
+				},
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -6,7 +6,10 @@ package cmd
 import (
 	"bytes"
 	"cicd/pipeci/apis"
+	"cicd/pipeci/graph"
+	"cicd/pipeci/lint"
 	schema "cicd/pipeci/schema"
+	"cicd/pipeci/templates"
 	"errors"
 	"fmt"
 	"log"
@@ -14,6 +17,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -30,14 +34,34 @@ var (
 	repo       string
 	commit     string
 
+	// run subFlags
+	runPriority string
+
 	// report subFlags
 	reportPipelineName string
 	reportRunCounter   int
 	reportStageName    string
 	reportJobName      string
+	reportGraphFormat  string
 
 	// statusSubFlags
 	statusExecId string
+	statusLatest bool
+
+	// graph subFlags
+	graphFormat       string
+	graphCriticalPath bool
+
+	// lint subFlags
+	lintFormat string
+
+	// init subFlags
+	initTemplate string
+	initForce    bool
+
+	// admin dlq subFlags
+	dlqQueue string
+	dlqLimit int
 
 	// Config var
 	pipeline schema.PipelineConfiguration
@@ -54,14 +78,19 @@ func mandatoryProcess(cmd *cobra.Command) error {
 	}
 
 	// FLAGS PROCESSING
-	// Validate configuration during `run`
-	if cmd.Use == "run" {
+	// Validate configuration during `run` and `graph`
+	if cmd.Use == "run" || cmd.Use == "graph" {
 		check = true
 	}
 	// Validate configuration file during dry-run
 	if showDryRun {
 		check = true
 	}
+	// Lint reports all configuration errors by itself
+	if cmd.Use == "lint" {
+		check = false
+		showDryRun = false
+	}
 
 	// flags
 	err = HandleRepoFlag()
@@ -203,19 +232,15 @@ func HandleFilenameFlag() error {
 // --check | -c
 func HandleCheckFlag() error {
 	if check {
-		// Parse configuration file
-		pConfig, location, err := schema.ParseYAMLFile(filename)
+		// Parse and validate configuration file, reporting every problem at once
+		pConfig, err := schema.LoadPipelineConfiguration(filename)
 		if err != nil {
-			return fmt.Errorf("%s:%d:%d: %s", filename, location.Line, location.Column, err.Error())
-		}
-		pipeline = *pConfig
-
-		// Validate configuration
-		location, validateErr := pipeline.ValidateConfiguration()
-		if validateErr != nil {
-			// Format error message
-			return fmt.Errorf("%s:%d:%d: %s", filename, location.Line, location.Column, validateErr.Error())
+			if diagnostics, ok := err.(schema.Diagnostics); ok {
+				return errors.New(diagnostics.Format(filename))
+			}
+			return err
 		} else {
+			pipeline = *pConfig
 			log.Print("Pipeline configuration is valid.")
 		}
 	}
@@ -310,6 +335,14 @@ var RunCmd = &cobra.Command{
 			return err
 		}
 
+		// Overrides the priority of the configuration file
+		if runPriority != "" {
+			if !schema.IsValidPriority(runPriority) {
+				return fmt.Errorf("--priority must be `high`, `normal` or `low`")
+			}
+			pipeline.Pipeline.Value.Priority = &schema.ConfigurationNode[string]{Value: runPriority}
+		}
+
 		// TODO 1: --local:
 		// TODO 2: no local: run the deployed version
 		if isLocal {
@@ -345,6 +378,14 @@ var ReportCmd = &cobra.Command{
 				return fmt.Errorf("error while getting local repository info: %v", err)
 			}
 
+			// Show the job dependency graph of a single pipeline run
+			if reportGraphFormat != "" {
+				if reportStageName != "" || reportJobName != "" {
+					return fmt.Errorf("graph is reported for a whole pipeline run, --stage and --job are not allowed")
+				}
+				return apis.ReportExecutionGraphLocal(repository, reportPipelineName, reportRunCounter, reportGraphFormat)
+			}
+
 			// Show summary all past pipeline runs for the local repository if no pipeline name specified
 			if reportPipelineName == "" {
 				if reportStageName != "" {
@@ -368,7 +409,7 @@ var ReportCmd = &cobra.Command{
 // Sub-command: pipeci status
 var StatusCmd = &cobra.Command{
 	Use:           "status",
-	Short:         "usage: pipeci status --exec-id <exec-id>",
+	Short:         "usage: pipeci status --exec-id <exec-id> | --latest",
 	Long:          "Show execution status of the whole pipeline",
 	SilenceUsage:  true,
 	SilenceErrors: true,
@@ -378,8 +419,19 @@ var StatusCmd = &cobra.Command{
 			return err
 		}
 
-		if statusExecId == "" {
-			return fmt.Errorf("must specify status execution id")
+		if statusLatest {
+			if statusExecId != "" {
+				return fmt.Errorf("--exec-id and --latest cannot be used together")
+			}
+			// Most recent run of the current repository and commit
+			var repository schema.Repository
+			repository, err = getLocalGitRepo()
+			if err != nil {
+				return fmt.Errorf("error while getting local repository info: %v", err)
+			}
+			err = apis.GetLatestExecutionStatus(repository)
+		} else if statusExecId == "" {
+			return fmt.Errorf("must specify status execution id or --latest")
 		} else {
 			err = apis.GetExecutionStatus(statusExecId)
 		}
@@ -388,6 +440,175 @@ var StatusCmd = &cobra.Command{
 	},
 }
 
+// Sub-command: pipeci graph
+var GraphCmd = &cobra.Command{
+	Use:           "graph",
+	Short:         "usage: pipeci graph --format <dot|mermaid|ascii>",
+	Long:          "Visualize the jobs and their dependencies of the pipeline configuration",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := mandatoryProcess(cmd)
+		if err != nil {
+			return err
+		}
+
+		g := graph.FromConfiguration(pipeline)
+
+		// Weight jobs by their historical durations
+		if graphCriticalPath {
+			var durations map[string]time.Duration
+			repository, err := getLocalGitRepo()
+			if err == nil {
+				durations, err = apis.GetJobDurationsLocal(repository, g.Name)
+			}
+			if err != nil {
+				log.Printf("Warning: no historical job durations, every job is weighted equally: %v", err)
+			}
+			total := g.MarkCriticalPath(durations)
+			log.Printf("Critical path duration: %v", total)
+		}
+
+		output, err := graph.Render(g, graphFormat)
+		if err != nil {
+			return err
+		}
+		fmt.Print(output)
+		return nil
+	},
+}
+
+// Sub-command: pipeci lint
+var LintCmd = &cobra.Command{
+	Use:           "lint",
+	Short:         "usage: pipeci lint --format <text|json|sarif>",
+	Long:          "Report all errors and best-practice warnings of the pipeline configuration. Use `# pipeci:ignore rule-id` comments to suppress a warning.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := mandatoryProcess(cmd)
+		if err != nil {
+			return err
+		}
+
+		diagnostics, err := lint.Lint(filename)
+		if err != nil {
+			return err
+		}
+
+		output, err := lint.Render(diagnostics, lintFormat)
+		if err != nil {
+			return err
+		}
+		if len(diagnostics) == 0 && lintFormat == lint.FormatText {
+			log.Print("No problems found.")
+		}
+		fmt.Print(output)
+
+		if lint.HasErrors(diagnostics) {
+			return errors.New("pipeline configuration has errors")
+		}
+		return nil
+	},
+}
+
+// Sub-command: pipeci init
+var InitCmd = &cobra.Command{
+	Use:           "init",
+	Short:         "usage: pipeci init --template <name> --force",
+	Long:          "Write a starter pipeline configuration with build, test and lint stages. The template is detected from the project files unless --template is set.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The configuration file does not exist yet, only the repository is checked
+		err := isGitRoot()
+		if err != nil {
+			return errors.New("current directory must be root of a Git repository")
+		}
+		if !isYAMLFile(filename) {
+			return errors.New("configuration file must be a YAML file")
+		}
+		if doesfileExist(filename) && !initForce {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", filename)
+		}
+
+		name := initTemplate
+		if name == "" {
+			name = templates.Detect(GlobalDirectory)
+		}
+		directory, err := filepath.Abs(GlobalDirectory)
+		if err != nil {
+			return err
+		}
+
+		content, err := templates.Render(name, templates.Values{PipelineName: templates.PipelineName(directory)})
+		if err != nil {
+			return err
+		}
+		err = os.MkdirAll(filepath.Dir(filename), 0755)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(filename, content, 0644)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("Created %s from the `%s` template.", filename, name)
+		return nil
+	},
+}
+
+// Sub-command: pipeci admin
+var AdminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "usage: pipeci admin dlq <list|replay|purge>",
+	Long:  "Administrative operations on the CI/CD backend.",
+}
+
+// Sub-command: pipeci admin dlq
+var AdminDlqCmd = &cobra.Command{
+	Use:   "dlq",
+	Short: "usage: pipeci admin dlq <list|replay|purge> --queue <task_queue|job_queue>",
+	Long:  "Inspect and re-drive messages that failed too many times and were moved to a dead-letter queue.",
+}
+
+// Sub-command: pipeci admin dlq list
+var AdminDlqListCmd = &cobra.Command{
+	Use:           "list",
+	Short:         "usage: pipeci admin dlq list --queue <queue> --limit <n>",
+	Long:          "List dead messages of a queue with their last error, without removing them.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return apis.ListDeadLetters(dlqQueue, dlqLimit)
+	},
+}
+
+// Sub-command: pipeci admin dlq replay
+var AdminDlqReplayCmd = &cobra.Command{
+	Use:           "replay",
+	Short:         "usage: pipeci admin dlq replay --queue <queue> --limit <n>",
+	Long:          "Move dead messages back to their queue, oldest first. All messages unless --limit is set.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return apis.ReplayDeadLetters(dlqQueue, dlqLimit)
+	},
+}
+
+// Sub-command: pipeci admin dlq purge
+var AdminDlqPurgeCmd = &cobra.Command{
+	Use:           "purge",
+	Short:         "usage: pipeci admin dlq purge --queue <queue>",
+	Long:          "Delete all dead messages of a queue.",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return apis.PurgeDeadLetters(dlqQueue)
+	},
+}
+
 // Init function
 func init() {
 	// --filename | -f
@@ -409,6 +630,9 @@ func init() {
 	// --commit
 	RootCmd.PersistentFlags().StringVar(&commit, "commit", "", "Specify Git commit hash.")
 
+	// run --priority high
+	RunCmd.Flags().StringVar(&runPriority, "priority", "", "Queue priority of the run: high, normal or low. Overrides the pipeline configuration.")
+
 	// report --pipeline "code-review"
 	ReportCmd.Flags().StringVar(&reportPipelineName, "pipeline", "", "Returns the list of all executions for the specified pipeline")
 
@@ -421,9 +645,43 @@ func init() {
 	// report --run 2
 	ReportCmd.Flags().IntVar(&reportRunCounter, "run", 0, "Run number i-th for a specified pipeline name")
 
+	// report --graph dot
+	ReportCmd.Flags().StringVar(&reportGraphFormat, "graph", "", "Print the job dependency graph of a pipeline run in `dot` or `mermaid` format. Latest run unless --run is set.")
+
 	// status --exec-id execId
 	StatusCmd.Flags().StringVar(&statusExecId, "exec-id", "", "An UUID to specify a pipeline during execution.")
 
+	// status --latest
+	StatusCmd.Flags().BoolVar(&statusLatest, "latest", false, "Show the most recent execution of the current repository and commit (see --commit).")
+
+	// graph --format ascii
+	GraphCmd.Flags().StringVar(&graphFormat, "format", "ascii", "Output format: `dot`, `mermaid` or `ascii`.")
+
+	// graph --critical-path
+	GraphCmd.Flags().BoolVar(&graphCriticalPath, "critical-path", false, "Highlight the longest chain of jobs weighted by historical job durations.")
+
+	// lint --format text
+	LintCmd.Flags().StringVar(&lintFormat, "format", lint.FormatText, "Output format: `text`, `json` or `sarif`.")
+
+	// init --template go
+	InitCmd.Flags().StringVar(&initTemplate, "template", "", "Starter template: "+strings.Join(templates.Names(), ", ")+". Detected from the project files by default.")
+
+	// init --force
+	InitCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite an existing configuration file.")
+
+	// admin dlq --queue task_queue
+	AdminDlqCmd.PersistentFlags().StringVar(&dlqQueue, "queue", "task_queue", "Queue whose dead letters to manage: task_queue or job_queue.")
+
+	// admin dlq list --limit 20
+	AdminDlqListCmd.Flags().IntVar(&dlqLimit, "limit", 20, "Maximum number of messages to list, all when 0.")
+
+	// admin dlq replay --limit 0
+	AdminDlqReplayCmd.Flags().IntVar(&dlqLimit, "limit", 0, "Maximum number of messages to replay, all when 0.")
+
+	// admin dlq list|replay|purge
+	AdminDlqCmd.AddCommand(AdminDlqListCmd, AdminDlqReplayCmd, AdminDlqPurgeCmd)
+	AdminCmd.AddCommand(AdminDlqCmd)
+
 	// run
 	RootCmd.AddCommand(RunCmd)
 
@@ -432,6 +690,18 @@ func init() {
 
 	// status
 	RootCmd.AddCommand(StatusCmd)
+
+	// graph
+	RootCmd.AddCommand(GraphCmd)
+
+	// lint
+	RootCmd.AddCommand(LintCmd)
+
+	// init
+	RootCmd.AddCommand(InitCmd)
+
+	// admin
+	RootCmd.AddCommand(AdminCmd)
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -5,6 +5,7 @@ import (
 	"cicd/pipeci/cmd"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -272,6 +273,42 @@ func TestRun(t *testing.T) {
 	// }
 }
 
+/*
+Test `run --priority` with a value other than high, normal or low
+*/
+func TestRun_InvalidPriority(t *testing.T) {
+	// Capture log output
+	var buf bytes.Buffer
+	log.SetOutput(&buf)      // Redirect log output to buffer
+	defer log.SetOutput(nil) // Reset after test
+
+	// Store the original directory to restore later
+	originalDir, _ := os.Getwd()
+	// Restore original directory after test
+	defer func() {
+		if err := os.Chdir(originalDir); err != nil {
+			t.Fatalf("Failed to return to original directory: %v\n", err)
+		}
+	}()
+
+	// Change to a wrong directory (assume it's root for test purposes)
+	err := os.Chdir("../../")
+	if err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	cmd.RootCmd.SetArgs([]string{"run", "-f", ".pipelines/test/docker_run_success.yaml", "--local", "--priority", "urgent"})
+
+	err = cmd.RootCmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--priority must be `high`, `normal` or `low`")
+
+	t.Cleanup(func() {
+		cmd.RunCmd.Flags().Set("priority", "") // Reset after test
+		cmd.RootCmd.SetArgs([]string{})
+	})
+}
+
 /*
 Test `report` subcommand
 */
@@ -416,3 +453,125 @@ func TestReport_JobWithNoStage_2(t *testing.T) {
 		cmd.RootCmd.SetArgs([]string{}) // Reset after test
 	})
 }
+
+/*
+Test `graph` subcommand
+*/
+func TestGraph(t *testing.T) {
+	// Capture log output
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	// Store the original directory to restore later
+	originalDir, _ := os.Getwd()
+	// Restore original directory after test
+	defer func() {
+		if err := os.Chdir(originalDir); err != nil {
+			t.Fatalf("Failed to return to original directory: %v\n", err)
+		}
+	}()
+
+	// Change to a wrong directory (assume it's root for test purposes)
+	err := os.Chdir("../../")
+	if err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	cmd.RootCmd.SetArgs([]string{"graph", "-f", ".pipelines/pipeline.yaml", "--format", "dot"})
+	err = cmd.RootCmd.Execute()
+	assert.NoError(t, err)
+
+	cmd.RootCmd.SetArgs([]string{"graph", "-f", ".pipelines/pipeline.yaml", "--format", "svg"})
+	err = cmd.RootCmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported graph format `svg`")
+
+	cmd.RootCmd.SetArgs([]string{"graph", "-f", ".pipelines/test/cyclic_deps.yaml"})
+	err = cmd.RootCmd.Execute()
+	assert.Error(t, err)
+}
+
+/*
+Test `lint` subcommand
+*/
+func TestLint(t *testing.T) {
+	// Capture log output
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	// Store the original directory to restore later
+	originalDir, _ := os.Getwd()
+	// Restore original directory after test
+	defer func() {
+		if err := os.Chdir(originalDir); err != nil {
+			t.Fatalf("Failed to return to original directory: %v\n", err)
+		}
+	}()
+
+	// Change to a wrong directory (assume it's root for test purposes)
+	err := os.Chdir("../../")
+	if err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	// Warnings only
+	cmd.RootCmd.SetArgs([]string{"lint", "-f", ".pipelines/test/lint_warnings.yaml", "--format", "json"})
+	err = cmd.RootCmd.Execute()
+	assert.NoError(t, err)
+
+	// Errors exit non-zero
+	cmd.RootCmd.SetArgs([]string{"lint", "-f", ".pipelines/test/cyclic_deps.yaml", "--format", "text"})
+	err = cmd.RootCmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "pipeline configuration has errors")
+}
+
+func TestInit(t *testing.T) {
+	// Capture log output
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	// Store the original directory to restore later
+	originalDir, _ := os.Getwd()
+	// Restore original directory after test
+	defer func() {
+		if err := os.Chdir(originalDir); err != nil {
+			t.Fatalf("Failed to return to original directory: %v\n", err)
+		}
+	}()
+
+	// Fresh Go repository
+	directory := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(directory, ".git"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(directory, "go.mod"), []byte("module example"), 0644))
+	err := os.Chdir(directory)
+	if err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+
+	// Detected template
+	cmd.RootCmd.SetArgs([]string{"init", "-f", ".pipelines/pipeline.yaml"})
+	err = cmd.RootCmd.Execute()
+	assert.NoError(t, err)
+	content, err := os.ReadFile(".pipelines/pipeline.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "image: golang:")
+	assert.Contains(t, buf.String(), "from the `go` template")
+
+	// Existing file is kept
+	cmd.RootCmd.SetArgs([]string{"init", "-f", ".pipelines/pipeline.yaml", "--template", "node"})
+	err = cmd.RootCmd.Execute()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "use --force to overwrite it")
+
+	// Overwrite with an explicit template
+	cmd.RootCmd.SetArgs([]string{"init", "-f", ".pipelines/pipeline.yaml", "--template", "node", "--force"})
+	err = cmd.RootCmd.Execute()
+	assert.NoError(t, err)
+	content, err = os.ReadFile(".pipelines/pipeline.yaml")
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "image: node:")
+}
@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"cicd/pipeci/schema"
+	"sort"
+)
+
+// Node id of a job in a pipeline configuration
+func JobNodeId(stage, job string) string {
+	return stage + "/" + job
+}
+
+/* Build the job graph of a validated pipeline configuration */
+func FromConfiguration(pipeline schema.PipelineConfiguration) *Graph {
+	g := &Graph{Clusters: pipeline.StageOrder}
+	if pipeline.Pipeline != nil && pipeline.Pipeline.Value.Name != nil {
+		g.Name = pipeline.Pipeline.Value.Name.Value
+	}
+
+	for _, stageName := range pipeline.StageOrder {
+		for _, level := range pipeline.ExecOrder[stageName] {
+			// Keep output deterministic
+			names := append([]string{}, level...)
+			sort.Strings(names)
+
+			for _, jobName := range names {
+				job := pipeline.Stages.Value[stageName].Value[jobName]
+				g.Nodes = append(g.Nodes, Node{
+					Id:      JobNodeId(stageName, jobName),
+					Label:   jobName,
+					Cluster: stageName,
+				})
+				if job.Dependencies != nil {
+					for _, dep := range job.Dependencies.Value {
+						g.Edges = append(g.Edges, Edge{From: JobNodeId(stageName, dep), To: JobNodeId(stageName, jobName)})
+					}
+				}
+			}
+		}
+	}
+	return g
+}
@@ -0,0 +1,24 @@
+package graph
+
+import (
+	"cicd/pipeci/schema"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromConfiguration(t *testing.T) {
+	pipeline, err := schema.LoadPipelineConfiguration("../../.pipelines/pipeline.yaml")
+	assert.NoError(t, err)
+
+	g := FromConfiguration(*pipeline)
+
+	assert.Equal(t, "pipeline-name", g.Name)
+	assert.Equal(t, []string{"build", "test", "docs"}, g.Clusters)
+	assert.Equal(t, 5, len(g.Nodes))
+	assert.Equal(t, "build/compile", g.Nodes[0].Id)
+	assert.Equal(t, "test/run-test", g.Nodes[1].Id)
+	assert.Contains(t, g.Edges, Edge{From: "test/run-test", To: "test/checkstyle"})
+	assert.Contains(t, g.Edges, Edge{From: "test/checkstyle", To: "test/check-coverage"})
+	assert.Contains(t, g.ASCII(), "│   [2] check-coverage <- checkstyle")
+}
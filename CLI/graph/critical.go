@@ -0,0 +1,72 @@
+package graph
+
+import "time"
+
+// Weight of a job without historical duration
+const defaultWeight = time.Second
+
+/*
+Mark the critical path: the longest chain of jobs weighted by their durations.
+Stages run one after another, so the critical path is the longest chain of each stage joined together.
+Jobs missing from weights fall back to their own Duration, then to defaultWeight.
+Returns the total weight of the critical path.
+*/
+func (g *Graph) MarkCriticalPath(weights map[string]time.Duration) time.Duration {
+	weightOf := func(node Node) time.Duration {
+		if w, ok := weights[node.Id]; ok && w > 0 {
+			return w
+		}
+		if node.Duration > 0 {
+			return node.Duration
+		}
+		return defaultWeight
+	}
+
+	critical := make(map[string]bool)
+	criticalEdges := make(map[[2]string]bool) // [from, to] -> edge chosen by the longest chain
+	var total time.Duration
+	clusters := g.nodesByCluster()
+	for _, cluster := range g.Clusters {
+		// Longest path in a DAG: visit layers in topological order
+		best := make(map[string]time.Duration)
+		prev := make(map[string]string)
+		var end string
+		for _, layer := range g.layers(clusters[cluster]) {
+			for _, node := range layer {
+				best[node.Id] = weightOf(node)
+				for _, edge := range g.Edges {
+					if edge.To != node.Id {
+						continue
+					}
+					if w, ok := best[edge.From]; ok && w+weightOf(node) > best[node.Id] {
+						best[node.Id] = w + weightOf(node)
+						prev[node.Id] = edge.From
+					}
+				}
+				if end == "" || best[node.Id] > best[end] {
+					end = node.Id
+				}
+			}
+		}
+		if end == "" {
+			continue
+		}
+
+		total += best[end]
+		for id := end; id != ""; id = prev[id] {
+			critical[id] = true
+			if prev[id] != "" {
+				criticalEdges[[2]string{prev[id], id}] = true
+			}
+		}
+	}
+
+	for i := range g.Nodes {
+		g.Nodes[i].Critical = critical[g.Nodes[i].Id]
+	}
+	for i := range g.Edges {
+		// A redundant edge between two critical jobs, e.g. a -> c next to a -> b -> c, is not on the path
+		g.Edges[i].Critical = criticalEdges[[2]string{g.Edges[i].From, g.Edges[i].To}]
+	}
+	return total
+}
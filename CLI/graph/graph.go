@@ -0,0 +1,273 @@
+package graph
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Supported output formats
+const (
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+	FormatASCII   = "ascii"
+)
+
+// Node of a job graph.
+type Node struct {
+	Id       string        // Unique node id
+	Label    string        // Display name
+	Cluster  string        // Stage the node belongs to
+	Status   string        // (optional) Execution status
+	Duration time.Duration // (optional) Execution duration
+	Critical bool          // Node is on the critical path
+}
+
+// Directed edge from parent to child node.
+type Edge struct {
+	From     string
+	To       string
+	Critical bool // Edge is on the critical path
+}
+
+// Directed graph of jobs grouped by stages.
+type Graph struct {
+	Name     string   // Pipeline name
+	Clusters []string // Stages in execution order
+	Nodes    []Node
+	Edges    []Edge
+}
+
+// Render the graph in the given format
+func Render(g *Graph, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case FormatDOT:
+		return g.DOT(), nil
+	case FormatMermaid:
+		return g.Mermaid(), nil
+	case FormatASCII:
+		return g.ASCII(), nil
+	default:
+		return "", fmt.Errorf("unsupported graph format `%s`, must be one of: %s, %s, %s", format, FormatDOT, FormatMermaid, FormatASCII)
+	}
+}
+
+// Nodes grouped by cluster, keeping insertion order
+func (g *Graph) nodesByCluster() map[string][]Node {
+	clusters := make(map[string][]Node)
+	for _, node := range g.Nodes {
+		clusters[node.Cluster] = append(clusters[node.Cluster], node)
+	}
+	return clusters
+}
+
+// Text shown inside a node: name, status and duration
+func (node Node) annotation() []string {
+	lines := []string{node.Label}
+	var details []string
+	if node.Status != "" {
+		details = append(details, node.Status)
+	}
+	if node.Duration > 0 {
+		details = append(details, node.Duration.Round(time.Second).String())
+	}
+	if len(details) > 0 {
+		lines = append(lines, strings.Join(details, " "))
+	}
+	return lines
+}
+
+// Graphviz fill color by status
+func dotColor(status string) string {
+	switch strings.ToUpper(status) {
+	case "SUCCESS":
+		return "palegreen"
+	case "FAILED":
+		return "lightcoral"
+	case "CANCELED":
+		return "lightgrey"
+	case "PENDING":
+		return "khaki"
+	default:
+		return "white"
+	}
+}
+
+// Render the graph in Graphviz DOT format
+func (g *Graph) DOT() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %q {\n", g.Name)
+	sb.WriteString("\trankdir=LR;\n")
+	sb.WriteString("\tnode [shape=box, style=\"rounded,filled\"];\n")
+
+	clusters := g.nodesByCluster()
+	for i, cluster := range g.Clusters {
+		fmt.Fprintf(&sb, "\tsubgraph cluster_%d {\n", i)
+		fmt.Fprintf(&sb, "\t\tlabel=%q;\n", cluster)
+		for _, node := range clusters[cluster] {
+			highlight := ""
+			if node.Critical {
+				highlight = ", color=\"red\", penwidth=3"
+			}
+			fmt.Fprintf(&sb, "\t\t%q [label=%q, fillcolor=%q%s];\n",
+				node.Id, strings.Join(node.annotation(), "\n"), dotColor(node.Status), highlight)
+		}
+		sb.WriteString("\t}\n")
+	}
+
+	for _, edge := range g.Edges {
+		highlight := ""
+		if edge.Critical {
+			highlight = " [color=\"red\", penwidth=3]"
+		}
+		fmt.Fprintf(&sb, "\t%q -> %q%s;\n", edge.From, edge.To, highlight)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Characters not allowed in Mermaid node ids
+var mermaidIdPattern = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// Sanitize an id to be used in Mermaid
+func mermaidId(id string) string {
+	return mermaidIdPattern.ReplaceAllString(id, "_")
+}
+
+// Render the graph as a Mermaid flowchart
+func (g *Graph) Mermaid() string {
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+
+	clusters := g.nodesByCluster()
+	for i, cluster := range g.Clusters {
+		fmt.Fprintf(&sb, "\tsubgraph stage_%d [\"%s\"]\n", i, cluster)
+		for _, node := range clusters[cluster] {
+			fmt.Fprintf(&sb, "\t\t%s[\"%s\"]\n", mermaidId(node.Id), strings.Join(node.annotation(), "<br/>"))
+		}
+		sb.WriteString("\tend\n")
+	}
+
+	var criticalLinks []string
+	for i, edge := range g.Edges {
+		fmt.Fprintf(&sb, "\t%s --> %s\n", mermaidId(edge.From), mermaidId(edge.To))
+		if edge.Critical {
+			criticalLinks = append(criticalLinks, fmt.Sprintf("%d", i))
+		}
+	}
+
+	// Color nodes by status
+	classes := map[string][]string{}
+	for _, node := range g.Nodes {
+		if node.Status != "" {
+			class := strings.ToLower(node.Status)
+			classes[class] = append(classes[class], mermaidId(node.Id))
+		}
+	}
+	for _, status := range []string{"SUCCESS", "FAILED", "CANCELED", "PENDING"} {
+		class := strings.ToLower(status)
+		if len(classes[class]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "\tclassDef %s fill:%s\n", class, dotColor(status))
+		fmt.Fprintf(&sb, "\tclass %s %s\n", strings.Join(classes[class], ","), class)
+	}
+
+	// Highlight the critical path
+	var criticalNodes []string
+	for _, node := range g.Nodes {
+		if node.Critical {
+			criticalNodes = append(criticalNodes, mermaidId(node.Id))
+		}
+	}
+	if len(criticalNodes) > 0 {
+		sb.WriteString("\tclassDef critical stroke:red,stroke-width:3px\n")
+		fmt.Fprintf(&sb, "\tclass %s critical\n", strings.Join(criticalNodes, ","))
+	}
+	if len(criticalLinks) > 0 {
+		fmt.Fprintf(&sb, "\tlinkStyle %s stroke:red,stroke-width:3px\n", strings.Join(criticalLinks, ","))
+	}
+	return sb.String()
+}
+
+// Render the graph as an ASCII diagram with one layer per dependency level
+func (g *Graph) ASCII() string {
+	var sb strings.Builder
+	sb.WriteString(g.Name + "\n")
+
+	labels := make(map[string]string)
+	parents := make(map[string][]string)
+	for _, node := range g.Nodes {
+		labels[node.Id] = node.Label
+	}
+	for _, edge := range g.Edges {
+		parents[edge.To] = append(parents[edge.To], labels[edge.From])
+	}
+
+	clusters := g.nodesByCluster()
+	for i, cluster := range g.Clusters {
+		branch, indent := "├─ ", "│  "
+		if i == len(g.Clusters)-1 {
+			branch, indent = "└─ ", "   "
+		}
+		sb.WriteString(branch + cluster + "\n")
+
+		for level, layer := range g.layers(clusters[cluster]) {
+			for _, node := range layer {
+				marker := " "
+				if node.Critical {
+					marker = "*"
+				}
+				line := fmt.Sprintf("%s%s[%d] %s", indent, marker, level, strings.Join(node.annotation(), " "))
+				if len(parents[node.Id]) > 0 {
+					line += " <- " + strings.Join(parents[node.Id], ", ")
+				}
+				sb.WriteString(line + "\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// Group nodes into layers, a node is placed one layer below its deepest parent
+func (g *Graph) layers(nodes []Node) [][]Node {
+	inCluster := make(map[string]bool)
+	for _, node := range nodes {
+		inCluster[node.Id] = true
+	}
+	parents := make(map[string][]string)
+	for _, edge := range g.Edges {
+		if inCluster[edge.From] && inCluster[edge.To] {
+			parents[edge.To] = append(parents[edge.To], edge.From)
+		}
+	}
+
+	depth := make(map[string]int)
+	var depthOf func(id string, visiting map[string]bool) int
+	depthOf = func(id string, visiting map[string]bool) int {
+		if d, ok := depth[id]; ok {
+			return d
+		}
+		if visiting[id] {
+			return 0 // cycles are rejected by validation
+		}
+		visiting[id] = true
+		d := 0
+		for _, parent := range parents[id] {
+			d = max(d, depthOf(parent, visiting)+1)
+		}
+		depth[id] = d
+		return d
+	}
+
+	var layers [][]Node
+	for _, node := range nodes {
+		d := depthOf(node.Id, make(map[string]bool))
+		for len(layers) <= d {
+			layers = append(layers, nil)
+		}
+		layers[d] = append(layers[d], node)
+	}
+	return layers
+}
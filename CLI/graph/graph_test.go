@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleGraph() *Graph {
+	return &Graph{
+		Name:     "sample",
+		Clusters: []string{"build", "test"},
+		Nodes: []Node{
+			{Id: "job_1", Label: "compile", Cluster: "build", Status: "SUCCESS", Duration: 3 * time.Second},
+			{Id: "job_2", Label: "unit-test", Cluster: "test", Status: "FAILED"},
+			{Id: "job_3", Label: "coverage", Cluster: "test"},
+		},
+		Edges: []Edge{{From: "job_2", To: "job_3"}},
+	}
+}
+
+func TestDOT(t *testing.T) {
+	out := sampleGraph().DOT()
+
+	assert.True(t, strings.HasPrefix(out, "digraph \"sample\" {"))
+	assert.Contains(t, out, "subgraph cluster_0 {")
+	assert.Contains(t, out, "label=\"build\";")
+	assert.Contains(t, out, "\"job_1\" [label=\"compile\\nSUCCESS 3s\", fillcolor=\"palegreen\"];")
+	assert.Contains(t, out, "\"job_3\" [label=\"coverage\", fillcolor=\"white\"];")
+	assert.Contains(t, out, "\"job_2\" -> \"job_3\";")
+}
+
+func TestMermaid(t *testing.T) {
+	out := sampleGraph().Mermaid()
+
+	assert.True(t, strings.HasPrefix(out, "flowchart LR"))
+	assert.Contains(t, out, "subgraph stage_1 [\"test\"]")
+	assert.Contains(t, out, "job_1[\"compile<br/>SUCCESS 3s\"]")
+	assert.Contains(t, out, "job_2 --> job_3")
+	assert.Contains(t, out, "class job_2 failed")
+}
+
+func TestMermaidId(t *testing.T) {
+	assert.Equal(t, "build_check_style", mermaidId("build/check-style"))
+}
+
+func TestRender(t *testing.T) {
+	g := sampleGraph()
+
+	out, err := Render(g, "DOT")
+	assert.NoError(t, err)
+	assert.Equal(t, g.DOT(), out)
+
+	out, err = Render(g, "mermaid")
+	assert.NoError(t, err)
+	assert.Equal(t, g.Mermaid(), out)
+
+	_, err = Render(g, "svg")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported graph format `svg`")
+}
+
+func TestASCII(t *testing.T) {
+	out := sampleGraph().ASCII()
+
+	assert.Equal(t, "sample\n"+
+		"├─ build\n"+
+		"│   [0] compile SUCCESS 3s\n"+
+		"└─ test\n"+
+		"    [0] unit-test FAILED\n"+
+		"    [1] coverage <- unit-test\n", out)
+}
+
+func TestMarkCriticalPath(t *testing.T) {
+	g := &Graph{
+		Name:     "critical",
+		Clusters: []string{"test"},
+		Nodes: []Node{
+			{Id: "a", Label: "a", Cluster: "test"},
+			{Id: "b", Label: "b", Cluster: "test"},
+			{Id: "c", Label: "c", Cluster: "test"},
+			{Id: "d", Label: "d", Cluster: "test"},
+		},
+		Edges: []Edge{{From: "a", To: "b"}, {From: "a", To: "c"}, {From: "c", To: "d"}},
+	}
+
+	// a -> b is longer than a -> c -> d when b is slow
+	total := g.MarkCriticalPath(map[string]time.Duration{"a": time.Minute, "b": 10 * time.Minute, "c": time.Minute, "d": time.Minute})
+	assert.Equal(t, 11*time.Minute, total)
+	assert.True(t, g.Nodes[0].Critical)
+	assert.True(t, g.Nodes[1].Critical)
+	assert.False(t, g.Nodes[2].Critical)
+	assert.True(t, g.Edges[0].Critical)
+	assert.False(t, g.Edges[2].Critical)
+
+	// Without history, the chain with the most jobs wins
+	total = g.MarkCriticalPath(nil)
+	assert.Equal(t, 3*defaultWeight, total)
+	assert.False(t, g.Nodes[1].Critical)
+	assert.True(t, g.Nodes[3].Critical)
+	assert.Contains(t, g.DOT(), "\"c\" -> \"d\" [color=\"red\", penwidth=3];")
+	assert.Contains(t, g.Mermaid(), "linkStyle 1,2 stroke:red,stroke-width:3px")
+	assert.Contains(t, g.ASCII(), "*[2] d <- c")
+}
+
+func TestMarkCriticalPath_RedundantNeeds(t *testing.T) {
+	g := &Graph{
+		Name:     "critical",
+		Clusters: []string{"build"},
+		Nodes: []Node{
+			{Id: "a", Label: "a", Cluster: "build"},
+			{Id: "b", Label: "b", Cluster: "build"},
+			{Id: "c", Label: "c", Cluster: "build"},
+		},
+		Edges: []Edge{{From: "a", To: "b"}, {From: "b", To: "c"}, {From: "a", To: "c"}},
+	}
+
+	// a -> c is redundant with a -> b -> c, only the chain is on the critical path
+	total := g.MarkCriticalPath(nil)
+	assert.Equal(t, 3*defaultWeight, total)
+	assert.True(t, g.Edges[0].Critical)
+	assert.True(t, g.Edges[1].Critical)
+	assert.False(t, g.Edges[2].Critical)
+}
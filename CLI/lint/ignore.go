@@ -0,0 +1,53 @@
+package lint
+
+import (
+	"regexp"
+	"strings"
+)
+
+// e.g. `image: maven # pipeci:ignore image-latest, unsafe-script`
+var ignorePattern = regexp.MustCompile(`#\s*pipeci:ignore\s+([\w\-, ]+)`)
+
+/*
+Find `# pipeci:ignore rule-id` comments by line number.
+A comment suppresses diagnostics on its own line, or on the next line when the comment stands alone.
+*/
+func parseIgnoreComments(content string) map[int][]string {
+	ignores := make(map[int][]string)
+	for i, line := range strings.Split(content, "\n") {
+		match := ignorePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		ruleIds := strings.FieldsFunc(match[1], func(r rune) bool { return r == ',' || r == ' ' })
+
+		lineNumber := i + 1
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lineNumber++
+		}
+		ignores[lineNumber] = append(ignores[lineNumber], ruleIds...)
+	}
+	return ignores
+}
+
+// Drop diagnostics suppressed by ignore comments. Syntax errors can not be ignored.
+func filterIgnored(diagnostics []Diagnostic, ignores map[int][]string) []Diagnostic {
+	var result []Diagnostic
+	for _, d := range diagnostics {
+		ignored := false
+		if d.RuleId == RuleSyntax {
+			result = append(result, d)
+			continue
+		}
+		for _, ruleId := range ignores[d.Line] {
+			if ruleId == d.RuleId || ruleId == "all" {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			result = append(result, d)
+		}
+	}
+	return result
+}
@@ -0,0 +1,144 @@
+package lint
+
+import (
+	"cicd/pipeci/schema"
+	"os"
+	"sort"
+)
+
+// Diagnostic severity
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Rule id of hard configuration errors reported by the schema validation
+const RuleSyntax = "syntax"
+
+// A single problem found in a pipeline configuration file.
+type Diagnostic struct {
+	RuleId   string   `json:"rule_id"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+}
+
+// Lint rule checking a parsed pipeline configuration.
+type Rule struct {
+	Id          string
+	Severity    Severity
+	Description string
+	Check       func(pipeline *schema.PipelineConfiguration) []Diagnostic
+}
+
+/*
+Lint a pipeline configuration file.
+Syntax errors and best-practice warnings are collected in a single pass,
+diagnostics suppressed by `# pipeci:ignore rule-id` comments are dropped.
+*/
+func Lint(filename string) ([]Diagnostic, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var diagnostics []Diagnostic
+	// Validation computes the execution order, best-practice rules run even if it fails
+	pipeline, err := schema.LoadPipelineConfiguration(filename)
+	if err != nil {
+		schemaDiagnostics, ok := err.(schema.Diagnostics)
+		if !ok {
+			return nil, err
+		}
+		for _, d := range schemaDiagnostics {
+			diagnostics = append(diagnostics, syntaxDiagnostic(d))
+		}
+	}
+	if pipeline != nil {
+		for _, rule := range Rules {
+			for _, d := range rule.Check(pipeline) {
+				d.RuleId = rule.Id
+				d.Severity = rule.Severity
+				diagnostics = append(diagnostics, d)
+			}
+		}
+	}
+
+	for i := range diagnostics {
+		diagnostics[i].File = filename
+	}
+	diagnostics = dedupe(diagnostics)
+	diagnostics = filterIgnored(diagnostics, parseIgnoreComments(string(data)))
+	sortDiagnostics(diagnostics)
+	return diagnostics, nil
+}
+
+// Schema error as a diagnostic
+func syntaxDiagnostic(d schema.Diagnostic) Diagnostic {
+	return Diagnostic{
+		RuleId:   RuleSyntax,
+		Severity: SeverityError,
+		Message:  d.Message,
+		Line:     d.Location.Line,
+		Column:   d.Location.Column,
+	}
+}
+
+// Severity rank, lower is more severe
+func (severity Severity) rank() int {
+	switch severity {
+	case SeverityError:
+		return 0
+	case SeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Drop rule diagnostics reported at the same location as a syntax error
+func dedupe(diagnostics []Diagnostic) []Diagnostic {
+	var result []Diagnostic
+	for _, d := range diagnostics {
+		duplicated := false
+		for _, other := range diagnostics {
+			if other.RuleId == RuleSyntax && d.RuleId != RuleSyntax && other.Line == d.Line && other.Column == d.Column && other.Line > 0 {
+				duplicated = true
+				break
+			}
+		}
+		if !duplicated {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// Sort diagnostics by position then severity
+func sortDiagnostics(diagnostics []Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.Severity.rank() < b.Severity.rank()
+	})
+}
+
+// Check if any diagnostic is an error
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package lint
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint_Warnings(t *testing.T) {
+	diagnostics, err := Lint("../../.pipelines/test/lint_warnings.yaml")
+	assert.NoError(t, err)
+	assert.False(t, HasErrors(diagnostics))
+
+	var ruleIds []string
+	var lines []int
+	for _, d := range diagnostics {
+		ruleIds = append(ruleIds, d.RuleId)
+		lines = append(lines, d.Line)
+	}
+	// javadoc `maven` image is suppressed by an ignore comment
+	assert.Equal(t, []string{"image-latest", "unsafe-script", "unsafe-script", "redundant-needs"}, ruleIds)
+	assert.Equal(t, []int{18, 19, 27, 43}, lines)
+	assert.Equal(t, "job `coverage` needs `setup` which is already required by `run-test`", diagnostics[3].Message)
+}
+
+func TestLint_SyntaxError(t *testing.T) {
+	diagnostics, err := Lint("../../.pipelines/test/cyclic_deps.yaml")
+	assert.NoError(t, err)
+	assert.True(t, HasErrors(diagnostics))
+
+	// Best-practice rules still run when validation fails
+	var syntax, warnings int
+	for _, d := range diagnostics {
+		if d.RuleId == RuleSyntax {
+			syntax++
+		} else {
+			warnings++
+		}
+	}
+	assert.Equal(t, 1, syntax)
+	assert.Equal(t, 5, warnings)
+}
+
+func TestLint_InvalidJobOnly(t *testing.T) {
+	diagnostics, err := Lint("../../.pipelines/test/invalid_job_only.yaml")
+	assert.NoError(t, err)
+	assert.True(t, HasErrors(diagnostics))
+
+	// The stage of a job with syntax errors is not reported as unused
+	var ruleIds []string
+	for _, d := range diagnostics {
+		ruleIds = append(ruleIds, d.RuleId)
+	}
+	assert.Equal(t, []string{RuleSyntax}, ruleIds)
+}
+
+func TestLint_FileNotFound(t *testing.T) {
+	_, err := Lint("not_found.yaml")
+	assert.Error(t, err)
+}
+
+func TestParseIgnoreComments(t *testing.T) {
+	content := "image: maven # pipeci:ignore image-latest\n" +
+		"# pipeci:ignore unsafe-script, redundant-needs\n" +
+		"script: sudo make\n"
+
+	ignores := parseIgnoreComments(content)
+	assert.Equal(t, []string{"image-latest"}, ignores[1])
+	assert.Equal(t, []string{"unsafe-script", "redundant-needs"}, ignores[3])
+
+	diagnostics := filterIgnored([]Diagnostic{
+		{RuleId: "image-latest", Line: 1},
+		{RuleId: "unsafe-script", Line: 1},
+		{RuleId: "unsafe-script", Line: 3},
+		{RuleId: RuleSyntax, Line: 3},
+	}, map[int][]string{1: {"image-latest"}, 3: {"all"}})
+	assert.Equal(t, 2, len(diagnostics))
+	assert.Equal(t, "unsafe-script", diagnostics[0].RuleId)
+	assert.Equal(t, RuleSyntax, diagnostics[1].RuleId)
+}
+
+func TestRender(t *testing.T) {
+	diagnostics := []Diagnostic{
+		{RuleId: "image-latest", Severity: SeverityWarning, Message: "a -> b", File: "p.yaml", Line: 3, Column: 5},
+		{RuleId: RuleSyntax, Severity: SeverityError, Message: "missing key", File: "p.yaml"},
+	}
+
+	out, err := Render(diagnostics, FormatText)
+	assert.NoError(t, err)
+	assert.Equal(t, "p.yaml:3:5: warning: a -> b [image-latest]\np.yaml:0:0: error: missing key [syntax]\n", out)
+
+	out, err = Render(diagnostics, FormatJSON)
+	assert.NoError(t, err)
+	var decoded []Diagnostic
+	assert.NoError(t, json.Unmarshal([]byte(out), &decoded))
+	assert.Equal(t, diagnostics, decoded)
+	assert.Contains(t, out, "a -> b")
+
+	out, err = Render(diagnostics, FormatSARIF)
+	assert.NoError(t, err)
+	var sarif sarifLog
+	assert.NoError(t, json.Unmarshal([]byte(out), &sarif))
+	assert.Equal(t, "2.1.0", sarif.Version)
+	assert.Equal(t, len(Rules)+1, len(sarif.Runs[0].Tool.Driver.Rules))
+	assert.Equal(t, "warning", sarif.Runs[0].Results[0].Level)
+	assert.Equal(t, 3, sarif.Runs[0].Results[0].Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Nil(t, sarif.Runs[0].Results[1].Locations[0].PhysicalLocation.Region)
+
+	_, err = Render(diagnostics, "xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,156 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Supported output formats
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// Render diagnostics in the given format
+func Render(diagnostics []Diagnostic, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case FormatText:
+		return renderText(diagnostics), nil
+	case FormatJSON:
+		return renderJSON(diagnostics)
+	case FormatSARIF:
+		return renderSARIF(diagnostics)
+	default:
+		return "", fmt.Errorf("unsupported lint format `%s`, must be one of: %s, %s, %s", format, FormatText, FormatJSON, FormatSARIF)
+	}
+}
+
+// file:line:col: severity: message [rule-id]
+func renderText(diagnostics []Diagnostic) string {
+	var sb strings.Builder
+	for _, d := range diagnostics {
+		fmt.Fprintf(&sb, "%s:%d:%d: %s: %s [%s]\n", d.File, d.Line, d.Column, d.Severity, d.Message, d.RuleId)
+	}
+	return sb.String()
+}
+
+// Indented JSON without escaping `<`, `>` and `&` in messages
+func marshalJSON(value interface{}) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func renderJSON(diagnostics []Diagnostic) (string, error) {
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+	return marshalJSON(diagnostics)
+}
+
+/* SARIF 2.1.0 log, see https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html */
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	Id               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleId    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// SARIF result level by severity
+func sarifLevel(severity Severity) string {
+	switch severity {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func renderSARIF(diagnostics []Diagnostic) (string, error) {
+	rules := []sarifRule{{Id: RuleSyntax, ShortDescription: sarifMessage{Text: "Pipeline configuration must be valid."}}}
+	for _, rule := range Rules {
+		rules = append(rules, sarifRule{Id: rule.Id, ShortDescription: sarifMessage{Text: rule.Description}})
+	}
+
+	results := make([]sarifResult, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		location := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: d.File}}
+		// SARIF lines start at 1
+		if d.Line > 0 {
+			location.Region = &sarifRegion{StartLine: d.Line, StartColumn: d.Column}
+		}
+		results = append(results, sarifResult{
+			RuleId:    d.RuleId,
+			Level:     sarifLevel(d.Severity),
+			Message:   sarifMessage{Text: d.Message},
+			Locations: []sarifLocation{{PhysicalLocation: location}},
+		})
+	}
+
+	log := sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "pipeci", Rules: rules}},
+			Results: results,
+		}},
+	}
+	return marshalJSON(log)
+}
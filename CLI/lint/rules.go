@@ -0,0 +1,197 @@
+package lint
+
+import (
+	"cicd/pipeci/schema"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// All best-practice rules, in reporting order
+var Rules = []Rule{
+	{
+		Id:          "image-latest",
+		Severity:    SeverityWarning,
+		Description: "Job images should be pinned to a version instead of `latest`.",
+		Check:       checkImageLatest,
+	},
+	{
+		Id:          "parallelizable-stage",
+		Severity:    SeverityInfo,
+		Description: "Consecutive stages with a single independent job could be merged to run in parallel.",
+		Check:       checkParallelizableStage,
+	},
+	{
+		Id:          "redundant-needs",
+		Severity:    SeverityWarning,
+		Description: "A `needs` entry is already implied by another dependency.",
+		Check:       checkRedundantNeeds,
+	},
+	{
+		Id:          "unsafe-script",
+		Severity:    SeverityWarning,
+		Description: "Scripts should not use `sudo` or pipe downloads into a shell.",
+		Check:       checkUnsafeScript,
+	},
+	{
+		Id:          "unused-stage",
+		Severity:    SeverityWarning,
+		Description: "Stages should have at least one job.",
+		Check:       checkUnusedStage,
+	},
+}
+
+// Build a diagnostic at a location, rule id and severity are set by the caller
+func newDiagnostic(location *schema.YAMLFileLocation, message string) Diagnostic {
+	d := Diagnostic{Message: message}
+	if location != nil {
+		d.Line = location.Line
+		d.Column = location.Column
+	}
+	return d
+}
+
+// Jobs of a stage sorted by name
+func sortedJobs(pipeline *schema.PipelineConfiguration, stage string) []*schema.JobConfiguration {
+	var jobs []*schema.JobConfiguration
+	if pipeline.Stages == nil || pipeline.Stages.Value[stage] == nil {
+		return jobs
+	}
+	for _, job := range pipeline.Stages.Value[stage].Value {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name.Value < jobs[j].Name.Value })
+	return jobs
+}
+
+// Every job of the pipeline in stage order
+func allJobs(pipeline *schema.PipelineConfiguration) []*schema.JobConfiguration {
+	var jobs []*schema.JobConfiguration
+	for _, stage := range pipeline.StageOrder {
+		jobs = append(jobs, sortedJobs(pipeline, stage)...)
+	}
+	return jobs
+}
+
+// image-latest: `maven` or `maven:latest`
+func checkImageLatest(pipeline *schema.PipelineConfiguration) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, job := range allJobs(pipeline) {
+		image := job.Image.Value
+		if strings.Contains(image, "@") {
+			continue // pinned by digest
+		}
+		// Tag comes after the last `:` that is not part of a registry host
+		tag := ""
+		if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+			tag = image[i+1:]
+		}
+		if tag == "" || tag == "latest" {
+			diagnostics = append(diagnostics, newDiagnostic(job.Image.Location,
+				fmt.Sprintf("job `%s` uses image `%s` which is not pinned to a version", job.Name.Value, image)))
+		}
+	}
+	return diagnostics
+}
+
+// Stage only has one job without dependencies
+func isSingleIndependentJobStage(pipeline *schema.PipelineConfiguration, stage string) bool {
+	jobs := sortedJobs(pipeline, stage)
+	return len(jobs) == 1 && (jobs[0].Dependencies == nil || len(jobs[0].Dependencies.Value) == 0)
+}
+
+// parallelizable-stage: e.g. stages `lint` and `test` each with one independent job
+func checkParallelizableStage(pipeline *schema.PipelineConfiguration) []Diagnostic {
+	var diagnostics []Diagnostic
+	for i := 1; i < len(pipeline.StageOrder); i++ {
+		prev, stage := pipeline.StageOrder[i-1], pipeline.StageOrder[i]
+		if isSingleIndependentJobStage(pipeline, prev) && isSingleIndependentJobStage(pipeline, stage) {
+			diagnostics = append(diagnostics, newDiagnostic(pipeline.Stages.Value[stage].Location,
+				fmt.Sprintf("stage `%s` has a single independent job, consider merging it with stage `%s` to run in parallel", stage, prev)))
+		}
+	}
+	return diagnostics
+}
+
+// Check if `target` is reachable from `from` following `needs` within a stage
+func dependsOn(jobs map[string]*schema.JobConfiguration, from, target string, visited map[string]bool) bool {
+	if visited[from] {
+		return false
+	}
+	visited[from] = true
+	job := jobs[from]
+	if job == nil || job.Dependencies == nil {
+		return false
+	}
+	for _, dep := range job.Dependencies.Value {
+		if dep == target || dependsOn(jobs, dep, target, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// redundant-needs: `needs: [a, b]` where b already needs a
+func checkRedundantNeeds(pipeline *schema.PipelineConfiguration) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, stage := range pipeline.StageOrder {
+		if pipeline.Stages == nil || pipeline.Stages.Value[stage] == nil {
+			continue
+		}
+		jobs := pipeline.Stages.Value[stage].Value
+		for _, job := range sortedJobs(pipeline, stage) {
+			if job.Dependencies == nil {
+				continue
+			}
+			for _, dep := range job.Dependencies.Value {
+				for _, other := range job.Dependencies.Value {
+					if other == dep {
+						continue
+					}
+					if dependsOn(jobs, other, dep, make(map[string]bool)) {
+						diagnostics = append(diagnostics, newDiagnostic(job.Dependencies.Location,
+							fmt.Sprintf("job `%s` needs `%s` which is already required by `%s`", job.Name.Value, dep, other)))
+						break
+					}
+				}
+			}
+		}
+	}
+	return diagnostics
+}
+
+var (
+	sudoPattern     = regexp.MustCompile(`(^|[\s;&|])sudo\s`)
+	pipeToShPattern = regexp.MustCompile(`\b(curl|wget)\b[^|]*\|\s*(sudo\s+)?(ba|z)?sh\b`)
+)
+
+// unsafe-script: `sudo ...` or `curl ... | sh`
+func checkUnsafeScript(pipeline *schema.PipelineConfiguration) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, job := range allJobs(pipeline) {
+		for _, script := range job.Script.Value {
+			if sudoPattern.MatchString(script) {
+				diagnostics = append(diagnostics, newDiagnostic(job.Script.Location,
+					fmt.Sprintf("job `%s` runs `sudo`: %s", job.Name.Value, script)))
+			}
+			if pipeToShPattern.MatchString(script) {
+				diagnostics = append(diagnostics, newDiagnostic(job.Script.Location,
+					fmt.Sprintf("job `%s` pipes a download into a shell: %s", job.Name.Value, script)))
+			}
+		}
+	}
+	return diagnostics
+}
+
+// unused-stage: stage listed in `stages` without jobs, unless its jobs have syntax errors
+func checkUnusedStage(pipeline *schema.PipelineConfiguration) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, stage := range pipeline.StageOrder {
+		if len(sortedJobs(pipeline, stage)) == 0 && !pipeline.HasInvalidJobs(stage) {
+			diagnostics = append(diagnostics, newDiagnostic(pipeline.Stages.Value[stage].Location,
+				fmt.Sprintf("stage `%s` has no jobs", stage)))
+		}
+	}
+	return diagnostics
+}
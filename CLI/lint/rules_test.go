@@ -0,0 +1,106 @@
+package lint
+
+import (
+	"cicd/pipeci/schema"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Build a job configuration for rule tests
+func testJob(name, stage, image string, script []string, needs ...string) *schema.JobConfiguration {
+	location := &schema.YAMLFileLocation{Line: 1, Column: 1}
+	job := &schema.JobConfiguration{
+		Name:   &schema.ConfigurationNode[string]{Value: name, Location: location},
+		Stage:  &schema.ConfigurationNode[string]{Value: stage, Location: location},
+		Image:  &schema.ConfigurationNode[string]{Value: image, Location: location},
+		Script: &schema.ConfigurationNode[[]string]{Value: script, Location: location},
+	}
+	if len(needs) > 0 {
+		job.Dependencies = &schema.ConfigurationNode[[]string]{Value: needs, Location: location}
+	}
+	return job
+}
+
+// Build a pipeline configuration for rule tests
+func testPipeline(stages []string, jobs ...*schema.JobConfiguration) *schema.PipelineConfiguration {
+	pipeline := &schema.PipelineConfiguration{
+		StageOrder: stages,
+		Stages: &schema.ConfigurationNode[map[string]*schema.ConfigurationNode[map[string]*schema.JobConfiguration]]{
+			Value: make(map[string]*schema.ConfigurationNode[map[string]*schema.JobConfiguration]),
+		},
+	}
+	for _, stage := range stages {
+		pipeline.Stages.Value[stage] = &schema.ConfigurationNode[map[string]*schema.JobConfiguration]{
+			Value:    make(map[string]*schema.JobConfiguration),
+			Location: &schema.YAMLFileLocation{Line: 2, Column: 3},
+		}
+	}
+	for _, job := range jobs {
+		pipeline.Stages.Value[job.Stage.Value].Value[job.Name.Value] = job
+	}
+	return pipeline
+}
+
+func TestCheckImageLatest(t *testing.T) {
+	pipeline := testPipeline([]string{"build"},
+		testJob("a", "build", "maven", []string{"mvn"}),
+		testJob("b", "build", "maven:latest", []string{"mvn"}),
+		testJob("c", "build", "maven:3.9", []string{"mvn"}),
+		testJob("d", "build", "localhost:5000/maven", []string{"mvn"}),
+		testJob("e", "build", "maven@sha256:abc", []string{"mvn"}),
+	)
+
+	diagnostics := checkImageLatest(pipeline)
+	assert.Equal(t, 3, len(diagnostics))
+	assert.Contains(t, diagnostics[2].Message, "localhost:5000/maven")
+}
+
+func TestCheckParallelizableStage(t *testing.T) {
+	pipeline := testPipeline([]string{"lint", "test", "deploy"},
+		testJob("lint", "lint", "golang:1.23", []string{"make lint"}),
+		testJob("test", "test", "golang:1.23", []string{"make test"}),
+		testJob("build", "deploy", "golang:1.23", []string{"make"}),
+		testJob("push", "deploy", "golang:1.23", []string{"make push"}, "build"),
+	)
+
+	diagnostics := checkParallelizableStage(pipeline)
+	assert.Equal(t, 1, len(diagnostics))
+	assert.Contains(t, diagnostics[0].Message, "stage `test`")
+}
+
+func TestCheckRedundantNeeds(t *testing.T) {
+	pipeline := testPipeline([]string{"test"},
+		testJob("a", "test", "maven:3.9", []string{"mvn"}),
+		testJob("b", "test", "maven:3.9", []string{"mvn"}, "a"),
+		testJob("c", "test", "maven:3.9", []string{"mvn"}, "b"),
+		testJob("d", "test", "maven:3.9", []string{"mvn"}, "a", "c"),
+	)
+
+	diagnostics := checkRedundantNeeds(pipeline)
+	assert.Equal(t, 1, len(diagnostics))
+	assert.Equal(t, "job `d` needs `a` which is already required by `c`", diagnostics[0].Message)
+}
+
+func TestCheckUnsafeScript(t *testing.T) {
+	pipeline := testPipeline([]string{"build"},
+		testJob("a", "build", "ubuntu:24.04", []string{"sudo apt-get update", "make && sudo make install"}),
+		testJob("b", "build", "ubuntu:24.04", []string{"wget -qO- https://get.example.com | sudo bash"}),
+		testJob("c", "build", "ubuntu:24.04", []string{"echo pseudo", "curl -o out.tar.gz https://example.com"}),
+	)
+
+	diagnostics := checkUnsafeScript(pipeline)
+	assert.Equal(t, 4, len(diagnostics))
+	assert.Contains(t, diagnostics[3].Message, "pipes a download into a shell")
+}
+
+func TestCheckUnusedStage(t *testing.T) {
+	pipeline := testPipeline([]string{"build", "docs"},
+		testJob("a", "build", "maven:3.9", []string{"mvn"}),
+	)
+
+	diagnostics := checkUnusedStage(pipeline)
+	assert.Equal(t, 1, len(diagnostics))
+	assert.Equal(t, "stage `docs` has no jobs", diagnostics[0].Message)
+	assert.Equal(t, 2, diagnostics[0].Line)
+}
@@ -0,0 +1,79 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Problem found in a pipeline configuration.
+type Diagnostic struct {
+	Location YAMLFileLocation
+	Message  string
+}
+
+// All problems found in a pipeline configuration, reported together.
+type Diagnostics []Diagnostic
+
+// YAML decoder errors carry their line in the message
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// Record a problem at the given location (nil when the key is missing altogether)
+func (diagnostics *Diagnostics) add(location *YAMLFileLocation, message string) {
+	diagnostic := Diagnostic{Message: message}
+	if location != nil {
+		diagnostic.Location = *location
+	}
+	*diagnostics = append(*diagnostics, diagnostic)
+}
+
+// Sort by line then column, keeping the discovery order for ties
+func (diagnostics Diagnostics) sort() {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Location.Line != diagnostics[j].Location.Line {
+			return diagnostics[i].Location.Line < diagnostics[j].Location.Line
+		}
+		return diagnostics[i].Location.Column < diagnostics[j].Location.Column
+	})
+}
+
+// Nil when there are no problems so the result can be returned as an error
+func (diagnostics Diagnostics) err() error {
+	if len(diagnostics) == 0 {
+		return nil
+	}
+	diagnostics.sort()
+	return diagnostics
+}
+
+func (diagnostics Diagnostics) Error() string {
+	return diagnostics.Format("")
+}
+
+/*
+Format diagnostics as `file:line:col: message`, one per line.
+The file prefix is omitted when filename is empty.
+*/
+func (diagnostics Diagnostics) Format(filename string) string {
+	lines := make([]string, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		line := fmt.Sprintf("%d:%d: %s", d.Location.Line, d.Location.Column, d.Message)
+		if filename != "" {
+			line = filename + ":" + line
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Convert a YAML decoder error into a diagnostic
+func yamlDiagnostic(err error) Diagnostic {
+	match := yamlErrorLine.FindStringSubmatch(err.Error())
+	if match == nil {
+		return Diagnostic{Message: "syntax error: " + strings.TrimPrefix(err.Error(), "yaml: ")}
+	}
+	line, _ := strconv.Atoi(match[1])
+	return Diagnostic{Location: YAMLFileLocation{Line: line, Column: 1}, Message: "syntax error: " + match[2]}
+}
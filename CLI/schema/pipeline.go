@@ -28,20 +28,22 @@ func isInvalidString(value interface{}) bool {
 	}
 }
 
-// parsePipelineConfig extracts values and line numbers from yaml.Node
-func parsePipelineConfig(root *yaml.Node, config *PipelineConfiguration) (YAMLFileLocation, error) {
+// parsePipelineConfig extracts values and line numbers from yaml.Node, recording every problem found
+func parsePipelineConfig(root *yaml.Node, config *PipelineConfiguration, diagnostics *Diagnostics) {
 	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
-		fmt.Println("Invalid YAML structure")
-		return YAMLFileLocation{}, nil
+		diagnostics.add(nil, "syntax error: empty configuration file")
+		return
 	}
 
 	// Access the mapping node
 	mapping := root.Content[0]
 	if mapping.Kind != yaml.MappingNode {
-		fmt.Println("Expected a mapping node")
-		return YAMLFileLocation{}, nil
+		diagnostics.add(&YAMLFileLocation{Line: mapping.Line, Column: mapping.Column}, "syntax error: configuration must be a mapping")
+		return
 	}
 
+	// Jobs are checked against the stages, which may be declared after them
+	var jobNodes []*yaml.Node
 	for i := 0; i < len(mapping.Content); i += 2 {
 		keyNode := mapping.Content[i]
 		valueNode := mapping.Content[i+1]
@@ -51,80 +53,101 @@ func parsePipelineConfig(root *yaml.Node, config *PipelineConfiguration) (YAMLFi
 			config.Version = &ConfigurationNode[string]{Value: valueNode.Value, Location: &YAMLFileLocation{Line: keyNode.Line, Column: keyNode.Column}}
 		case "pipeline":
 			config.Pipeline = &ConfigurationNode[PipelineInfo]{Location: &YAMLFileLocation{Line: keyNode.Line, Column: keyNode.Column}}
-			parsePipelineInfo(valueNode, &config.Pipeline.Value)
+			parsePipelineInfo(valueNode, &config.Pipeline.Value, diagnostics)
 		case "stages":
 			config.Stages = &ConfigurationNode[map[string]*ConfigurationNode[map[string]*JobConfiguration]]{Value: make(map[string]*ConfigurationNode[map[string]*JobConfiguration]), Location: &YAMLFileLocation{Line: keyNode.Line, Column: keyNode.Column}}
 			if valueNode.Kind == yaml.SequenceNode {
 				for _, item := range valueNode.Content {
+					location := &YAMLFileLocation{Line: item.Line, Column: item.Column}
 					val := strings.TrimSpace(item.Value)
 					if isInvalidString(val) {
-						return *config.Stages.Location, errors.New("syntax error: stage name must be a non-empty string")
+						diagnostics.add(location, "syntax error: stage name must be a non-empty string")
+						continue
 					}
 					if config.Stages.Value[val] != nil {
-						return *config.Stages.Location, errors.New("syntax error: duplicated stages")
+						diagnostics.add(location, "syntax error: duplicated stages")
+						continue
 					}
 					config.Stages.Value[val] = &ConfigurationNode[map[string]*JobConfiguration]{
 						Value:    make(map[string]*JobConfiguration),
-						Location: &YAMLFileLocation{Line: item.Line, Column: item.Column},
+						Location: location,
 					}
 					config.StageOrder = append(config.StageOrder, val)
 				}
 			}
 		case "jobs":
 			if valueNode.Kind == yaml.SequenceNode {
-				for _, jobNode := range valueNode.Content {
-					var job JobConfiguration
-					// parse
-					parseJobConfig(jobNode, &job)
-					// validate format
-					// Name
-					if job.Name == nil {
-						return *config.Stages.Value[job.Stage.Value].Location, errors.New("syntax error: missing job name")
-					}
-					if isInvalidString(job.Name.Value) {
-						return *job.Name.Location, errors.New("syntax error: job name must be a non-empty string")
-					}
-					// Stage
-					if config.Stages == nil {
-						return YAMLFileLocation{}, errors.New("syntax error: missing key `stages`")
-					}
-					if job.Stage == nil {
-						return *job.Name.Location, errors.New("syntax error: job `" + job.Name.Value + "` is missing stage")
-					}
-					if isInvalidString(job.Stage.Value) {
-						return *job.Stage.Location, errors.New("syntax error: job stage must be a non-empty string")
-					}
-					if config.Stages.Value[job.Stage.Value] == nil {
-						return *config.Stages.Location, errors.New("syntax error: stage `" + job.Stage.Value + "` must be defined in stages")
-					}
-					if config.Stages.Value[job.Stage.Value].Value[job.Name.Value] != nil {
-						return *job.Stage.Location, errors.New("syntax error: duplicated job name within a stage")
-					}
-					// Image
-					if job.Image == nil {
-						return *job.Name.Location, errors.New("syntax error: job `" + job.Name.Value + "` is missing image")
-					}
-					if isInvalidString(job.Image.Value) {
-						return *job.Image.Location, errors.New("syntax error: job image must be a non-empty string")
-					}
-					// Script
-					if job.Script == nil {
-						return *job.Name.Location, errors.New("syntax error: job `" + job.Name.Value + "` is missing script")
-					}
-					if len(job.Script.Value) == 0 {
-						return *job.Script.Location, errors.New("syntax error: empty job script")
-					}
+				jobNodes = append(jobNodes, valueNode.Content...)
+			}
+		}
+	}
 
-					config.Stages.Value[job.Stage.Value].Value[job.Name.Value] = &job
+	for _, jobNode := range jobNodes {
+		var job JobConfiguration
+		// parse
+		parseJobConfig(jobNode, &job)
+		// validate format, a job with any problem is left out of its stage
+		if parseJobProblems(config, &job, &YAMLFileLocation{Line: jobNode.Line, Column: jobNode.Column}, diagnostics) {
+			// still known by name, so that its stage and dependents are not reported too
+			if job.Name != nil && !isInvalidString(job.Name.Value) && job.Stage != nil && config.Stages != nil && config.Stages.Value[job.Stage.Value] != nil {
+				if config.invalidJobs == nil {
+					config.invalidJobs = make(map[string]map[string]bool)
+				}
+				if config.invalidJobs[job.Stage.Value] == nil {
+					config.invalidJobs[job.Stage.Value] = make(map[string]bool)
 				}
+				config.invalidJobs[job.Stage.Value][job.Name.Value] = true
 			}
+			continue
 		}
+		config.Stages.Value[job.Stage.Value].Value[job.Name.Value] = &job
 	}
-	return YAMLFileLocation{}, nil
 }
 
-// parsePipelineInfo extracts pipeline details
-func parsePipelineInfo(node *yaml.Node, pipeline *PipelineInfo) {
+// parseJobProblems records the problems of a single job, returning true if there were any
+func parseJobProblems(config *PipelineConfiguration, job *JobConfiguration, location *YAMLFileLocation, diagnostics *Diagnostics) bool {
+	count := len(*diagnostics)
+
+	// Name
+	if job.Name == nil {
+		diagnostics.add(location, "syntax error: missing job name")
+		return true
+	}
+	if isInvalidString(job.Name.Value) {
+		diagnostics.add(job.Name.Location, "syntax error: job name must be a non-empty string")
+		return true
+	}
+	// Stage
+	if job.Stage == nil {
+		diagnostics.add(job.Name.Location, "syntax error: job `"+job.Name.Value+"` is missing stage")
+	} else if isInvalidString(job.Stage.Value) {
+		diagnostics.add(job.Stage.Location, "syntax error: job stage must be a non-empty string")
+	} else if config.Stages == nil {
+		// reported once by ValidateConfiguration
+		return true
+	} else if config.Stages.Value[job.Stage.Value] == nil {
+		diagnostics.add(job.Stage.Location, "syntax error: stage `"+job.Stage.Value+"` must be defined in stages")
+	} else if config.Stages.Value[job.Stage.Value].Value[job.Name.Value] != nil {
+		diagnostics.add(job.Name.Location, "syntax error: duplicated job name within a stage")
+	}
+	// Image
+	if job.Image == nil {
+		diagnostics.add(job.Name.Location, "syntax error: job `"+job.Name.Value+"` is missing image")
+	} else if isInvalidString(job.Image.Value) {
+		diagnostics.add(job.Image.Location, "syntax error: job image must be a non-empty string")
+	}
+	// Script
+	if job.Script == nil {
+		diagnostics.add(job.Name.Location, "syntax error: job `"+job.Name.Value+"` is missing script")
+	} else if len(job.Script.Value) == 0 {
+		diagnostics.add(job.Script.Location, "syntax error: empty job script")
+	}
+
+	return len(*diagnostics) > count
+}
+
+// parsePipelineInfo extracts pipeline details, recording values of the wrong type
+func parsePipelineInfo(node *yaml.Node, pipeline *PipelineInfo, diagnostics *Diagnostics) {
 	if node.Kind != yaml.MappingNode {
 		fmt.Println("Expected a mapping node for pipeline")
 		return
@@ -137,6 +160,28 @@ func parsePipelineInfo(node *yaml.Node, pipeline *PipelineInfo) {
 		switch keyNode.Value {
 		case "name":
 			pipeline.Name = &ConfigurationNode[string]{Value: valueNode.Value, Location: &YAMLFileLocation{Line: keyNode.Line, Column: keyNode.Column}}
+		case "priority":
+			pipeline.Priority = &ConfigurationNode[string]{Value: valueNode.Value, Location: &YAMLFileLocation{Line: keyNode.Line, Column: keyNode.Column}}
+		case "timeout":
+			pipeline.Timeout = &ConfigurationNode[string]{Value: valueNode.Value, Location: &YAMLFileLocation{Line: keyNode.Line, Column: keyNode.Column}}
+		case "concurrency_group":
+			pipeline.ConcurrencyGroup = &ConfigurationNode[string]{Value: valueNode.Value, Location: &YAMLFileLocation{Line: keyNode.Line, Column: keyNode.Column}}
+		case "concurrency":
+			location := &YAMLFileLocation{Line: keyNode.Line, Column: keyNode.Column}
+			var concurrency int
+			if err := valueNode.Decode(&concurrency); err != nil || concurrency < 1 {
+				diagnostics.add(location, "syntax error: pipeline concurrency must be a positive integer")
+				continue
+			}
+			pipeline.Concurrency = &ConfigurationNode[int]{Value: concurrency, Location: location}
+		case "cancel_in_progress":
+			location := &YAMLFileLocation{Line: keyNode.Line, Column: keyNode.Column}
+			var cancelInProgress bool
+			if err := valueNode.Decode(&cancelInProgress); err != nil {
+				diagnostics.add(location, "syntax error: pipeline cancel_in_progress must be `true` or `false`")
+				continue
+			}
+			pipeline.CancelInProgress = &ConfigurationNode[bool]{Value: cancelInProgress, Location: location}
 		}
 	}
 }
@@ -177,59 +222,106 @@ func parseJobConfig(node *yaml.Node, job *JobConfiguration) {
 	}
 }
 
-// Reads YAML file then parse to Pipeline
-func ParseYAMLFile(filename string) (*PipelineConfiguration, YAMLFileLocation, error) {
+/*
+Reads YAML file then parse to Pipeline.
+Configuration problems are returned together as Diagnostics alongside the partially parsed pipeline,
+jobs with problems are left out of it.
+*/
+func ParseYAMLFile(filename string) (*PipelineConfiguration, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
-		return nil, YAMLFileLocation{}, err
+		return nil, err
 	}
+	return ParseYAML(data)
+}
 
+// Parse YAML content to Pipeline, see ParseYAMLFile
+func ParseYAML(data []byte) (*PipelineConfiguration, error) {
 	var pipeline PipelineConfiguration
 	var root yaml.Node
 
 	if err := yaml.Unmarshal(data, &root); err != nil {
-		return nil, YAMLFileLocation{}, err
+		return nil, Diagnostics{yamlDiagnostic(err)}
+	}
+
+	var diagnostics Diagnostics
+	parsePipelineConfig(&root, &pipeline, &diagnostics)
+	return &pipeline, diagnostics.err()
+}
+
+/*
+Parse and validate a pipeline configuration file.
+Problems from both steps are returned together as Diagnostics sorted by location.
+*/
+func LoadPipelineConfiguration(filename string) (*PipelineConfiguration, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return LoadPipelineConfigurationData(data)
+}
+
+// Parse and validate YAML content, see LoadPipelineConfiguration
+func LoadPipelineConfigurationData(data []byte) (*PipelineConfiguration, error) {
+	pipeline, err := ParseYAML(data)
+	if pipeline == nil {
+		return nil, err
 	}
 
-	location, validateErr := parsePipelineConfig(&root, &pipeline)
-	if validateErr != nil {
-		return nil, location, validateErr
+	var diagnostics Diagnostics
+	if err != nil {
+		diagnostics = append(diagnostics, err.(Diagnostics)...)
+	}
+	if err := pipeline.ValidateConfiguration(); err != nil {
+		diagnostics = append(diagnostics, err.(Diagnostics)...)
 	}
+	return pipeline, diagnostics.err()
+}
 
-	return &pipeline, YAMLFileLocation{}, nil
+// Whether jobs of a stage were left out of it for their problems, see ParseYAML
+func (pipeline *PipelineConfiguration) HasInvalidJobs(stage string) bool {
+	return len(pipeline.invalidJobs[stage]) > 0
 }
 
-// Validate Pipeline configuration
-func (pipeline *PipelineConfiguration) ValidateConfiguration() (YAMLFileLocation, error) {
+// Validate Pipeline configuration, returning every problem found as Diagnostics
+func (pipeline *PipelineConfiguration) ValidateConfiguration() error {
+	var diagnostics Diagnostics
+
 	// Validate version
 	if pipeline.Version == nil {
-		return YAMLFileLocation{}, errors.New("syntax error: missing key `version`")
-	}
-	if pipeline.Version.Value != "v0" {
-		return YAMLFileLocation{}, errors.New("syntax error: invalid version")
+		diagnostics.add(nil, "syntax error: missing key `version`")
+	} else if pipeline.Version.Value != "v0" {
+		diagnostics.add(pipeline.Version.Location, "syntax error: invalid version")
 	}
 
 	// Validate pipeline info
 	if pipeline.Pipeline == nil {
-		return YAMLFileLocation{}, errors.New("syntax error: missing key `pipeline`")
+		diagnostics.add(nil, "syntax error: missing key `pipeline`")
+	} else if name := pipeline.Pipeline.Value.Name; name == nil {
+		diagnostics.add(pipeline.Pipeline.Location, "syntax error: pipeline name is required")
+	} else if isInvalidString(name.Value) {
+		diagnostics.add(name.Location, "syntax error: pipeline name is required")
 	}
-	if isInvalidString(pipeline.Pipeline.Value.Name.Value) {
-		return *pipeline.Pipeline.Value.Name.Location, errors.New("syntax error: pipeline name is required")
+	if pipeline.Pipeline != nil {
+		if priority := pipeline.Pipeline.Value.Priority; priority != nil && !IsValidPriority(priority.Value) {
+			diagnostics.add(priority.Location, "syntax error: pipeline priority must be `high`, `normal` or `low`")
+		}
+		if timeout := pipeline.Pipeline.Value.Timeout; timeout != nil && !IsValidTimeout(timeout.Value) {
+			diagnostics.add(timeout.Location, "syntax error: pipeline timeout must be a positive duration, e.g. `30m`")
+		}
+		if group := pipeline.Pipeline.Value.ConcurrencyGroup; group != nil && isInvalidString(group.Value) {
+			diagnostics.add(group.Location, "syntax error: pipeline concurrency_group must be a non-empty string")
+		}
 	}
 
 	// Validate stages and jobs
 	// Check stages
 	if pipeline.Stages == nil {
-		return YAMLFileLocation{}, errors.New("syntax error: missing key `stages`")
+		diagnostics.add(nil, "syntax error: missing key `stages`")
+		return diagnostics.err()
 	}
 	if len(pipeline.Stages.Value) == 0 {
-		return *pipeline.Stages.Location, errors.New("syntax error: stages must have at least one item")
-	}
-
-	for stage, jobs := range pipeline.Stages.Value {
-		if len(jobs.Value) == 0 {
-			return *jobs.Location, errors.New("syntax error: stage `" + stage + "` has no jobs")
-		}
+		diagnostics.add(pipeline.Stages.Location, "syntax error: stages must have at least one item")
 	}
 
 	// Validate logic
@@ -237,12 +329,16 @@ func (pipeline *PipelineConfiguration) ValidateConfiguration() (YAMLFileLocation
 	for stage, jobs := range pipeline.Stages.Value {
 		// check stages with empty jobs
 		if len(jobs.Value) == 0 {
-			return *pipeline.Stages.Location, errors.New("syntax error: stage `" + stage + "` must have at least one job")
+			if !pipeline.HasInvalidJobs(stage) {
+				diagnostics.add(jobs.Location, "syntax error: stage `"+stage+"` has no jobs")
+			}
+			continue
 		}
 
 		pipeline.ExecOrder[stage] = make([][]string, 0)
 		indegrees := make(map[string]int)
 		graph := make(map[string][]string)
+		missingDependency := false
 		for name, job := range jobs.Value {
 			if indegrees[name] == 0 {
 				indegrees[name] = 0
@@ -252,13 +348,22 @@ func (pipeline *PipelineConfiguration) ValidateConfiguration() (YAMLFileLocation
 				for _, dependency := range job.Dependencies.Value {
 					// dependency job not exist
 					if jobs.Value[dependency] == nil {
-						return *job.Dependencies.Location, errors.New("syntax error: dependency job not exist")
+						// an invalid job is reported on its own
+						if !pipeline.invalidJobs[stage][dependency] {
+							diagnostics.add(job.Dependencies.Location, "syntax error: dependency job `"+dependency+"` not exist")
+						}
+						missingDependency = true
+						continue
 					}
 					indegrees[job.Name.Value] += 1
 					graph[dependency] = append(graph[dependency], job.Name.Value)
 				}
 			}
 		}
+		// cycles can only be traced once every dependency resolves
+		if missingDependency {
+			continue
+		}
 
 		// Execution order
 		var parallel [][]string
@@ -266,13 +371,14 @@ func (pipeline *PipelineConfiguration) ValidateConfiguration() (YAMLFileLocation
 		// check cyclic dependencies among jobs within a stage
 		hasCycle, location, validateErr := detectCycle(&parallel, &indegrees, jobs.Value, graph)
 		if hasCycle && validateErr != nil {
-			return location, validateErr
+			diagnostics.add(&location, validateErr.Error())
+			continue
 		}
 
 		pipeline.ExecOrder[stage] = parallel
 	}
 
-	return *pipeline.Version.Location, nil
+	return diagnostics.err()
 }
 
 /*
@@ -2,6 +2,7 @@ package schema_test
 
 import (
 	"cicd/pipeci/cmd"
+	"cicd/pipeci/schema"
 	"os"
 	"strings"
 	"testing"
@@ -73,3 +74,167 @@ func TestInvalidConfigFile(t *testing.T) {
 	// Job Missing Stage
 	testWrongConfigFile(t, "./.pipelines/test/job_missing_stage.yaml", "job `test` is missing stage")
 }
+
+/*
+All problems are reported together, sorted by location.
+*/
+func TestInvalidConfigFile_MultipleErrors(t *testing.T) {
+	_, err := schema.LoadPipelineConfiguration("../../.pipelines/test/multiple_errors.yaml")
+	diagnostics, ok := err.(schema.Diagnostics)
+	if !ok {
+		t.Fatalf("expected diagnostics but got %v", err)
+	}
+
+	expected := []string{
+		"multiple_errors.yaml:1:1: syntax error: invalid version",
+		"multiple_errors.yaml:11:5: syntax error: job `compile` is missing image",
+		"multiple_errors.yaml:21:5: syntax error: dependency job `not-a-job` not exist",
+		"multiple_errors.yaml:25:5: syntax error: stage `deploy` must be defined in stages",
+	}
+	if got := diagnostics.Format("multiple_errors.yaml"); got != strings.Join(expected, "\n") {
+		t.Errorf("unexpected diagnostics:\n%s", got)
+	}
+}
+
+/*
+A stage whose jobs are all invalid is not reported as empty.
+*/
+func TestInvalidConfigFile_InvalidJobOnly(t *testing.T) {
+	_, err := schema.LoadPipelineConfiguration("../../.pipelines/test/invalid_job_only.yaml")
+	diagnostics, ok := err.(schema.Diagnostics)
+	if !ok {
+		t.Fatalf("expected diagnostics but got %v", err)
+	}
+	if got := diagnostics.Format("invalid_job_only.yaml"); got != "invalid_job_only.yaml:10:5: syntax error: job `compile` is missing image" {
+		t.Errorf("unexpected diagnostics:\n%s", got)
+	}
+}
+
+/*
+Pipeline priority is optional, and one of high, normal or low.
+*/
+func TestPipelinePriority(t *testing.T) {
+	config := func(priority string) []byte {
+		return []byte(`version: v0
+pipeline:
+  name: deploy
+` + priority + `
+stages:
+  - deploy
+jobs:
+  - name: release
+    stage: deploy
+    image: alpine
+    script:
+      - echo release
+`)
+	}
+
+	pipeline, err := schema.LoadPipelineConfigurationData(config(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pipeline.Pipeline.Value.Priority != nil {
+		t.Errorf("expected no priority but got %v", pipeline.Pipeline.Value.Priority.Value)
+	}
+
+	pipeline, err = schema.LoadPipelineConfigurationData(config("  priority: high"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := pipeline.Pipeline.Value.Priority.Value; got != schema.PriorityHigh {
+		t.Errorf("expected priority `high` but got %v", got)
+	}
+
+	_, err = schema.LoadPipelineConfigurationData(config("  priority: urgent"))
+	if err == nil || !strings.Contains(err.Error(), "4:3: syntax error: pipeline priority must be `high`, `normal` or `low`") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+/*
+Concurrency settings are optional, the limit is a positive integer and cancel_in_progress a boolean.
+*/
+func TestPipelineConcurrency(t *testing.T) {
+	config := func(concurrency string) []byte {
+		return []byte(`version: v0
+pipeline:
+  name: deploy
+` + concurrency + `
+stages:
+  - deploy
+jobs:
+  - name: release
+    stage: deploy
+    image: alpine
+    script:
+      - echo release
+`)
+	}
+
+	pipeline, err := schema.LoadPipelineConfigurationData(config(""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info := pipeline.Pipeline.Value
+	if info.ConcurrencyGroup != nil || info.Concurrency != nil || info.CancelInProgress != nil {
+		t.Errorf("expected no concurrency settings but got %+v", info)
+	}
+
+	pipeline, err = schema.LoadPipelineConfigurationData(config("  concurrency_group: production\n  concurrency: 2\n  cancel_in_progress: true"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info = pipeline.Pipeline.Value
+	if info.ConcurrencyGroup.Value != "production" || info.Concurrency.Value != 2 || !info.CancelInProgress.Value {
+		t.Errorf("unexpected concurrency settings %v %v %v", info.ConcurrencyGroup.Value, info.Concurrency.Value, info.CancelInProgress.Value)
+	}
+
+	for setting, expected := range map[string]string{
+		"  concurrency: 0":           "4:3: syntax error: pipeline concurrency must be a positive integer",
+		"  concurrency: two":         "4:3: syntax error: pipeline concurrency must be a positive integer",
+		"  cancel_in_progress: soon": "4:3: syntax error: pipeline cancel_in_progress must be `true` or `false`",
+		"  concurrency_group: \"\"":  "4:3: syntax error: pipeline concurrency_group must be a non-empty string",
+	} {
+		_, err = schema.LoadPipelineConfigurationData(config(setting))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s: unexpected error: %v", setting, err)
+		}
+	}
+}
+
+/*
+Pipeline timeout is optional, and a positive duration.
+*/
+func TestPipelineTimeout(t *testing.T) {
+	config := func(timeout string) []byte {
+		return []byte(`version: v0
+pipeline:
+  name: deploy
+` + timeout + `
+stages:
+  - deploy
+jobs:
+  - name: release
+    stage: deploy
+    image: alpine
+    script:
+      - echo release
+`)
+	}
+
+	pipeline, err := schema.LoadPipelineConfigurationData(config("  timeout: 1h30m"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := pipeline.Pipeline.Value.Timeout.Value; got != "1h30m" {
+		t.Errorf("expected timeout `1h30m` but got %v", got)
+	}
+
+	for _, timeout := range []string{"  timeout: 30", "  timeout: -5m", "  timeout: soon"} {
+		_, err = schema.LoadPipelineConfigurationData(config(timeout))
+		if err == nil || !strings.Contains(err.Error(), "4:3: syntax error: pipeline timeout must be a positive duration, e.g. `30m`") {
+			t.Errorf("%s: unexpected error: %v", timeout, err)
+		}
+	}
+}
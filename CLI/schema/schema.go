@@ -1,5 +1,7 @@
 package schema
 
+import "time"
+
 // Location of ConfigurationNode in YAML file.
 type YAMLFileLocation struct {
 	Line   int
@@ -28,7 +30,34 @@ type JobConfiguration struct {
 
 // Pipeline identifier info.
 type PipelineInfo struct {
-	Name *ConfigurationNode[string] // (required) Name of pipeline.
+	Name     *ConfigurationNode[string] // (required) Name of pipeline.
+	Priority *ConfigurationNode[string] // (optional) Queue priority: high, normal or low. Defaults to normal.
+	// (optional) Runs of a repository in the same group share the Concurrency limit. Defaults to the pipeline name.
+	ConcurrencyGroup *ConfigurationNode[string]
+	// (optional) Maximum concurrent runs of the group per repository. Defaults to 1 with a group, unlimited otherwise.
+	Concurrency *ConfigurationNode[int]
+	// (optional) Cancel the runs in progress of the group instead of waiting for them to finish.
+	CancelInProgress *ConfigurationNode[bool]
+	// (optional) Maximum duration of a run, e.g. `30m`. Runs past it are stopped by the operator.
+	Timeout *ConfigurationNode[string]
+}
+
+// Pipeline priorities, runs of higher priority are dequeued first.
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// Check if a priority is one of high, normal or low.
+func IsValidPriority(priority string) bool {
+	return priority == PriorityHigh || priority == PriorityNormal || priority == PriorityLow
+}
+
+// Check if a timeout is a positive duration such as `90s`, `30m` or `1h30m`.
+func IsValidTimeout(timeout string) bool {
+	duration, err := time.ParseDuration(timeout)
+	return err == nil && duration > 0
 }
 
 // Pipeline configuration
@@ -43,6 +72,8 @@ type PipelineConfiguration struct {
 	*/
 	StageOrder []string
 	ExecOrder  map[string][][]string
+
+	invalidJobs map[string]map[string]bool // Names of the jobs left out of each stage for their problems
 }
 
 // GitHub repository configuration
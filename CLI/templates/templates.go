@@ -0,0 +1,111 @@
+package templates
+
+import (
+	"bytes"
+	"cicd/pipeci/schema"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Starter pipeline configurations, one file per template
+//
+//go:embed library/*.yaml
+var library embed.FS
+
+// Template used when the project type cannot be detected
+const Generic = "generic"
+
+// Project marker files and the template they select, checked in order
+var markers = []struct {
+	File     string
+	Template string
+}{
+	{File: "go.mod", Template: "go"},
+	{File: "package.json", Template: "node"},
+	{File: "pom.xml", Template: "maven"},
+	{File: "pyproject.toml", Template: "python"},
+}
+
+// Characters kept in a pipeline name derived from a directory
+var unsafeNameCharacters = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// Values substituted into a template
+type Values struct {
+	PipelineName string
+}
+
+// Names of all embedded templates, sorted.
+func Names() []string {
+	entries, err := library.ReadDir("library")
+	if err != nil {
+		panic(err) // embedded at build time
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Detect the template of the project in a directory from its marker files.
+func Detect(directory string) string {
+	for _, marker := range markers {
+		if _, err := os.Stat(filepath.Join(directory, marker.File)); err == nil {
+			return marker.Template
+		}
+	}
+	return Generic
+}
+
+/*
+Pipeline name derived from the project directory.
+Numeric names are rejected by the schema, so they are prefixed.
+*/
+func PipelineName(directory string) string {
+	name := unsafeNameCharacters.ReplaceAllString(strings.ToLower(filepath.Base(directory)), "-")
+	name = strings.Trim(name, "-.")
+	if name == "" {
+		return "pipeline"
+	}
+	if _, err := strconv.ParseFloat(name, 64); err == nil {
+		return "pipeline-" + name
+	}
+	return name
+}
+
+/*
+Render a template into a pipeline configuration.
+The result is validated so a broken template or value never reaches the repository.
+*/
+func Render(name string, values Values) ([]byte, error) {
+	content, err := library.ReadFile("library/" + name + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("unknown template `%s`, available templates: %s", name, strings.Join(Names(), ", "))
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, values); err != nil {
+		return nil, err
+	}
+
+	if _, err := schema.LoadPipelineConfigurationData(out.Bytes()); err != nil {
+		if diagnostics, ok := err.(schema.Diagnostics); ok {
+			return nil, fmt.Errorf("template `%s` is not a valid pipeline configuration:\n%s", name, diagnostics.Format(name+".yaml"))
+		}
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
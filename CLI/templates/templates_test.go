@@ -0,0 +1,51 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, []string{"generic", "go", "maven", "node", "python"}, Names())
+}
+
+func TestDetect(t *testing.T) {
+	directory := t.TempDir()
+	assert.Equal(t, Generic, Detect(directory))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(directory, "pyproject.toml"), []byte(""), 0644))
+	assert.Equal(t, "python", Detect(directory))
+
+	// go.mod is checked first
+	assert.NoError(t, os.WriteFile(filepath.Join(directory, "go.mod"), []byte("module example"), 0644))
+	assert.Equal(t, "go", Detect(directory))
+}
+
+func TestRender_AllTemplatesValid(t *testing.T) {
+	for _, name := range Names() {
+		content, err := Render(name, Values{PipelineName: "my-project"})
+		assert.NoError(t, err, name)
+		assert.Contains(t, string(content), "name: my-project", name)
+	}
+}
+
+func TestRender_UnknownTemplate(t *testing.T) {
+	_, err := Render("cobol", Values{PipelineName: "my-project"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown template `cobol`")
+}
+
+func TestRender_InvalidValues(t *testing.T) {
+	_, err := Render("go", Values{PipelineName: "123"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "pipeline name is required")
+}
+
+func TestPipelineName(t *testing.T) {
+	assert.Equal(t, "my-project", PipelineName("/home/me/My Project"))
+	assert.Equal(t, "pipeline-001", PipelineName("/tmp/001"))
+	assert.Equal(t, "pipeline", PipelineName("/"))
+}
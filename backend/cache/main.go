@@ -78,6 +78,34 @@ func GetWithDefault(ctx context.Context, key, defaultValue string) (string, erro
 	return val, nil
 }
 
+/*
+GetOrLoad reads a value through the cache: on a miss, or when Redis is unavailable,
+the value is loaded from the source of truth and cached for `expiration`.
+*/
+func GetOrLoad(ctx context.Context, key string, expiration time.Duration, load func() (string, error)) (string, error) {
+	if instance != nil {
+		val, err := instance.Get(ctx, key).Result()
+		if err == nil {
+			return val, nil
+		}
+		if err != redis.Nil {
+			log.Printf("Cache unavailable for key %s, loading from source: %v", key, err)
+		}
+	}
+
+	val, err := load()
+	if err != nil {
+		return "", err
+	}
+
+	if instance != nil {
+		if err := instance.Set(ctx, key, val, expiration).Err(); err != nil {
+			log.Printf("Failed to cache key %s: %v", key, err)
+		}
+	}
+	return val, nil
+}
+
 // Close cleans up the Redis connection
 func Close() error {
 	return instance.Close()
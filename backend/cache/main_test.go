@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -125,6 +126,80 @@ func TestSetWithExpiration(t *testing.T) {
 	})
 }
 
+func TestGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	key := "test_read_through_key"
+	defer instance.Del(ctx, key)
+
+	loads := 0
+	load := func() (string, error) {
+		loads++
+		return "42", nil
+	}
+
+	t.Run("miss loads and caches", func(t *testing.T) {
+		val, err := GetOrLoad(ctx, key, time.Minute, load)
+		require.NoError(t, err)
+		assert.Equal(t, "42", val)
+		assert.Equal(t, 1, loads)
+
+		cached, err := Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, "42", cached)
+	})
+
+	t.Run("hit skips the source", func(t *testing.T) {
+		val, err := GetOrLoad(ctx, key, time.Minute, load)
+		require.NoError(t, err)
+		assert.Equal(t, "42", val)
+		assert.Equal(t, 1, loads, "Source should not be queried on a cache hit")
+	})
+
+	t.Run("source error is returned", func(t *testing.T) {
+		_, err := GetOrLoad(ctx, "test_missing_key", time.Minute, func() (string, error) {
+			return "", fmt.Errorf("not found")
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not found")
+	})
+}
+
+func TestPendingTaskPosition(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	defer func() {
+		for _, id := range []string{"test_nightly", "test_review", "test_deploy", "test_expired"} {
+			RemovePendingTask(ctx, id)
+		}
+	}()
+
+	require.NoError(t, AddPendingTask(ctx, "test_expired", "high", now.Add(-48*time.Hour)))
+	require.NoError(t, AddPendingTask(ctx, "test_nightly", "low", now))
+	require.NoError(t, AddPendingTask(ctx, "test_review", "", now.Add(time.Millisecond)))
+	require.NoError(t, AddPendingTask(ctx, "test_deploy", "high", now.Add(2*time.Millisecond)))
+
+	t.Run("higher priorities are ahead", func(t *testing.T) {
+		position, err := PendingTaskPosition(ctx, "test_deploy")
+		require.NoError(t, err)
+		assert.Equal(t, 1, position, "Expired tasks should be dropped")
+
+		position, err = PendingTaskPosition(ctx, "test_nightly")
+		require.NoError(t, err)
+		assert.Equal(t, 3, position)
+	})
+
+	t.Run("started tasks are not pending", func(t *testing.T) {
+		require.NoError(t, RemovePendingTask(ctx, "test_deploy"))
+		position, err := PendingTaskPosition(ctx, "test_deploy")
+		require.NoError(t, err)
+		assert.Equal(t, 0, position)
+
+		position, err = PendingTaskPosition(ctx, "test_review")
+		require.NoError(t, err)
+		assert.Equal(t, 1, position)
+	})
+}
+
 func TestClose(t *testing.T) {
 	// Create a new client to test Close without affecting the main instance
 	options := &redis.Options{
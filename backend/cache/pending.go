@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+/*
+Tasks waiting in the task queue are tracked in one sorted set per priority, scored by enqueue time,
+so that the queue position of a pending execution can be shown without consuming the queue.
+Workers remove a task when they start processing it.
+*/
+
+// Priorities in dequeue order
+var pendingPriorities = []string{"high", "normal", "low"}
+
+// Entries older than this are dropped, their message was lost or the worker failed to remove them
+const pendingTaskExpiration = 24 * time.Hour
+
+// Sorted set of the pending tasks of a priority
+func pendingTasksKey(priority string) string {
+	return "pending_tasks:" + priority
+}
+
+// Record a task about to be enqueued, priority is `high`, `normal` or `low`, normal when not set
+func AddPendingTask(ctx context.Context, executionId string, priority string, enqueuedAt time.Time) error {
+	if priority != "high" && priority != "low" {
+		priority = "normal"
+	}
+	expired := strconv.FormatInt(enqueuedAt.Add(-pendingTaskExpiration).UnixMilli(), 10)
+	for _, p := range pendingPriorities {
+		if err := instance.ZRemRangeByScore(ctx, pendingTasksKey(p), "-inf", "("+expired).Err(); err != nil {
+			return fmt.Errorf("failed to expire pending tasks: %w", err)
+		}
+	}
+
+	member := redis.Z{Score: float64(enqueuedAt.UnixMilli()), Member: executionId}
+	if err := instance.ZAdd(ctx, pendingTasksKey(priority), member).Err(); err != nil {
+		return fmt.Errorf("failed to add pending task %s: %w", executionId, err)
+	}
+	return nil
+}
+
+// Forget a task that was not enqueued or started processing
+func RemovePendingTask(ctx context.Context, executionId string) error {
+	for _, priority := range pendingPriorities {
+		if err := instance.ZRem(ctx, pendingTasksKey(priority), executionId).Err(); err != nil {
+			return fmt.Errorf("failed to remove pending task %s: %w", executionId, err)
+		}
+	}
+	return nil
+}
+
+/*
+1-based position of a pending task in the task queue, 0 when it is not pending.
+Tasks of higher priority are ahead, then older tasks of the same priority.
+*/
+func PendingTaskPosition(ctx context.Context, executionId string) (int, error) {
+	ahead := 0
+	for _, priority := range pendingPriorities {
+		key := pendingTasksKey(priority)
+		rank, err := instance.ZRank(ctx, key, executionId).Result()
+		if err == nil {
+			return ahead + int(rank) + 1, nil
+		}
+		if err != redis.Nil {
+			return 0, fmt.Errorf("failed to get pending task %s: %w", executionId, err)
+		}
+
+		count, err := instance.ZCard(ctx, key).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to count pending tasks: %w", err)
+		}
+		ahead += int(count)
+	}
+	return 0, nil
+}
@@ -1,6 +1,7 @@
 create database if not exists CicdApplication;
 use CicdApplication;
 
+drop table if exists Dependencies;
 drop table if exists Jobs;
 drop table if exists Stages;
 drop table if exists Pipelines;
@@ -19,8 +20,11 @@ CREATE TABLE Pipelines (
     status enum('SUCCESS', 'FAILED', 'CANCELED', 'PENDING'),		-- Pipeline execution status
     start_time timestamp not null default CURRENT_TIMESTAMP,        -- Pipeline execution start time
     end_time timestamp,						            			-- Pipeline execution end time
-    
-    constraint pk_Pipelines_pipeline_id  primary key (pipeline_id)
+
+    execution_id varchar(64) not null,                              -- Task UUID, a redelivered task resumes this execution
+
+    constraint pk_Pipelines_pipeline_id  primary key (pipeline_id),
+    constraint uq_Pipelines_execution_id unique (execution_id)
 );
 
 -- Stage's execution report
@@ -55,8 +59,11 @@ CREATE TABLE Jobs (
     end_time timestamp,						            			-- Pipeline execution end time
 
     container_id varchar(255) not null,                             -- Docker Container ID. Used to retrieve logs.
-    
+
+    execution_id varchar(64) not null,                              -- `job_<uuid>`, a redelivered job resumes this execution
+
     constraint pk_Jobs_job_id primary key (job_id),
+    constraint uq_Jobs_execution_id unique (execution_id),
     constraint fk_Jobs_stage_id foreign key (stage_id)
 		references Stages(stage_id)
         on update cascade
@@ -64,14 +71,21 @@ CREATE TABLE Jobs (
 );
 
 
--- -- Dependencies
--- CREATE TABLE Dependencies (
--- 	parent_id int,
---     child_id int,
--- 	PRIMARY KEY (parent_id, child_id),
---     FOREIGN KEY (parent_id) REFERENCES Jobs(job_id),
---     FOREIGN KEY (child_id) REFERENCES Jobs(job_id)
--- );
+-- Job dependency edges within a stage (parent must finish before child)
+CREATE TABLE Dependencies (
+	parent_id int,									                -- Parent Job Execution Id
+    child_id int,									                -- Child Job Execution Id
+
+    constraint pk_Dependencies primary key (parent_id, child_id),
+    constraint fk_Dependencies_parent_id foreign key (parent_id)
+		references Jobs(job_id)
+        on update cascade
+        on delete cascade,
+    constraint fk_Dependencies_child_id foreign key (child_id)
+		references Jobs(job_id)
+        on update cascade
+        on delete cascade
+);
 
 
 
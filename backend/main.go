@@ -35,12 +35,20 @@ func setupRouter() *gin.Engine {
 	// Report endpoints
 	router.POST("/report/local", routes.ReportPastExecutionsLocal_CurrentRepo)
 	router.POST("/report/local/query", routes.ReportPastExecutionsLocal_ByCondition)
+	router.POST("/report/local/graph", routes.ReportExecutionGraphLocal)
+	router.POST("/report/local/durations", routes.ReportJobDurationsLocal)
 
 	// Execute endpoints
 	router.POST("/execute/local", routes.ExecuteLocal)
 
 	// Status endpoints
 	router.POST("/status", routes.RequestExecutionStatus)
+	router.POST("/status/latest", routes.RequestLatestExecutionStatus)
+
+	// Admin endpoints
+	router.POST("/admin/dlq/list", routes.ListDeadLetters)
+	router.POST("/admin/dlq/replay", routes.ReplayDeadLetters)
+	router.POST("/admin/dlq/purge", routes.PurgeDeadLetters)
 
 	return router
 }
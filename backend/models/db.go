@@ -20,15 +20,16 @@ const (
 
 // Pipeline's execution report
 type Pipeline struct {
-	PipelineId int          `json:"pipeline_id" db:"pipeline_id"`
-	Repository string       `json:"repository" db:"repository"`
-	CommitHash string       `json:"commit_hash" db:"commit_hash"`
-	IPAddress  string       `json:"ip_address" db:"ip_address"`
-	Name       string       `json:"name" db:"name"`
-	StageOrder string       `json:"stage_order" db:"stage_order"`
-	Status     ExecStatus   `json:"status" db:"status"`
-	StartTime  time.Time    `json:"start_time" db:"start_time"`
-	EndTime    sql.NullTime `json:"end_time" db:"end_time"`
+	PipelineId  int          `json:"pipeline_id" db:"pipeline_id"`
+	Repository  string       `json:"repository" db:"repository"`
+	CommitHash  string       `json:"commit_hash" db:"commit_hash"`
+	IPAddress   string       `json:"ip_address" db:"ip_address"`
+	Name        string       `json:"name" db:"name"`
+	StageOrder  string       `json:"stage_order" db:"stage_order"`
+	Status      ExecStatus   `json:"status" db:"status"`
+	StartTime   time.Time    `json:"start_time" db:"start_time"`
+	EndTime     sql.NullTime `json:"end_time" db:"end_time"`
+	ExecutionId string       `json:"execution_id" db:"execution_id"`
 }
 
 // Stage's execution report
@@ -52,6 +53,7 @@ type Job struct {
 	StartTime   time.Time    `json:"start_time" db:"start_time"`
 	EndTime     sql.NullTime `json:"end_time" db:"end_time"`
 	ContainerId string       `json:"container_id" db:"container_id"`
+	ExecutionId string       `json:"execution_id" db:"execution_id"`
 }
 
 // Dependencies
@@ -59,3 +61,10 @@ type Dependency struct {
 	ParentId int `json:"parent_id" db:"parent_id"`
 	ChildId  int `json:"child_id" db:"child_id"`
 }
+
+// Average duration of successful runs of a job
+type JobDuration struct {
+	StageName      string  `json:"stage_name" db:"stage_name"`
+	JobName        string  `json:"job_name" db:"job_name"`
+	AverageSeconds float64 `json:"average_seconds" db:"average_seconds"`
+}
@@ -99,3 +99,13 @@ func TestRepository(t *testing.T) {
 	assert.Equal(t, "https://github.com/example/repo.git", repo.Url)
 	assert.Equal(t, "abc123", repo.CommitHash)
 }
+
+func TestPipelineConfiguration_Priority(t *testing.T) {
+	assert.Equal(t, "", PipelineConfiguration{}.Priority())
+
+	pipeline := PipelineConfiguration{Pipeline: &ConfigurationNode[PipelineInfo]{Value: PipelineInfo{
+		Name:     &ConfigurationNode[string]{Value: "deploy"},
+		Priority: &ConfigurationNode[string]{Value: "high"},
+	}}}
+	assert.Equal(t, "high", pipeline.Priority())
+}
@@ -0,0 +1,60 @@
+package routes
+
+import (
+	types "cicd/pipeci/backend/types"
+	"cicd/pipeci/queue"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+/* Run a dead-letter queue operation on the broker holding the requested queue */
+func withDeadLetterQueue(c *gin.Context, operation func(q queue.Queue, body types.DeadLetter_RequestBody) (gin.H, error)) {
+	var body types.DeadLetter_RequestBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	q, err := queue.OpenQueueBroker(body.Queue)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	defer q.Close()
+
+	result, err := operation(q, body)
+	if err != nil {
+		log.Printf("DeadLetterQueue %v", err)
+		c.IndentedJSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	result["success"] = true
+	result["queue"] = queue.DeadLetterQueueName(body.Queue)
+	c.IndentedJSON(http.StatusOK, result)
+}
+
+/* List dead messages of a queue */
+func ListDeadLetters(c *gin.Context) {
+	withDeadLetterQueue(c, func(q queue.Queue, body types.DeadLetter_RequestBody) (gin.H, error) {
+		deadLetters, err := queue.ListDeadLetters(q, body.Queue, body.Limit)
+		return gin.H{"messages": deadLetters}, err
+	})
+}
+
+/* Move dead messages back to their queue */
+func ReplayDeadLetters(c *gin.Context) {
+	withDeadLetterQueue(c, func(q queue.Queue, body types.DeadLetter_RequestBody) (gin.H, error) {
+		replayed, err := queue.ReplayDeadLetters(c.Request.Context(), q, body.Queue, body.Limit)
+		return gin.H{"count": replayed}, err
+	})
+}
+
+/* Delete dead messages of a queue */
+func PurgeDeadLetters(c *gin.Context) {
+	withDeadLetterQueue(c, func(q queue.Queue, body types.DeadLetter_RequestBody) (gin.H, error) {
+		purged, err := queue.PurgeDeadLetters(q, body.Queue)
+		return gin.H{"count": purged}, err
+	})
+}
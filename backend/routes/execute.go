@@ -1,40 +1,44 @@
 package routes
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"time"
 
 	// DockerService "cicd/pipeci/backend/containers/docker"
-	queue "cicd/pipeci/backend/queue"
+	"cicd/pipeci/backend/cache"
 	types "cicd/pipeci/backend/types"
+	"cicd/pipeci/queue"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-/* Enqueue task into pipeline_queue */
-func enqueue(body types.ExecuteLocal_RequestBody) (string, error) {
-	// Connect to RabbitMQ
-	conn, ch, err := queue.ConnectRabbitMQ()
-	if err != nil {
-		return "", err
-	}
-	defer conn.Close()
-	defer ch.Close()
-
-	// Declare the queue
-	q, err := queue.DeclareQueue(ch)
+/* Enqueue task into pipeline_queue, the connection to the broker is shared by every request */
+func enqueue(ctx context.Context, body types.ExecuteLocal_RequestBody) (string, error) {
+	publisher, queueName, err := queue.TaskPublisher()
 	if err != nil {
 		return "", err
 	}
 
 	// Generate UUID as Task ID
 	taskId := uuid.New()
-	task := queue.Task{Id: taskId.String(), Message: body}
-	if err := queue.EnqueueTask(ch, q.Name, task); err != nil {
+	task := types.Task{Id: taskId.String(), Message: body}
+	priority := body.Pipeline.Priority()
+
+	// Recorded first, the task may be picked up as soon as it is published
+	if err := cache.AddPendingTask(ctx, task.Id, priority, time.Now()); err != nil {
+		log.Printf("Queue position of task %v is not tracked: %v", task.Id, err)
+	}
+	if err := queue.Enqueue(ctx, publisher, queueName, task, queue.MessagePriority(priority)); err != nil {
 		log.Printf("Error enqueuing task: %v", err)
+		if err := cache.RemovePendingTask(context.Background(), task.Id); err != nil {
+			log.Printf("%v", err)
+		}
 		return "", err
 	}
+	log.Printf("Task enqueued: %v", task.Id)
 
 	return taskId.String(), nil
 }
@@ -47,7 +51,7 @@ func ExecuteLocal(c *gin.Context) {
 		return
 	}
 
-	taskId, err := enqueue(body)
+	taskId, err := enqueue(c.Request.Context(), body)
 
 	// err = DockerService.Execute(body.Pipeline, body.Repository)
 	if err != nil {
@@ -3,11 +3,13 @@ package routes
 import (
 	"cicd/pipeci/backend/db"
 	"cicd/pipeci/backend/models"
+	DependencyService "cicd/pipeci/backend/services/dependency"
 	JobService "cicd/pipeci/backend/services/job"
 	PipelineService "cicd/pipeci/backend/services/pipeline"
 	StageService "cicd/pipeci/backend/services/stage"
 	"cicd/pipeci/backend/types"
 	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
 
@@ -87,6 +89,80 @@ func ReportPastExecutionsLocal_ByCondition(c *gin.Context) {
 	log.Println("ReportPastExecutionsLocal_ByCondition: Done report summary!")
 }
 
+/* Report the job dependency graph of a single pipeline run */
+func ReportExecutionGraphLocal(c *gin.Context) {
+	var body types.ReportPastExecutionsLocal_CurrentRepo_RequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		return
+	}
+	initStatusServices()
+
+	// SQL Filter
+	pipelineFilters := map[string]interface{}{"repository": body.Repository.Url, "ip_address": body.IPAddress}
+	if body.Repository.CommitHash != "" {
+		pipelineFilters["commit_hash"] = body.Repository.CommitHash
+	}
+	if body.PipelineName != "" {
+		pipelineFilters["name"] = body.PipelineName
+	}
+
+	pipelines, err := pipelineService.QueryPipelines(pipelineFilters)
+	if err != nil {
+		requestExecutionStatusError(c, err)
+		return
+	}
+
+	pipeline, err := selectPipelineRun(pipelines, body.RunCounter)
+	if err != nil {
+		requestExecutionStatusError(c, err)
+		return
+	}
+
+	response, err := getExecutionStatus(pipeline)
+	if err != nil {
+		requestExecutionStatusError(c, err)
+	} else {
+		c.IndentedJSON(http.StatusOK, response)
+	}
+	log.Println("ReportExecutionGraphLocal: Done report graph!")
+}
+
+/* Report average job durations of past successful runs of a pipeline */
+func ReportJobDurationsLocal(c *gin.Context) {
+	var body types.ReportPastExecutionsLocal_CurrentRepo_RequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		return
+	}
+
+	var jobService = JobService.NewJobService(db.Instance)
+	durations, err := jobService.GetAverageJobDurations(body.Repository.Url, body.PipelineName)
+	if err != nil {
+		log.Printf("ReportJobDurationsLocal %v", err)
+		c.IndentedJSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+	} else {
+		c.IndentedJSON(http.StatusOK, durations)
+	}
+}
+
+/*
+Select the i-th run from pipelines ordered by start time.
+Run counter starts from 1. The latest run is selected if runCounter is not set.
+*/
+func selectPipelineRun(pipelines []models.Pipeline, runCounter int) (models.Pipeline, error) {
+	if len(pipelines) == 0 {
+		return models.Pipeline{}, fmt.Errorf("no executions detected")
+	}
+	if runCounter <= 0 {
+		return pipelines[len(pipelines)-1], nil
+	}
+	if runCounter > len(pipelines) {
+		return models.Pipeline{}, fmt.Errorf("run %d does not exist, only %d runs found", runCounter, len(pipelines))
+	}
+	return pipelines[runCounter-1], nil
+}
+
 // ------------ QUERY DATABASE FOR EXECUTION REPORTS ---------------- //
 /* Get execution reports for pipeline */
 func gatherPipelineReport(c *gin.Context, pipelineFilters map[string]interface{}) {
@@ -140,6 +216,7 @@ func gatherJobReport(c *gin.Context, pipelineFilters, stageFilters, jobFilters m
 	var pipelineService = PipelineService.NewPipelineService(db.Instance)
 	var stageService = StageService.NewStageService(db.Instance)
 	var jobService = JobService.NewJobService(db.Instance)
+	var dependencyService = DependencyService.NewDependencyService(db.Instance)
 	var reports []types.Report_ResponseBody
 	var err error
 
@@ -166,7 +243,7 @@ func gatherJobReport(c *gin.Context, pipelineFilters, stageFilters, jobFilters m
 					log.Printf("gatherJobReport %v", err)
 					c.IndentedJSON(http.StatusBadRequest, gin.H{"success": false})
 				} else {
-					parseJobReports(jobs, &reports)
+					parseJobReports(jobs, dependencyService, &reports)
 				}
 			}
 		}
@@ -216,7 +293,7 @@ func parseStageReports(stages []models.Stage, reports *[]types.Report_ResponseBo
 }
 
 /* Parse jobs and append to general reports list */
-func parseJobReports(jobs []models.Job, reports *[]types.Report_ResponseBody) {
+func parseJobReports(jobs []models.Job, dependencyService *DependencyService.DependencyService, reports *[]types.Report_ResponseBody) {
 	for _, job := range jobs {
 		report := types.Report_ResponseBody{
 			Id:        job.JobId,
@@ -224,6 +301,13 @@ func parseJobReports(jobs []models.Job, reports *[]types.Report_ResponseBody) {
 			StartTime: job.StartTime,
 			Status:    string(job.Status),
 		}
+		// Needs
+		needs, err := dependencyService.GetParentIds(job.JobId)
+		if err != nil {
+			log.Printf("parseJobReports %v", err)
+		} else {
+			report.Needs = needs
+		}
 		// EndTime
 		if job.EndTime.Valid {
 			report.EndTime = job.EndTime
@@ -4,6 +4,7 @@ import (
 	"cicd/pipeci/backend/cache"
 	"cicd/pipeci/backend/db"
 	"cicd/pipeci/backend/models"
+	DependencyService "cicd/pipeci/backend/services/dependency"
 	JobService "cicd/pipeci/backend/services/job"
 	PipelineService "cicd/pipeci/backend/services/pipeline"
 	StageService "cicd/pipeci/backend/services/stage"
@@ -12,14 +13,20 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Expiration of the execution id to pipeline id mapping in Redis
+const executionIdCacheExpiration = 24 * time.Hour
+
 var (
-	pipelineService *PipelineService.PipelineService
-	stageService    *StageService.StageService
-	jobService      *JobService.JobService
+	pipelineService   *PipelineService.PipelineService
+	stageService      *StageService.StageService
+	jobService        *JobService.JobService
+	dependencyService *DependencyService.DependencyService
 )
 
 /* Get Pipeline Execution status */
@@ -28,10 +35,11 @@ func getExecutionStatus(pipeline models.Pipeline) (types.RequestExecutionStatus_
 
 	// set pipeline
 	response.Pipeline = types.PipelineExecutionStatus{
-		PipelineId: pipeline.PipelineId,
-		Name:       pipeline.Name,
-		Status:     string(pipeline.Status),
-		StageOrder: pipeline.StageOrder,
+		PipelineId:  pipeline.PipelineId,
+		ExecutionId: pipeline.ExecutionId,
+		Name:        pipeline.Name,
+		Status:      string(pipeline.Status),
+		StageOrder:  pipeline.StageOrder,
 	}
 
 	// set stages and jobs
@@ -50,10 +58,18 @@ func getExecutionStatus(pipeline models.Pipeline) (types.RequestExecutionStatus_
 
 		var jobResponse = make([]types.JobExecutionStatus, len(jobs))
 		for i, job := range jobs {
+			// query parent jobs
+			needs, err := dependencyService.GetParentIds(job.JobId)
+			if err != nil {
+				return response, err
+			}
 			jobResponse[i] = types.JobExecutionStatus{
-				JobId:  job.JobId,
-				Name:   job.Name,
-				Status: string(job.Status),
+				JobId:     job.JobId,
+				Name:      job.Name,
+				Status:    string(job.Status),
+				StartTime: job.StartTime,
+				EndTime:   job.EndTime,
+				Needs:     needs,
 			}
 		}
 
@@ -67,17 +83,52 @@ func getExecutionStatus(pipeline models.Pipeline) (types.RequestExecutionStatus_
 	return response, nil
 }
 
+/* Status of an execution still waiting in the task queue, it has no report yet */
+func getPendingExecutionStatus(executionId string, position int) types.RequestExecutionStatus_ResponseBody {
+	return types.RequestExecutionStatus_ResponseBody{
+		Pipeline: types.PipelineExecutionStatus{
+			ExecutionId:   executionId,
+			Status:        string(models.PENDING),
+			QueuePosition: position,
+		},
+		Stages: make(map[string]types.StageExecutionStatus),
+	}
+}
+
 /* Return API error */
 func requestExecutionStatusError(c *gin.Context, err error) {
 	log.Printf("RequestExecutionStatus %v", err)
 	c.IndentedJSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
 }
 
-/* Get pipeline execution status */
-func RequestExecutionStatus(c *gin.Context) {
+/* Initialize services used to gather execution status */
+func initStatusServices() {
 	pipelineService = PipelineService.NewPipelineService(db.Instance)
 	stageService = StageService.NewStageService(db.Instance)
 	jobService = JobService.NewJobService(db.Instance)
+	dependencyService = DependencyService.NewDependencyService(db.Instance)
+}
+
+/*
+Resolve the pipeline id of an execution id.
+Redis caches the mapping, the Pipelines table is the source of truth when the key was evicted or flushed.
+*/
+func getPipelineIdByExecutionId(ctx context.Context, executionId string) (string, error) {
+	return cache.GetOrLoad(ctx, executionId, executionIdCacheExpiration, func() (string, error) {
+		pipelines, err := pipelineService.QueryPipelines(map[string]interface{}{"execution_id": executionId})
+		if err != nil {
+			return "", err
+		}
+		if len(pipelines) == 0 {
+			return "", fmt.Errorf("no pipeline execution found for execution id %v", executionId)
+		}
+		return strconv.Itoa(pipelines[0].PipelineId), nil
+	})
+}
+
+/* Get pipeline execution status */
+func RequestExecutionStatus(c *gin.Context) {
+	initStatusServices()
 
 	var ctx context.Context = context.Background()
 	var pipelineId string
@@ -87,7 +138,17 @@ func RequestExecutionStatus(c *gin.Context) {
 	if err != nil {
 		return
 	}
-	pipelineId, err = cache.Get(ctx, body.ExecutionId)
+
+	// Not picked up by a worker yet
+	position, err := cache.PendingTaskPosition(ctx, body.ExecutionId)
+	if err != nil {
+		log.Printf("RequestExecutionStatus %v", err)
+	} else if position > 0 {
+		c.IndentedJSON(http.StatusOK, getPendingExecutionStatus(body.ExecutionId, position))
+		return
+	}
+
+	pipelineId, err = getPipelineIdByExecutionId(ctx, body.ExecutionId)
 	if err != nil {
 		requestExecutionStatusError(c, err)
 		return
@@ -112,3 +173,31 @@ func RequestExecutionStatus(c *gin.Context) {
 		}
 	}
 }
+
+/* Get execution status of the most recent run of a repository at a commit */
+func RequestLatestExecutionStatus(c *gin.Context) {
+	initStatusServices()
+
+	var body types.RequestLatestExecutionStatus_RequestBody
+	err := c.ShouldBindJSON(&body)
+	if err != nil {
+		return
+	}
+
+	pipeline, err := pipelineService.GetLatestPipeline(body.Repository.Url, body.Repository.CommitHash)
+	if err != nil {
+		requestExecutionStatusError(c, err)
+		return
+	}
+	if pipeline == nil {
+		requestExecutionStatusError(c, fmt.Errorf("no pipeline execution found for commit %v", body.Repository.CommitHash))
+		return
+	}
+
+	response, err := getExecutionStatus(*pipeline)
+	if err != nil {
+		requestExecutionStatusError(c, err)
+	} else {
+		c.IndentedJSON(http.StatusOK, response)
+	}
+}
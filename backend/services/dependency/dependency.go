@@ -0,0 +1,78 @@
+package DependencyService
+
+import (
+	"cicd/pipeci/backend/models"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type DependencyService struct {
+	db *sql.DB
+}
+
+func NewDependencyService(db *sql.DB) *DependencyService {
+	return &DependencyService{db: db}
+}
+
+// Query job dependency edges by input conditions
+func (service *DependencyService) QueryDependencies(filters map[string]interface{}) ([]models.Dependency, error) {
+	var dependencies []models.Dependency
+	query := "SELECT parent_id, child_id FROM Dependencies"
+	args := make([]interface{}, 0)
+
+	// Sort keys for deterministic query order
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Build the conditions
+	conditions := make([]string, 0)
+	for _, key := range keys {
+		value := filters[key]
+		conditions = append(conditions, fmt.Sprintf("%s = ?", key))
+		args = append(args, value)
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Execute
+	rows, err := service.db.Query(query+" ORDER BY parent_id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("QueryDependencies: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dependency models.Dependency
+		if err := rows.Scan(&dependency.ParentId, &dependency.ChildId); err != nil {
+			return nil, fmt.Errorf("QueryDependencies: %v", err)
+		}
+		dependencies = append(dependencies, dependency)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("QueryDependencies: %v", err)
+	}
+
+	return dependencies, nil
+}
+
+// Get parent job ids of a job
+func (service *DependencyService) GetParentIds(childId int) ([]int, error) {
+	dependencies, err := service.QueryDependencies(map[string]interface{}{"child_id": childId})
+	if err != nil {
+		return nil, err
+	}
+
+	parentIds := make([]int, 0, len(dependencies))
+	for _, dependency := range dependencies {
+		parentIds = append(parentIds, dependency.ParentId)
+	}
+	return parentIds, nil
+}
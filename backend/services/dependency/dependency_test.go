@@ -0,0 +1,98 @@
+package DependencyService
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryDependencies_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewDependencyService(db)
+
+	// Define expected rows
+	rows := sqlmock.NewRows([]string{"parent_id", "child_id"}).
+		AddRow(1, 3).
+		AddRow(2, 3)
+
+	// Expect query with correct filters
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT parent_id, child_id FROM Dependencies WHERE child_id = ? ORDER BY parent_id")).
+		WithArgs(3).
+		WillReturnRows(rows)
+
+	// Call method under test
+	dependencies, err := service.QueryDependencies(map[string]interface{}{"child_id": 3})
+
+	// Assert expected edges were returned
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(dependencies))
+	assert.Equal(t, 1, dependencies[0].ParentId)
+	assert.Equal(t, 3, dependencies[1].ChildId)
+
+	// Ensure all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestQueryDependencies_DBError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewDependencyService(db)
+
+	// Expect query to return an error
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT parent_id, child_id FROM Dependencies ORDER BY parent_id")).
+		WillReturnError(fmt.Errorf("database error"))
+
+	// Call method under test
+	dependencies, err := service.QueryDependencies(map[string]interface{}{})
+
+	// Assert error occurred
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "QueryDependencies: database error")
+	assert.Nil(t, dependencies)
+
+	// Ensure all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetParentIds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewDependencyService(db)
+
+	rows := sqlmock.NewRows([]string{"parent_id", "child_id"}).
+		AddRow(4, 7).
+		AddRow(5, 7)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT parent_id, child_id FROM Dependencies WHERE child_id = ? ORDER BY parent_id")).
+		WithArgs(7).
+		WillReturnRows(rows)
+
+	parentIds, err := service.GetParentIds(7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{4, 5}, parentIds)
+
+	// Ensure all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
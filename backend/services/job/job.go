@@ -53,7 +53,7 @@ func (service *JobService) QueryJobs(filters map[string]interface{}) ([]models.J
 		if err := rows.Scan(
 			&job.JobId, &job.StageId, &job.Name,
 			&job.Image, &job.Script, &job.Status,
-			&job.StartTime, &job.EndTime, &job.ContainerId,
+			&job.StartTime, &job.EndTime, &job.ContainerId, &job.ExecutionId,
 		); err != nil {
 			return nil, fmt.Errorf("QueryJobs: %v", err)
 		}
@@ -66,3 +66,35 @@ func (service *JobService) QueryJobs(filters map[string]interface{}) ([]models.J
 
 	return jobs, nil
 }
+
+// Average duration of successful job executions of a pipeline in a repository
+func (service *JobService) GetAverageJobDurations(repository, pipelineName string) ([]models.JobDuration, error) {
+	var durations []models.JobDuration
+	rows, err := service.db.Query(
+		`SELECT s.name, j.name, AVG(TIMESTAMPDIFF(SECOND, j.start_time, j.end_time))
+		FROM Jobs j
+		JOIN Stages s ON j.stage_id = s.stage_id
+		JOIN Pipelines p ON s.pipeline_id = p.pipeline_id
+		WHERE p.repository = ? AND p.name = ? AND j.status = ? AND j.end_time IS NOT NULL
+		GROUP BY s.name, j.name`,
+		repository, pipelineName, models.SUCCESS,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("GetAverageJobDurations: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var duration models.JobDuration
+		if err := rows.Scan(&duration.StageName, &duration.JobName, &duration.AverageSeconds); err != nil {
+			return nil, fmt.Errorf("GetAverageJobDurations: %v", err)
+		}
+		durations = append(durations, duration)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetAverageJobDurations: %v", err)
+	}
+
+	return durations, nil
+}
@@ -26,9 +26,9 @@ func TestQueryJobs_Success(t *testing.T) {
 	}
 
 	// Define expected rows
-	rows := sqlmock.NewRows([]string{"job_id", "stage_id", "name", "image", "script", "status", "start_time", "end_time", "container_id"}).
-		AddRow(1, 1, "job1", "", "", models.SUCCESS, time.Now(), time.Now(), "").
-		AddRow(2, 2, "job2", "", "", models.SUCCESS, time.Now(), time.Now(), "")
+	rows := sqlmock.NewRows([]string{"job_id", "stage_id", "name", "image", "script", "status", "start_time", "end_time", "container_id", "execution_id"}).
+		AddRow(1, 1, "job1", "", "", models.SUCCESS, time.Now(), time.Now(), "", "job_1").
+		AddRow(2, 2, "job2", "", "", models.SUCCESS, time.Now(), time.Now(), "", "job_2")
 
 	// Expect query with correct filters
 	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Jobs WHERE status = ? ORDER BY start_time")).
@@ -65,9 +65,9 @@ func TestQueryJobs_NoFilters(t *testing.T) {
 	filters := map[string]interface{}{}
 
 	// Define expected rows
-	rows := sqlmock.NewRows([]string{"job_id", "stage_id", "name", "image", "script", "status", "start_time", "end_time", "container_id"}).
-		AddRow(1, 1, "job1", "", "", models.SUCCESS, time.Now(), time.Now(), "").
-		AddRow(2, 2, "job2", "", "", models.SUCCESS, time.Now(), time.Now(), "")
+	rows := sqlmock.NewRows([]string{"job_id", "stage_id", "name", "image", "script", "status", "start_time", "end_time", "container_id", "execution_id"}).
+		AddRow(1, 1, "job1", "", "", models.SUCCESS, time.Now(), time.Now(), "", "job_3").
+		AddRow(2, 2, "job2", "", "", models.SUCCESS, time.Now(), time.Now(), "", "job_4")
 
 	// Expect query with no filters
 	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Jobs ORDER BY start_time")).
@@ -123,3 +123,57 @@ func TestQueryJobs_DBError(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestGetAverageJobDurations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewJobService(db)
+
+	rows := sqlmock.NewRows([]string{"stage_name", "job_name", "average_seconds"}).
+		AddRow("build", "compile", 12.5).
+		AddRow("test", "unit", 30.0)
+
+	mock.ExpectQuery("SELECT s.name, j.name, AVG").
+		WithArgs("https://github.com/org/repo", "pipeline", models.SUCCESS).
+		WillReturnRows(rows)
+
+	durations, err := service.GetAverageJobDurations("https://github.com/org/repo", "pipeline")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(durations))
+	assert.Equal(t, "compile", durations[0].JobName)
+	assert.Equal(t, 30.0, durations[1].AverageSeconds)
+
+	// Ensure all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetAverageJobDurations_DBError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewJobService(db)
+
+	mock.ExpectQuery("SELECT s.name, j.name, AVG").
+		WillReturnError(fmt.Errorf("database error"))
+
+	durations, err := service.GetAverageJobDurations("https://github.com/org/repo", "pipeline")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "GetAverageJobDurations: database error")
+	assert.Nil(t, durations)
+
+	// Ensure all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -30,7 +30,7 @@ func (service *PipelineService) GetPipelines() ([]models.Pipeline, error) {
 		if err := rows.Scan(
 			&pipeline.PipelineId, &pipeline.Repository, &pipeline.CommitHash, &pipeline.IPAddress,
 			&pipeline.Name, &pipeline.StageOrder,
-			&pipeline.Status, &pipeline.StartTime, &pipeline.EndTime,
+			&pipeline.Status, &pipeline.StartTime, &pipeline.EndTime, &pipeline.ExecutionId,
 		); err != nil {
 			return nil, fmt.Errorf("GetPipelines: %v", err)
 		}
@@ -79,7 +79,7 @@ func (service *PipelineService) QueryPipelines(filters map[string]interface{}) (
 		if err := rows.Scan(
 			&pipeline.PipelineId, &pipeline.Repository, &pipeline.CommitHash, &pipeline.IPAddress,
 			&pipeline.Name, &pipeline.StageOrder,
-			&pipeline.Status, &pipeline.StartTime, &pipeline.EndTime,
+			&pipeline.Status, &pipeline.StartTime, &pipeline.EndTime, &pipeline.ExecutionId,
 		); err != nil {
 			return nil, fmt.Errorf("QueryPipelines: %v", err)
 		}
@@ -93,6 +93,26 @@ func (service *PipelineService) QueryPipelines(filters map[string]interface{}) (
 	return pipelines, nil
 }
 
+// Most recent pipeline execution of a repository at a commit, nil if it never ran
+func (service *PipelineService) GetLatestPipeline(repository, commitHash string) (*models.Pipeline, error) {
+	var pipeline models.Pipeline
+	err := service.db.QueryRow(
+		"SELECT * FROM Pipelines WHERE repository = ? AND commit_hash = ? ORDER BY start_time DESC, pipeline_id DESC LIMIT 1",
+		repository, commitHash,
+	).Scan(
+		&pipeline.PipelineId, &pipeline.Repository, &pipeline.CommitHash, &pipeline.IPAddress,
+		&pipeline.Name, &pipeline.StageOrder,
+		&pipeline.Status, &pipeline.StartTime, &pipeline.EndTime, &pipeline.ExecutionId,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetLatestPipeline: %v", err)
+	}
+	return &pipeline, nil
+}
+
 // Deletes all pipelines with names starting with "test_"
 func (service *PipelineService) CleanUpTestPipelines() error {
 	result, err := service.db.Exec("DELETE FROM Pipelines WHERE name LIKE ?", "test_%")
@@ -23,9 +23,9 @@ func TestGetPipelines(t *testing.T) {
 	service := NewPipelineService(db)
 
 	// Define the expected rows
-	rows := sqlmock.NewRows([]string{"pipeline_id", "repository", "commit_hash", "ip_address", "name", "stage_order", "status", "start_time", "end_time"}).
-		AddRow(1, "repo1", "abc123", "0.0.0.0", "pipeline1", 1, models.PENDING, time.Now(), time.Now()).
-		AddRow(2, "repo2", "def456", "192.168.1.2", "pipeline2", 2, models.SUCCESS, time.Now(), time.Now())
+	rows := sqlmock.NewRows([]string{"pipeline_id", "repository", "commit_hash", "ip_address", "name", "stage_order", "status", "start_time", "end_time", "execution_id"}).
+		AddRow(1, "repo1", "abc123", "0.0.0.0", "pipeline1", 1, models.PENDING, time.Now(), time.Now(), "task-1").
+		AddRow(2, "repo2", "def456", "192.168.1.2", "pipeline2", 2, models.SUCCESS, time.Now(), time.Now(), "task-2")
 
 	// Expect the query and return the mock rows
 	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Pipelines")).WillReturnRows(rows)
@@ -95,9 +95,9 @@ func TestQueryPipelines_Success(t *testing.T) {
 	}
 
 	// Define the expected rows
-	rows := sqlmock.NewRows([]string{"pipeline_id", "repository", "commit_hash", "ip_address", "name", "stage_order", "status", "start_time", "end_time"}).
-		AddRow(1, "repo1", "abc123", "192.168.1.1", "pipeline1", 1, models.SUCCESS, time.Now(), time.Now()).
-		AddRow(2, "repo1", "def456", "192.168.1.2", "pipeline2", 2, models.SUCCESS, time.Now(), time.Now())
+	rows := sqlmock.NewRows([]string{"pipeline_id", "repository", "commit_hash", "ip_address", "name", "stage_order", "status", "start_time", "end_time", "execution_id"}).
+		AddRow(1, "repo1", "abc123", "192.168.1.1", "pipeline1", 1, models.SUCCESS, time.Now(), time.Now(), "task-3").
+		AddRow(2, "repo1", "def456", "192.168.1.2", "pipeline2", 2, models.SUCCESS, time.Now(), time.Now(), "task-4")
 
 	// Expect the query with the correct filters
 	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Pipelines WHERE repository = ? AND status = ? ORDER BY start_time")).
@@ -138,9 +138,9 @@ func TestQueryPipelines_NoFilters(t *testing.T) {
 	filters := map[string]interface{}{}
 
 	// Define the expected rows
-	rows := sqlmock.NewRows([]string{"pipeline_id", "repository", "commit_hash", "ip_address", "name", "stage_order", "status", "start_time", "end_time"}).
-		AddRow(1, "repo1", "abc123", "192.168.1.1", "pipeline1", 1, models.SUCCESS, time.Now(), time.Now()).
-		AddRow(2, "repo2", "def456", "192.168.1.2", "pipeline2", 2, models.PENDING, time.Now(), time.Now())
+	rows := sqlmock.NewRows([]string{"pipeline_id", "repository", "commit_hash", "ip_address", "name", "stage_order", "status", "start_time", "end_time", "execution_id"}).
+		AddRow(1, "repo1", "abc123", "192.168.1.1", "pipeline1", 1, models.SUCCESS, time.Now(), time.Now(), "task-5").
+		AddRow(2, "repo2", "def456", "192.168.1.2", "pipeline2", 2, models.PENDING, time.Now(), time.Now(), "task-6")
 
 	// Expect the query with no filters
 	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Pipelines ORDER BY start_time")).
@@ -222,3 +222,36 @@ func TestCleanUpTestPipelines(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestGetLatestPipeline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewPipelineService(db)
+
+	rows := sqlmock.NewRows([]string{"pipeline_id", "repository", "commit_hash", "ip_address", "name", "stage_order", "status", "start_time", "end_time", "execution_id"}).
+		AddRow(2, "repo1", "abc123", "0.0.0.0", "pipeline1", "build", models.PENDING, time.Now(), nil, "task-2")
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Pipelines WHERE repository = ? AND commit_hash = ? ORDER BY start_time DESC, pipeline_id DESC LIMIT 1")).
+		WithArgs("repo1", "abc123").
+		WillReturnRows(rows)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Pipelines WHERE repository = ? AND commit_hash = ?")).
+		WithArgs("repo1", "def456").
+		WillReturnRows(sqlmock.NewRows([]string{"pipeline_id"}))
+
+	pipeline, err := service.GetLatestPipeline("repo1", "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pipeline.PipelineId)
+	assert.Equal(t, "task-2", pipeline.ExecutionId)
+
+	// Commit never executed
+	pipeline, err = service.GetLatestPipeline("repo1", "def456")
+	assert.NoError(t, err)
+	assert.Nil(t, pipeline)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
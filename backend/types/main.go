@@ -12,6 +12,12 @@ type ExecuteLocal_RequestBody struct {
 	Repository models.Repository            `json:"repository"`
 }
 
+// Task published to the task queue, consumed by a worker
+type Task struct {
+	Id      string                   `json:"id"`
+	Message ExecuteLocal_RequestBody `json:"message"`
+}
+
 // report
 type ReportPastExecutionsLocal_CurrentRepo_RequestBody struct {
 	Repository   models.Repository `json:"repository"`
@@ -28,6 +34,7 @@ type Report_ResponseBody struct {
 	StartTime time.Time    `json:"start_time"`
 	EndTime   sql.NullTime `json:"end_time"`
 	Status    string       `json:"status"`
+	Needs     []int        `json:"needs,omitempty"` // Parent job ids, only set for job reports
 	// RunCounter int          `json:"run_counter"`
 }
 
@@ -36,16 +43,23 @@ type RequestExecutionStatus_RequestBody struct {
 	ExecutionId string `json:"execution_id"`
 }
 
+// status --latest
+type RequestLatestExecutionStatus_RequestBody struct {
+	Repository models.Repository `json:"repository"`
+}
+
 type RequestExecutionStatus_ResponseBody struct {
 	Pipeline PipelineExecutionStatus         `json:"pipeline"`
 	Stages   map[string]StageExecutionStatus `json:"stages"`
 }
 
 type PipelineExecutionStatus struct {
-	PipelineId int    `json:"pipeline_id"`
-	Name       string `json:"name"`
-	Status     string `json:"status"`
-	StageOrder string `json:"stage_order"`
+	PipelineId    int    `json:"pipeline_id"`
+	ExecutionId   string `json:"execution_id"`
+	Name          string `json:"name"`
+	Status        string `json:"status"`
+	StageOrder    string `json:"stage_order"`
+	QueuePosition int    `json:"queue_position,omitempty"` // Position in the task queue while the run is pending
 }
 
 type StageExecutionStatus struct {
@@ -56,7 +70,16 @@ type StageExecutionStatus struct {
 }
 
 type JobExecutionStatus struct { // * put OrderBy created_at when query status
-	JobId  int    `json:"job_id"`
-	Name   string `json:"name"`
-	Status string `json:"status"`
+	JobId     int          `json:"job_id"`
+	Name      string       `json:"name"`
+	Status    string       `json:"status"`
+	StartTime time.Time    `json:"start_time"`
+	EndTime   sql.NullTime `json:"end_time"`
+	Needs     []int        `json:"needs"` // Parent job ids
+}
+
+// admin dlq
+type DeadLetter_RequestBody struct {
+	Queue string `json:"queue"` // task_queue or job_queue
+	Limit int    `json:"limit"` // Maximum number of messages, all when <= 0
 }
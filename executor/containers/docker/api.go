@@ -4,17 +4,21 @@ package DockerService
 import (
 	"bytes"
 	"cicd/pipeci/executor/cache"
+	"cicd/pipeci/executor/containers"
+	KubernetesService "cicd/pipeci/executor/containers/k8s"
 	"cicd/pipeci/executor/db"
 	"cicd/pipeci/executor/models"
 	JobService "cicd/pipeci/executor/services/job"
-	PipelineService "cicd/pipeci/executor/services/pipeline"
-	StageService "cicd/pipeci/executor/services/stage"
+	StatusService "cicd/pipeci/executor/services/status"
 	"cicd/pipeci/executor/storage"
+	"cicd/pipeci/queue"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
@@ -27,7 +31,7 @@ type DockerClient struct {
 	ctx context.Context // Context
 }
 
-/* Initialize Docker client */
+/* Initialize Docker client, running job containers on the Docker host */
 func initDockerClient() (*DockerClient, error) {
 	ctx := context.Background()
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
@@ -89,8 +93,8 @@ func (dc *DockerClient) createContainer(containerName string, imageName string,
 	return resp.ID, nil
 }
 
-// deleteContainer deletes a Docker container by its Id
-func (dc *DockerClient) deleteContainer(containerId string) error {
+// Delete deletes a Docker container by its Id
+func (dc *DockerClient) Delete(containerId string) error {
 	options := container.RemoveOptions{
 		Force: true, // Force removal if the container is running
 	}
@@ -118,8 +122,8 @@ func (dc *DockerClient) WaitContainer(containerId string) error {
 }
 
 /* Retrieve container logs as byte stream for easier MinIO upload */
-func (dc *DockerClient) getContainerLogs(containerID string) (*bytes.Buffer, error) {
-	log.Printf("START getContainerLogs")
+func (dc *DockerClient) Logs(containerID string) (*bytes.Buffer, error) {
+	log.Printf("START Logs")
 	// Get container logs
 	out, err := dc.cli.ContainerLogs(dc.ctx, containerID, container.LogsOptions{
 		ShowStdout: true,
@@ -142,8 +146,14 @@ func (dc *DockerClient) getContainerLogs(containerID string) (*bytes.Buffer, err
 	return &logBuffer, nil
 }
 
-// Actions on Docker
-func (dc *DockerClient) initContainer(job models.JobConfiguration, repository models.Repository) (string, error) {
+/* Check if a container exists on this Docker host */
+func (dc *DockerClient) Exists(containerId string) bool {
+	_, err := dc.cli.ContainerInspect(dc.ctx, containerId)
+	return err == nil
+}
+
+/* Pull the job image and create its container, not started yet */
+func (dc *DockerClient) Prepare(job models.JobConfiguration, repository models.Repository) (string, error) {
 	log.Printf("Running stage `%v`, job: `%v`", job.Stage.Value, job.Name.Value)
 
 	if err := dc.pullImage(job.Image.Value); err != nil {
@@ -166,27 +176,66 @@ func (dc *DockerClient) initContainer(job models.JobConfiguration, repository mo
 		return containerId, err
 	}
 	log.Printf("Container Id for job %v: %v", job.Name.Value, containerId)
+	return containerId, nil
+}
 
-	// Start container
-	if err := dc.startContainer(containerId); err != nil {
-		return containerId, err
+/* Start a container unless a previous delivery already did, then wait for completion */
+func (dc *DockerClient) Run(containerId string) error {
+	inspect, err := dc.cli.ContainerInspect(dc.ctx, containerId)
+	if err != nil {
+		return err
+	}
+	if inspect.State != nil && inspect.State.Status == "created" {
+		if err := dc.startContainer(containerId); err != nil {
+			return err
+		}
 	}
 
-	// Wait for completion
+	// Wait for completion, returns right away with the exit code of a finished container
 	if err := dc.WaitContainer(containerId); err != nil {
-		return containerId, err
+		return err
 	}
 
 	// Done
 	log.Printf("Execution done for Container Id %v", containerId)
+	return nil
+}
+
+/* Error of a job execution claimed by another executor, which reports the job and publishes its event */
+var errJobClaimed = errors.New("job execution claimed by another executor")
+
+/*
+Get the container of a job execution.
+The container of a previous delivery is resumed when it still exists on the runtime, otherwise
+a new container is created and recorded on the job report, unless another executor did first.
+*/
+func claimContainer(runtime containers.Runtime, jobService *JobService.JobService, jobReport models.Job, job models.JobConfiguration, repository models.Repository) (string, error) {
+	if jobReport.ContainerId != "" && runtime.Exists(jobReport.ContainerId) {
+		log.Printf("Resuming container %v of job execution %v", jobReport.ContainerId, jobReport.ExecutionId)
+		return jobReport.ContainerId, nil
+	}
+
+	containerId, err := runtime.Prepare(job, repository)
+	if err != nil {
+		return containerId, err
+	}
+
+	claimed, err := jobService.ClaimJob(jobReport.JobId, jobReport.ContainerId, containerId)
+	if err != nil || !claimed {
+		runtime.Delete(containerId)
+		if err != nil {
+			return "", err
+		}
+		return "", errJobClaimed
+	}
 	return containerId, nil
 }
 
 /* Take actions after executions: get logs, upload to Minio, then delete containers */
-func (dc *DockerClient) handlePostExecution(containerId string) error {
+func handlePostExecution(runtime containers.Runtime, containerId string) error {
 	log.Printf("START handlePostExecution")
 	// Retrieve container logs
-	containerLogs, err := dc.getContainerLogs(containerId)
+	containerLogs, err := runtime.Logs(containerId)
 	if err != nil {
 		return err
 	}
@@ -199,37 +248,55 @@ func (dc *DockerClient) handlePostExecution(containerId string) error {
 	}
 
 	// Delete container after saving logs
-	dc.deleteContainer(containerId)
+	runtime.Delete(containerId)
 
 	// Done
 	log.Printf("handlePostExecution done for Container Id %v", containerId)
 	return nil
 }
 
-/* Match execution key-value pair  */
+/* Connect to the configured container runtime: the Docker host, or Kubernetes running every job in its own pod */
+func initRuntime() (containers.Runtime, error) {
+	switch name := containers.RuntimeName(); name {
+	case containers.RuntimeDocker:
+		return initDockerClient()
+	case containers.RuntimeKubernetes:
+		return KubernetesService.NewKubernetesClient()
+	default:
+		return nil, queue.Permanent(fmt.Errorf("unsupported container runtime `%s`", name))
+	}
+}
+
+/* Match execution key-value pair, the database keeps the execution id once the key expires */
 func matchExecutionIdToJob(executionId string, jobId int) {
 	ctx := context.Background()
-	cache.Set(ctx, executionId, jobId, 0)
+	cache.Set(ctx, executionId, jobId, 24*time.Hour)
 }
 
 /*
-Execute jobs in Docker containers
+Execute jobs in containers of the configured runtime
 Revisions:
   - Feb 15: Linear execution
     TODO #1: Parallel execution for single-graph pipeline
     TODO #2: Parallel execution for multiple-graphs pipeline
     TODO #3: continue-on-error
 */
-func executeJob(job models.JobConfiguration, repository models.Repository) (string, error) {
+func executeJob(jobService *JobService.JobService, jobReport models.Job, job models.JobConfiguration, repository models.Repository) (string, error) {
 	log.Printf("START executeJob")
-	dc, err := initDockerClient()
+	runtime, err := initRuntime()
 	if err != nil {
 		return "", err
 	}
-	defer dc.Close()
+	defer runtime.Close()
 
-	containerId, initErr := dc.initContainer(job, repository)
-	postExecErr := dc.handlePostExecution(containerId)
+	containerId, initErr := claimContainer(runtime, jobService, jobReport, job, repository)
+	if errors.Is(initErr, errJobClaimed) {
+		return "", initErr
+	}
+	if initErr == nil {
+		initErr = runtime.Run(containerId)
+	}
+	postExecErr := handlePostExecution(runtime, containerId)
 
 	// If both initContainer and handlePostExecution fail, combine errors
 	if initErr != nil && postExecErr != nil {
@@ -253,47 +320,77 @@ func executeJob(job models.JobConfiguration, repository models.Repository) (stri
 	return containerId, nil
 }
 
-/* Job Execution Result models */
-type JobExecResult struct {
-	Job models.JobConfiguration
-	Err error
-}
-
 /*
 Execute a pipeline and store reports
+Executions are keyed by the job execution id: a redelivered job resumes its container,
+or only publishes its event again once finished.
 TODO #1: Allow failures and update status for failed jobs
 TODO #2: Force stop job(s)
 */
 func Execute(pipelineReportId, stageReportId, jobReportId int, executionId string, job models.JobConfiguration, repository models.Repository) error {
 	// Service instance
-	var pipelineService = PipelineService.NewPipelineService(db.Instance)
-	var stageService = StageService.NewStageService(db.Instance)
+	var statusService = StatusService.NewStatusService(db.Instance)
 	var jobService = JobService.NewJobService(db.Instance)
 
+	// Job execution report created by the worker
+	jobReport, err := jobService.GetJobByExecutionId(executionId)
+	if err != nil {
+		return err
+	}
+	if jobReport == nil {
+		return queue.Permanent(fmt.Errorf("no job report for execution `%s`", executionId))
+	}
+	jobReportId = jobReport.JobId
+
 	// Put K-V pair to Redis
 	matchExecutionIdToJob(executionId, jobReportId)
 
+	// Notify the worker scheduling the pipeline so that child jobs are released or canceled
+	publish := func(status models.ExecStatus) error {
+		return queue.PublishJobEvent(queue.JobEvent{
+			Id:         executionId,
+			JobId:      jobReportId,
+			StageId:    stageReportId,
+			PipelineId: pipelineReportId,
+			Status:     string(status),
+		})
+	}
+
+	// Finished by a previous delivery, a resumed worker may still wait for the event
+	if jobReport.Status.IsTerminal() {
+		log.Printf("REPORT: Job `%v` already finished with status %v, skipping", job.Name.Value, jobReport.Status)
+		return publish(jobReport.Status)
+	}
+
 	// * Execute job and update job/stage/pipeline execution status
-	if containerId, err := executeJob(job, repository); err != nil {
-		err = jobService.UpdateJobStatusAndEndTime(jobReportId, containerId, models.FAILED)
-		if err != nil {
-			log.Printf("%v\n", err)
-		}
+	var status models.ExecStatus = models.SUCCESS
+	containerId, err := executeJob(jobService, *jobReport, job, repository)
+	if errors.Is(err, errJobClaimed) {
+		// A duplicated delivery, acked so that it is not retried into the dead-letter queue
+		log.Printf("REPORT: Job `%v` is run by another executor, skipping", job.Name.Value)
+		return nil
+	}
+	if err != nil {
+		status = models.FAILED
 		log.Printf("REPORT: Job `%v` run failed!\nCaused by: %v", job.Name.Value, err)
 	} else {
-		if err = jobService.UpdateJobStatusAndEndTime(jobReportId, containerId, models.SUCCESS); err != nil {
-			log.Printf("%v\n", err)
-		}
-		if err = stageService.UpdateStageStatusAndEndTime(stageReportId, models.FAILED); err != nil {
-			log.Printf("%v\n", err)
-		}
-		if err = pipelineService.UpdatePipelineStatusAndEndTime(pipelineReportId, models.FAILED); err != nil {
-			log.Printf("%v\n", err)
-		}
 		log.Printf("REPORT: Job `%v` run success!\n", job.Name.Value)
 	}
 
-	return nil
+	// Stage and pipeline statuses are recomputed in the same transaction
+	stageStatus, pipelineStatus, err := statusService.UpdateJobStatus(jobReportId, containerId, status)
+	if errors.Is(err, StatusService.ErrJobFinished) {
+		// Failed past its deadline or canceled by the worker meanwhile, which no longer waits for the job
+		log.Printf("REPORT: Job `%v` was already finished by the worker, status %v discarded", job.Name.Value, status)
+		return nil
+	}
+	if err != nil {
+		log.Printf("%v\n", err)
+	} else {
+		log.Printf("REPORT: Stage is %v, pipeline is %v", stageStatus, pipelineStatus)
+	}
+
+	return publish(status)
 }
 
 // Remove Personal Access Token from URL if exists
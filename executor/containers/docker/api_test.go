@@ -4,6 +4,9 @@ import (
 	"cicd/pipeci/executor/cache"
 	"cicd/pipeci/executor/db"
 	"cicd/pipeci/executor/models"
+	JobService "cicd/pipeci/executor/services/job"
+	PipelineService "cicd/pipeci/executor/services/pipeline"
+	StageService "cicd/pipeci/executor/services/stage"
 	"cicd/pipeci/executor/storage"
 	"fmt"
 
@@ -14,6 +17,7 @@ import (
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 )
@@ -80,7 +84,7 @@ func TestCreateContainer(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, containerId)
 
-	err = dc.deleteContainer(containerId)
+	err = dc.Delete(containerId)
 	assert.NoError(t, err)
 }
 
@@ -103,7 +107,7 @@ func TestStartContainer(t *testing.T) {
 	err = dc.startContainer(containerId)
 	assert.NoError(t, err)
 
-	err = dc.deleteContainer(containerId)
+	err = dc.Delete(containerId)
 	assert.NoError(t, err)
 }
 
@@ -128,7 +132,7 @@ func TestWaitContainer(t *testing.T) {
 	err = dc.WaitContainer(containerId)
 	assert.NoError(t, err)
 
-	err = dc.deleteContainer(containerId)
+	err = dc.Delete(containerId)
 	assert.NoError(t, err)
 }
 
@@ -155,12 +159,39 @@ func cleanUpAfterTest(dc *DockerClient) error {
 	// Gather container ids, currently doing nothing
 	// TODO: clean up artifacts
 	for _, containerId := range removedIds {
-		// go dc.deleteContainer(containerId)
+		// go dc.Delete(containerId)
 		fmt.Printf("clean up after test container id: %v", containerId)
 	}
 	return nil
 }
 
+// Create the reports of a job execution, as the worker does before enqueuing it
+func createTestJobReport(t *testing.T, job models.JobConfiguration) (int, int, int, string) {
+	executionId := "job_" + uuid.New().String()
+	pipelineId, err := PipelineService.NewPipelineService(db.Instance).CreatePipeline(models.Pipeline{
+		Name:        TEST_PIPELINE_NAME,
+		StageOrder:  job.Stage.Value,
+		Status:      models.PENDING,
+		ExecutionId: uuid.New().String(),
+	})
+	assert.NoError(t, err)
+	stageId, err := StageService.NewStageService(db.Instance).CreateStage(models.Stage{
+		PipelineId: pipelineId,
+		Name:       job.Stage.Value,
+		Status:     models.PENDING,
+	})
+	assert.NoError(t, err)
+	jobId, err := JobService.NewJobService(db.Instance).CreateJob(models.Job{
+		StageId:     stageId,
+		Name:        job.Name.Value,
+		Image:       job.Image.Value,
+		Status:      models.PENDING,
+		ExecutionId: executionId,
+	})
+	assert.NoError(t, err)
+	return pipelineId, stageId, jobId, executionId
+}
+
 // Test initContainer
 func TestExecute(t *testing.T) {
 	db.Init()
@@ -198,7 +229,8 @@ func TestExecute(t *testing.T) {
 		},
 	}
 
-	err = Execute(0, 0, 0, "exec_id", *pipeline.Stages.Value["build"].Value["compile"], models.Repository{
+	pipelineId, stageId, jobId, executionId := createTestJobReport(t, *pipeline.Stages.Value["build"].Value["compile"])
+	err = Execute(pipelineId, stageId, jobId, executionId, *pipeline.Stages.Value["build"].Value["compile"], models.Repository{
 		Url: "https://github.com/CS6510-SEA-SP25/t3-cicd.git", CommitHash: "ae47cc929081a0312a54bf85f3f6c232a912e243",
 	})
 	assert.NoError(t, err)
@@ -245,7 +277,8 @@ func TestExecuteFailed(t *testing.T) {
 		},
 	}
 
-	err = Execute(0, 0, 0, "exec_id", *pipeline.Stages.Value["build"].Value["compile"], models.Repository{})
+	pipelineId, stageId, jobId, executionId := createTestJobReport(t, *pipeline.Stages.Value["build"].Value["compile"])
+	err = Execute(pipelineId, stageId, jobId, executionId, *pipeline.Stages.Value["build"].Value["compile"], models.Repository{})
 	if err == nil {
 		t.Errorf("expected an error but got none")
 	} else {
@@ -291,7 +324,8 @@ func TestExecuteFailed_InvalidCommand(t *testing.T) {
 			"build": {{"compile"}},
 		},
 	}
-	err = Execute(0, 0, 0, "exec_id", *pipeline.Stages.Value["build"].Value["compile"], models.Repository{})
+	pipelineId, stageId, jobId, executionId := createTestJobReport(t, *pipeline.Stages.Value["build"].Value["compile"])
+	err = Execute(pipelineId, stageId, jobId, executionId, *pipeline.Stages.Value["build"].Value["compile"], models.Repository{})
 	if err == nil {
 		t.Errorf("expected an error but got none")
 	} else {
@@ -357,7 +391,8 @@ func TestExecuteFailed_TerminatedJobs(t *testing.T) {
 		},
 	}
 
-	err = Execute(0, 0, 0, "exec_id", *pipeline.Stages.Value["build"].Value["compile"], models.Repository{})
+	pipelineId, stageId, jobId, executionId := createTestJobReport(t, *pipeline.Stages.Value["build"].Value["compile"])
+	err = Execute(pipelineId, stageId, jobId, executionId, *pipeline.Stages.Value["build"].Value["compile"], models.Repository{})
 	if err == nil {
 		t.Errorf("expected an error but got none")
 	} else {
@@ -0,0 +1,343 @@
+package KubernetesService
+
+import (
+	"bytes"
+	"cicd/pipeci/executor/models"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// Containers of a job pod: the repository checkout, then the job script
+	cloneContainer = "clone"
+	jobContainer   = "job"
+	// Volume shared by the containers of a job pod
+	workspaceVolume = "workspace"
+	workspacePath   = "/workspace"
+	repositoryPath  = "/workspace/repo"
+	// Scheduling gate holding a prepared job pod until its execution is claimed
+	claimGate = "pipeci.cicd/claim"
+	// Keys of the Secret of a job pod, the repository URL may carry an access token
+	repositoryUrlKey = "REPOSITORY_URL"
+	commitHashKey    = "COMMIT_HASH"
+	// Image of the checkout init container, overridden with GIT_IMAGE
+	defaultGitImage = "alpine/git:latest"
+	// Namespace of the executor, mounted with its service account token
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// Interval between two checks of a running job pod
+var pollInterval = 2 * time.Second
+
+// Waiting reasons of a container whose image will never start
+var imageFailures = map[string]bool{"ErrImagePull": true, "ImagePullBackOff": true, "InvalidImageName": true}
+
+/* Maximum duration of a job pod: JOB_TIMEOUT, e.g. `30m`, 1 hour by default */
+func jobTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv("JOB_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		return time.Hour
+	}
+	return timeout
+}
+
+// Kubernetes Client, running every job in its own pod rather than on a Docker host
+type KubernetesClient struct {
+	clientset kubernetes.Interface // Kubernetes API Client
+	namespace string               // Namespace of the job pods
+	ctx       context.Context      // Context
+}
+
+/* Initialize Kubernetes client from the service account of the executor pod */
+func NewKubernetesClient() (*KubernetesClient, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load in-cluster config: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &KubernetesClient{clientset: clientset, namespace: jobNamespace(), ctx: context.Background()}, nil
+}
+
+/* Namespace of the job pods: JOB_NAMESPACE, else the namespace of the executor */
+func jobNamespace() string {
+	if namespace := os.Getenv("JOB_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	if namespace, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+		return strings.TrimSpace(string(namespace))
+	}
+	return "default"
+}
+
+/* Image of the checkout init container */
+func gitImage() string {
+	if image := os.Getenv("GIT_IMAGE"); image != "" {
+		return image
+	}
+	return defaultGitImage
+}
+
+/* Nothing to release, the client has no persistent connection */
+func (kc *KubernetesClient) Close() {}
+
+/* Security context of the job containers: unprivileged, without privilege escalation */
+func jobSecurityContext() *corev1.SecurityContext {
+	privileged, allowPrivilegeEscalation := false, false
+	return &corev1.SecurityContext{
+		Privileged:               &privileged,
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+}
+
+/* Environment variable read from the Secret of a job pod */
+func secretEnvVar(secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: key,
+		ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+			Key:                  key,
+		}},
+	}
+}
+
+/*
+Secret of a job pod with the repository to check out, named after the pod and deleted with it.
+Keeps the access token of the repository URL out of the pod spec.
+*/
+func jobSecret(pod *corev1.Pod, repository models.Repository) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+			Labels:    pod.Labels,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Name:       pod.Name,
+				UID:        pod.UID,
+			}},
+		},
+		StringData: map[string]string{
+			repositoryUrlKey: repository.Url,
+			commitHashKey:    repository.CommitHash,
+		},
+	}
+}
+
+/*
+Build the pod of a job. An init container clones the repository at the commit into a shared
+volume, then the job container runs the script in it. The pod is held by a scheduling gate
+until Run, like a created Docker container waits to be started.
+The repository is read from the Secret of the pod and only expanded as quoted shell arguments.
+*/
+func jobPod(name, namespace string, job models.JobConfiguration) *corev1.Pod {
+	automountServiceAccountToken := false
+	activeDeadlineSeconds := max(int64(jobTimeout().Seconds()), 1)
+	workspaceMount := corev1.VolumeMount{Name: workspaceVolume, MountPath: workspacePath}
+
+	// Checkout code from Github - checkout to specific commit hash
+	checkout := []string{
+		`git clone --no-checkout -- "$` + repositoryUrlKey + `" ` + repositoryPath,
+		"cd " + repositoryPath,
+		`git checkout "$` + commitHashKey + `" --`,
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "pipeline-job"},
+			Annotations: map[string]string{
+				"pipeci.cicd/stage": job.Stage.Value,
+				"pipeci.cicd/job":   job.Name.Value,
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds:        &activeDeadlineSeconds,
+			SchedulingGates:              []corev1.PodSchedulingGate{{Name: claimGate}},
+			AutomountServiceAccountToken: &automountServiceAccountToken,
+			InitContainers: []corev1.Container{{
+				Name:            cloneContainer,
+				Image:           gitImage(),
+				Command:         []string{"sh", "-c", strings.Join(checkout, " && ")},
+				Env:             []corev1.EnvVar{secretEnvVar(name, repositoryUrlKey), secretEnvVar(name, commitHashKey)},
+				VolumeMounts:    []corev1.VolumeMount{workspaceMount},
+				SecurityContext: jobSecurityContext(),
+			}},
+			Containers: []corev1.Container{{
+				Name:            jobContainer,
+				Image:           job.Image.Value,
+				Command:         []string{"sh", "-c", strings.Join(job.Script.Value, " && ")},
+				WorkingDir:      repositoryPath,
+				VolumeMounts:    []corev1.VolumeMount{workspaceMount},
+				SecurityContext: jobSecurityContext(),
+			}},
+			Volumes: []corev1.Volume{{
+				Name:         workspaceVolume,
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			}},
+		},
+	}
+}
+
+/* Create the pod of a job and its Secret, not scheduled yet */
+func (kc *KubernetesClient) Prepare(job models.JobConfiguration, repository models.Repository) (string, error) {
+	log.Printf("Running stage `%v`, job: `%v`", job.Stage.Value, job.Name.Value)
+
+	name := "pipeline-job-" + uuid.New().String()
+	pod, err := kc.clientset.CoreV1().Pods(kc.namespace).Create(kc.ctx, jobPod(name, kc.namespace, job), metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to create job pod: %w", err)
+	}
+	// Owned by the pod, the gate keeps the pod from starting before the Secret exists
+	if _, err := kc.clientset.CoreV1().Secrets(kc.namespace).Create(kc.ctx, jobSecret(pod, repository), metav1.CreateOptions{}); err != nil {
+		if deleteErr := kc.Delete(pod.Name); deleteErr != nil {
+			log.Printf("%v\n", deleteErr)
+		}
+		return "", fmt.Errorf("failed to create job secret: %w", err)
+	}
+	log.Printf("Pod for job %v: %v", job.Name.Value, pod.Name)
+	return pod.Name, nil
+}
+
+/* Schedule a job pod unless a previous delivery already did, then wait for completion */
+func (kc *KubernetesClient) Run(podName string) error {
+	pods := kc.clientset.CoreV1().Pods(kc.namespace)
+	pod, err := pods.Get(kc.ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if len(pod.Spec.SchedulingGates) > 0 {
+		pod.Spec.SchedulingGates = nil
+		if _, err := pods.Update(kc.ctx, pod, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to schedule job pod: %w", err)
+		}
+	}
+
+	/*
+		Wait for completion, returns right away with the outcome of a finished pod.
+		The kubelet fails a pod running past its active deadline, a pod never started,
+		e.g. unschedulable, fails once the deadline is reached here.
+	*/
+	deadline := time.Now().Add(jobTimeout())
+	for {
+		pod, err := pods.Get(kc.ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded:
+			log.Printf("Execution done for Pod %v", podName)
+			return nil
+		case corev1.PodFailed:
+			return podFailure(pod)
+		case corev1.PodPending:
+			if err := imageFailure(pod); err != nil {
+				return err
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("job pod did not finish within %v", jobTimeout())
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+/* Error of a pending job pod whose checkout or job image cannot be pulled, nil otherwise */
+func imageFailure(pod *corev1.Pod) error {
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if waiting := status.State.Waiting; waiting != nil && imageFailures[waiting.Reason] {
+			return fmt.Errorf("image `%s` of container `%s` cannot be pulled: %s %s", status.Image, status.Name, waiting.Reason, waiting.Message)
+		}
+	}
+	return nil
+}
+
+/* Error of a failed job pod, from the exit code of its failed container */
+func podFailure(pod *corev1.Pod) error {
+	for _, status := range pod.Status.InitContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			return fmt.Errorf("repository checkout exited with non-zero status: %d", terminated.ExitCode)
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			return fmt.Errorf("container exited with non-zero status: %d", terminated.ExitCode)
+		}
+	}
+	return fmt.Errorf("pod failed: %s %s", pod.Status.Reason, pod.Status.Message)
+}
+
+/* Check if a container of a pod started, the logs of the others are not available */
+func containerStarted(statuses []corev1.ContainerStatus, name string) bool {
+	for _, status := range statuses {
+		if status.Name == name {
+			return status.State.Running != nil || status.State.Terminated != nil
+		}
+	}
+	return false
+}
+
+/* Retrieve the logs of the checkout then of the job as byte stream for easier MinIO upload */
+func (kc *KubernetesClient) Logs(podName string) (*bytes.Buffer, error) {
+	log.Printf("START Logs")
+	pods := kc.clientset.CoreV1().Pods(kc.namespace)
+	pod, err := pods.Get(kc.ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod logs: %w", err)
+	}
+
+	var logBuffer bytes.Buffer
+	for _, container := range []string{cloneContainer, jobContainer} {
+		statuses := pod.Status.ContainerStatuses
+		if container == cloneContainer {
+			statuses = pod.Status.InitContainerStatuses
+		}
+		if !containerStarted(statuses, container) {
+			continue
+		}
+
+		out, err := pods.GetLogs(podName, &corev1.PodLogOptions{Container: container}).Stream(kc.ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pod logs: %w", err)
+		}
+		_, err = io.Copy(&logBuffer, out)
+		out.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pod logs: %w", err)
+		}
+	}
+	return &logBuffer, nil
+}
+
+/* Check if a job pod exists */
+func (kc *KubernetesClient) Exists(podName string) bool {
+	_, err := kc.clientset.CoreV1().Pods(kc.namespace).Get(kc.ctx, podName, metav1.GetOptions{})
+	return err == nil
+}
+
+// Delete deletes a job pod by its name, right away
+func (kc *KubernetesClient) Delete(podName string) error {
+	gracePeriodSeconds := int64(0)
+	return kc.clientset.CoreV1().Pods(kc.namespace).Delete(kc.ctx, podName, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriodSeconds})
+}
@@ -0,0 +1,178 @@
+package KubernetesService
+
+import (
+	"cicd/pipeci/executor/models"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestClient() *KubernetesClient {
+	return &KubernetesClient{clientset: fake.NewSimpleClientset(), namespace: "ci", ctx: context.Background()}
+}
+
+func testJob() (models.JobConfiguration, models.Repository) {
+	job := models.JobConfiguration{
+		Name:   &models.ConfigurationNode[string]{Value: "unit-test"},
+		Stage:  &models.ConfigurationNode[string]{Value: "test"},
+		Image:  &models.ConfigurationNode[string]{Value: "golang:1.23"},
+		Script: &models.ConfigurationNode[[]string]{Value: []string{"go vet ./...", "go test ./..."}},
+	}
+	repository := models.Repository{Url: "https://github.com/example/app.git", CommitHash: "4f1c2ab"}
+	return job, repository
+}
+
+// Set the phase and container statuses of a job pod, like the kubelet would
+func finishPod(t *testing.T, kc *KubernetesClient, podName string, phase corev1.PodPhase, cloneExit, jobExit int32) {
+	pod, err := kc.clientset.CoreV1().Pods(kc.namespace).Get(kc.ctx, podName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	pod.Status.Phase = phase
+	pod.Status.InitContainerStatuses = []corev1.ContainerStatus{
+		{Name: cloneContainer, State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: cloneExit}}},
+	}
+	if cloneExit == 0 {
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+			{Name: jobContainer, State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: jobExit}}},
+		}
+	}
+	_, err = kc.clientset.CoreV1().Pods(kc.namespace).UpdateStatus(kc.ctx, pod, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+}
+
+// Test preparing a gated job pod checking out the repository in an init container
+func TestPrepare(t *testing.T) {
+	kc := newTestClient()
+	job, repository := testJob()
+
+	podName, err := kc.Prepare(job, repository)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(podName, "pipeline-job-"))
+	assert.True(t, kc.Exists(podName))
+
+	pod, err := kc.clientset.CoreV1().Pods("ci").Get(kc.ctx, podName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []corev1.PodSchedulingGate{{Name: claimGate}}, pod.Spec.SchedulingGates)
+	assert.Equal(t, corev1.RestartPolicyNever, pod.Spec.RestartPolicy)
+	assert.Equal(t, int64(3600), *pod.Spec.ActiveDeadlineSeconds)
+	assert.False(t, *pod.Spec.AutomountServiceAccountToken)
+
+	clone := pod.Spec.InitContainers[0]
+	assert.Equal(t, defaultGitImage, clone.Image)
+	assert.Equal(t, `git clone --no-checkout -- "$REPOSITORY_URL" /workspace/repo && cd /workspace/repo && git checkout "$COMMIT_HASH" --`, clone.Command[2])
+	assert.NotContains(t, clone.Command[2], repository.Url)
+	assert.Equal(t, pod.Name, clone.Env[0].ValueFrom.SecretKeyRef.Name)
+
+	// Repository of the checkout, deleted with the pod
+	secret, err := kc.clientset.CoreV1().Secrets("ci").Get(kc.ctx, podName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"REPOSITORY_URL": repository.Url, "COMMIT_HASH": "4f1c2ab"}, secret.StringData)
+	assert.Equal(t, "Pod", secret.OwnerReferences[0].Kind)
+
+	container := pod.Spec.Containers[0]
+	assert.Equal(t, "golang:1.23", container.Image)
+	assert.Equal(t, []string{"sh", "-c", "go vet ./... && go test ./..."}, container.Command)
+	assert.Equal(t, repositoryPath, container.WorkingDir)
+	assert.False(t, *container.SecurityContext.AllowPrivilegeEscalation)
+	for _, volume := range pod.Spec.Volumes {
+		assert.Nil(t, volume.HostPath)
+	}
+
+	assert.NoError(t, kc.Delete(podName))
+	assert.False(t, kc.Exists(podName))
+}
+
+// Test running a job pod until it succeeds or fails
+func TestRun(t *testing.T) {
+	pollInterval = 10 * time.Millisecond
+	job, repository := testJob()
+
+	tests := []struct {
+		name      string
+		phase     corev1.PodPhase
+		cloneExit int32
+		jobExit   int32
+		err       string
+	}{
+		{"succeeded", corev1.PodSucceeded, 0, 0, ""},
+		{"job failed", corev1.PodFailed, 0, 2, "container exited with non-zero status: 2"},
+		{"checkout failed", corev1.PodFailed, 128, 0, "repository checkout exited with non-zero status: 128"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kc := newTestClient()
+			podName, err := kc.Prepare(job, repository)
+			assert.NoError(t, err)
+
+			// Finish the pod once it is scheduled
+			go func() {
+				for {
+					pod, err := kc.clientset.CoreV1().Pods("ci").Get(kc.ctx, podName, metav1.GetOptions{})
+					if err == nil && len(pod.Spec.SchedulingGates) == 0 {
+						finishPod(t, kc, podName, tt.phase, tt.cloneExit, tt.jobExit)
+						return
+					}
+					time.Sleep(pollInterval)
+				}
+			}()
+
+			err = kc.Run(podName)
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+
+			// A redelivered job returns right away with the outcome of its pod
+			assert.Equal(t, err, kc.Run(podName))
+
+			// Logs of the containers that started only
+			logs, err := kc.Logs(podName)
+			assert.NoError(t, err)
+			if tt.cloneExit == 0 {
+				assert.Equal(t, "fake logsfake logs", logs.String())
+			} else {
+				assert.Equal(t, "fake logs", logs.String())
+			}
+		})
+	}
+}
+
+// Test failing a job pod whose image cannot be pulled, or which never finishes
+func TestRun_Pending(t *testing.T) {
+	pollInterval = 10 * time.Millisecond
+	job, repository := testJob()
+
+	t.Run("image pull failure", func(t *testing.T) {
+		kc := newTestClient()
+		podName, err := kc.Prepare(job, repository)
+		assert.NoError(t, err)
+
+		pod, err := kc.clientset.CoreV1().Pods("ci").Get(kc.ctx, podName, metav1.GetOptions{})
+		assert.NoError(t, err)
+		pod.Status.Phase = corev1.PodPending
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name:  jobContainer,
+			Image: "golang:1.23",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"}},
+		}}
+		_, err = kc.clientset.CoreV1().Pods("ci").UpdateStatus(kc.ctx, pod, metav1.UpdateOptions{})
+		assert.NoError(t, err)
+
+		assert.EqualError(t, kc.Run(podName), "image `golang:1.23` of container `job` cannot be pulled: ImagePullBackOff Back-off pulling image")
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		t.Setenv("JOB_TIMEOUT", "50ms")
+		kc := newTestClient()
+		podName, err := kc.Prepare(job, repository)
+		assert.NoError(t, err)
+
+		assert.EqualError(t, kc.Run(podName), "job pod did not finish within 50ms")
+	})
+}
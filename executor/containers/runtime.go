@@ -0,0 +1,41 @@
+package containers
+
+import (
+	"bytes"
+	"cicd/pipeci/executor/models"
+	"os"
+)
+
+// Container runtimes, selected with the CONTAINER_RUNTIME variable
+const (
+	RuntimeDocker     = "docker"
+	RuntimeKubernetes = "kubernetes"
+)
+
+/*
+Runtime runs the container of a job.
+A container is prepared without running, so that the job execution can be claimed first,
+then run until it finishes. The id of a container is stored on the job report to resume it.
+*/
+type Runtime interface {
+	// Prepare the container of a job, its repository checked out at the commit
+	Prepare(job models.JobConfiguration, repository models.Repository) (string, error)
+	// Run a prepared container unless a previous delivery already did, then wait for completion
+	Run(containerId string) error
+	// Check if a container still exists on this runtime
+	Exists(containerId string) bool
+	// Logs of a finished container
+	Logs(containerId string) (*bytes.Buffer, error)
+	// Delete a container
+	Delete(containerId string) error
+	// Release the connection to the runtime
+	Close()
+}
+
+/* Name of the configured runtime, Docker by default */
+func RuntimeName() string {
+	if name := os.Getenv("CONTAINER_RUNTIME"); name != "" {
+		return name
+	}
+	return RuntimeDocker
+}
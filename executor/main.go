@@ -6,9 +6,15 @@ import (
 	"cicd/pipeci/executor/db"
 	"cicd/pipeci/executor/storage"
 	"cicd/pipeci/executor/types"
+	"cicd/pipeci/queue"
+	"context"
 	"encoding/json"
 	"flag"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -20,7 +26,6 @@ type QueueItem struct {
 	StageId    int                           `json:"stageId"`
 	PipelineId int                           `json:"pipelineId"`
 	Message    types.JobExecutor_RequestBody `json:"message"`
-	Dependency map[string][]string           `json:"dependency"`
 }
 
 /* Process QueueItem received from message queue */
@@ -29,7 +34,7 @@ func processQueueItem(jsonInput string) error {
 	var queueItem QueueItem
 	if err := json.Unmarshal([]byte(jsonInput), &queueItem); err != nil {
 		log.Printf("[Executor] Error JSON Unmarshalling: %v", err)
-		return err
+		return queue.Permanent(err)
 	}
 
 	log.Println("[Executor] JSON parsed done.")
@@ -71,11 +76,45 @@ func main() {
 
 	// Parse command-line flags
 	jsonInput := flag.String("input", "", "Job JSON input")
+	inputFile := flag.String("input-file", "", "File containing the job JSON input, e.g. a mounted Secret")
+	consume := flag.Bool("consume", false, "Consume jobs from RabbitMQ until SIGTERM")
+	prefetch := flag.Int("prefetch", 1, "Maximum number of jobs executed at once in --consume mode")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 25*time.Second, "Time given to in-flight jobs on SIGTERM before they are requeued")
 	flag.Parse()
 
-	// JSON passed via CLI flag
+	// Long-running consumer, jobs are acked once they are terminal
+	if *consume {
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+		defer stop()
+
+		config := queue.JobConsumerConfig(*prefetch, *shutdownTimeout)
+		// Job containers are not interrupted with their handler, a redelivery resumes them
+		err := queue.Consume(ctx, config, func(_ context.Context, body []byte) error {
+			return processQueueItem(string(body))
+		})
+		if err != nil {
+			log.Fatalf("[Executor] Consumer failed: %v", err)
+		}
+		log.Println("[Executor] Consumer stopped.")
+		return
+	}
+
+	// JSON read from a file, keeps the payload out of the pod spec
+	if *inputFile != "" {
+		content, err := os.ReadFile(*inputFile)
+		if err != nil {
+			log.Fatalf("[Executor] Failed to read input file: %v", err)
+		}
+		*jsonInput = string(content)
+	}
+
+	// JSON passed via CLI flag or file, the message was acked before this pod started
 	if *jsonInput != "" {
 		if err := processQueueItem(*jsonInput); err != nil {
+			config := queue.JobConsumerConfig(*prefetch, *shutdownTimeout)
+			if dlqErr := queue.DeadLetterInput(config, []byte(*jsonInput), err); dlqErr != nil {
+				log.Printf("[Executor] Failed to dead-letter job: %v", dlqErr)
+			}
 			log.Fatalf("[Executor] Job failed: %v", err)
 		}
 	}
@@ -18,17 +18,23 @@ const (
 	PENDING  ExecStatus = "PENDING"
 )
 
+// Check if an execution status is final
+func (status ExecStatus) IsTerminal() bool {
+	return status == SUCCESS || status == FAILED || status == CANCELED
+}
+
 // Pipeline's execution report
 type Pipeline struct {
-	PipelineId int          `json:"pipeline_id" db:"pipeline_id"`
-	Repository string       `json:"repository" db:"repository"`
-	CommitHash string       `json:"commit_hash" db:"commit_hash"`
-	IPAddress  string       `json:"ip_address" db:"ip_address"`
-	Name       string       `json:"name" db:"name"`
-	StageOrder string       `json:"stage_order" db:"stage_order"`
-	Status     ExecStatus   `json:"status" db:"status"`
-	StartTime  time.Time    `json:"start_time" db:"start_time"`
-	EndTime    sql.NullTime `json:"end_time" db:"end_time"`
+	PipelineId  int          `json:"pipeline_id" db:"pipeline_id"`
+	Repository  string       `json:"repository" db:"repository"`
+	CommitHash  string       `json:"commit_hash" db:"commit_hash"`
+	IPAddress   string       `json:"ip_address" db:"ip_address"`
+	Name        string       `json:"name" db:"name"`
+	StageOrder  string       `json:"stage_order" db:"stage_order"`
+	Status      ExecStatus   `json:"status" db:"status"`
+	StartTime   time.Time    `json:"start_time" db:"start_time"`
+	EndTime     sql.NullTime `json:"end_time" db:"end_time"`
+	ExecutionId string       `json:"execution_id" db:"execution_id"`
 }
 
 // Stage's execution report
@@ -52,4 +58,5 @@ type Job struct {
 	StartTime   time.Time    `json:"start_time" db:"start_time"`
 	EndTime     sql.NullTime `json:"end_time" db:"end_time"`
 	ContainerId string       `json:"container_id" db:"container_id"`
-}
\ No newline at end of file
+	ExecutionId string       `json:"execution_id" db:"execution_id"`
+}
@@ -31,7 +31,16 @@ type JobConfiguration struct {
 
 // Pipeline identifier info.
 type PipelineInfo struct {
-	Name *ConfigurationNode[string] // (required) Name of pipeline.
+	Name     *ConfigurationNode[string] // (required) Name of pipeline.
+	Priority *ConfigurationNode[string] // (optional) Queue priority: high, normal or low. Defaults to normal.
+	// (optional) Runs of a repository in the same group share the Concurrency limit. Defaults to the pipeline name.
+	ConcurrencyGroup *ConfigurationNode[string]
+	// (optional) Maximum concurrent runs of the group per repository. Defaults to 1 with a group, unlimited otherwise.
+	Concurrency *ConfigurationNode[int]
+	// (optional) Cancel the runs in progress of the group instead of waiting for them to finish.
+	CancelInProgress *ConfigurationNode[bool]
+	// (optional) Maximum duration of a run, e.g. `30m`. Runs past it are stopped by the operator.
+	Timeout *ConfigurationNode[string]
 }
 
 // Pipeline configuration
@@ -21,8 +21,8 @@ func (service *JobService) CreateJob(job models.Job) (int, error) {
 	job.StartTime = time.Now()
 
 	result, err := service.db.Exec(
-		"INSERT INTO Jobs (stage_id, name, image, script, status, start_time, container_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		job.StageId, job.Name, job.Image, job.Script, job.Status, job.StartTime, job.ContainerId,
+		"INSERT INTO Jobs (stage_id, name, image, script, status, start_time, container_id, execution_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		job.StageId, job.Name, job.Image, job.Script, job.Status, job.StartTime, job.ContainerId, job.ExecutionId,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("CreateJob: %v", err)
@@ -37,6 +37,45 @@ func (service *JobService) CreateJob(job models.Job) (int, error) {
 	return int(jobID), nil
 }
 
+// Get the job report of an execution, nil if no job was created for it
+func (service *JobService) GetJobByExecutionId(executionId string) (*models.Job, error) {
+	var job models.Job
+	err := service.db.QueryRow(
+		"SELECT job_id, stage_id, name, image, script, status, start_time, end_time, container_id, execution_id FROM Jobs WHERE execution_id = ?",
+		executionId,
+	).Scan(
+		&job.JobId, &job.StageId, &job.Name,
+		&job.Image, &job.Script, &job.Status,
+		&job.StartTime, &job.EndTime, &job.ContainerId, &job.ExecutionId,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetJobByExecutionId: %v", err)
+	}
+	return &job, nil
+}
+
+/*
+Record the container running a pending job, unless another executor replaced
+`previousContainerId` first. Returns false when the claim was lost.
+*/
+func (service *JobService) ClaimJob(jobID int, previousContainerId, containerId string) (bool, error) {
+	result, err := service.db.Exec(
+		"UPDATE Jobs SET container_id = ? WHERE job_id = ? AND container_id = ? AND status = ?",
+		containerId, jobID, previousContainerId, models.PENDING,
+	)
+	if err != nil {
+		return false, fmt.Errorf("ClaimJob: %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("ClaimJob: %v", err)
+	}
+	return rowsAffected == 1, nil
+}
+
 // Update job status and end_time
 func (service *JobService) UpdateJobStatusAndEndTime(jobID int, containerId string, status models.ExecStatus) error {
 	var endTime time.Time = time.Now()
@@ -28,6 +28,7 @@ func TestCreateJob(t *testing.T) {
 		Status:      models.PENDING,
 		StartTime:   time.Now(),
 		ContainerId: "container1",
+		ExecutionId: "job_1",
 	}
 
 	// Expect the exec and return a mock result
@@ -40,6 +41,7 @@ func TestCreateJob(t *testing.T) {
 			job.Status,
 			sqlmock.AnyArg(), // Use AnyArg for the start_time argument
 			job.ContainerId,
+			job.ExecutionId,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -161,6 +163,7 @@ func TestCreateJob_DBError(t *testing.T) {
 			job.Status,
 			sqlmock.AnyArg(), // Use AnyArg for the start_time argument
 			job.ContainerId,
+			job.ExecutionId,
 		).
 		WillReturnError(fmt.Errorf("database error"))
 
@@ -253,3 +256,65 @@ func TestUpdateJobStatusAndEndTime_NoRowsAffectedError(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestGetJobByExecutionId(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewJobService(db)
+
+	rows := sqlmock.NewRows([]string{"job_id", "stage_id", "name", "image", "script", "status", "start_time", "end_time", "container_id", "execution_id"}).
+		AddRow(1, 2, "compile", "golang", "go build", models.PENDING, time.Now(), nil, "container1", "job_1")
+	mock.ExpectQuery("FROM Jobs WHERE execution_id = \\?").
+		WithArgs("job_1").
+		WillReturnRows(rows)
+	mock.ExpectQuery("FROM Jobs WHERE execution_id = \\?").
+		WithArgs("job_2").
+		WillReturnRows(sqlmock.NewRows([]string{"job_id"}))
+
+	job, err := service.GetJobByExecutionId("job_1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, job.JobId)
+	assert.Equal(t, "container1", job.ContainerId)
+
+	job, err = service.GetJobByExecutionId("job_2")
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestClaimJob(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewJobService(db)
+
+	mock.ExpectExec("UPDATE Jobs SET container_id = \\? WHERE job_id = \\? AND container_id = \\? AND status = \\?").
+		WithArgs("container2", 1, "", models.PENDING).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Claimed by another executor meanwhile
+	mock.ExpectExec("UPDATE Jobs SET container_id = \\? WHERE job_id = \\? AND container_id = \\? AND status = \\?").
+		WithArgs("container3", 1, "", models.PENDING).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	claimed, err := service.ClaimJob(1, "", "container2")
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = service.ClaimJob(1, "", "container3")
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
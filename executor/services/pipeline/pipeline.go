@@ -20,8 +20,8 @@ func (service *PipelineService) CreatePipeline(pipeline models.Pipeline) (int, e
 	pipeline.StartTime = time.Now()
 
 	result, err := service.db.Exec(
-		"INSERT INTO Pipelines (repository, commit_hash, ip_address, name, stage_order, status, start_time) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		pipeline.Repository, pipeline.CommitHash, pipeline.IPAddress, pipeline.Name, pipeline.StageOrder, pipeline.Status, pipeline.StartTime,
+		"INSERT INTO Pipelines (repository, commit_hash, ip_address, name, stage_order, status, start_time, execution_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		pipeline.Repository, pipeline.CommitHash, pipeline.IPAddress, pipeline.Name, pipeline.StageOrder, pipeline.Status, pipeline.StartTime, pipeline.ExecutionId,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("CreatePipeline: %v", err)
@@ -22,13 +22,14 @@ func TestCreatePipeline(t *testing.T) {
 
 	// Define the pipeline
 	pipeline := models.Pipeline{
-		Repository: "repo1",
-		CommitHash: "abc123",
-		IPAddress:  "192.168.1.1",
-		Name:       "pipeline1",
-		StageOrder: "",
-		Status:     models.PENDING,
-		StartTime:  time.Now(),
+		Repository:  "repo1",
+		CommitHash:  "abc123",
+		IPAddress:   "192.168.1.1",
+		Name:        "pipeline1",
+		StageOrder:  "",
+		Status:      models.PENDING,
+		StartTime:   time.Now(),
+		ExecutionId: "task1",
 	}
 
 	// Expect the exec and return a mock result
@@ -41,6 +42,7 @@ func TestCreatePipeline(t *testing.T) {
 			pipeline.StageOrder,
 			pipeline.Status,
 			sqlmock.AnyArg(), // Use AnyArg for the time argument
+			pipeline.ExecutionId,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -156,6 +158,7 @@ func TestCreatePipeline_DBError(t *testing.T) {
 			pipeline.StageOrder,
 			pipeline.Status,
 			sqlmock.AnyArg(), // Use AnyArg for the start_time argument
+			"",
 		).
 		WillReturnError(fmt.Errorf("database error"))
 
@@ -0,0 +1,126 @@
+package StatusService
+
+import (
+	"cicd/pipeci/executor/models"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateStageStatus(t *testing.T) {
+	assert.Equal(t, models.PENDING, AggregateStageStatus([]models.ExecStatus{models.SUCCESS, models.PENDING}))
+	assert.Equal(t, models.PENDING, AggregateStageStatus([]models.ExecStatus{models.FAILED, models.PENDING}))
+	assert.Equal(t, models.FAILED, AggregateStageStatus([]models.ExecStatus{models.CANCELED, models.FAILED}))
+	assert.Equal(t, models.CANCELED, AggregateStageStatus([]models.ExecStatus{models.SUCCESS, models.CANCELED}))
+	assert.Equal(t, models.SUCCESS, AggregateStageStatus([]models.ExecStatus{models.SUCCESS, models.SUCCESS}))
+}
+
+func TestAggregatePipelineStatus(t *testing.T) {
+	stageOrder := []string{"build", "test"}
+
+	// Later stage not started yet
+	assert.Equal(t, models.PENDING, AggregatePipelineStatus(stageOrder, map[string]models.ExecStatus{"build": models.SUCCESS}))
+	// Failed stage ends the pipeline
+	assert.Equal(t, models.FAILED, AggregatePipelineStatus(stageOrder, map[string]models.ExecStatus{"build": models.FAILED}))
+	assert.Equal(t, models.CANCELED, AggregatePipelineStatus(stageOrder, map[string]models.ExecStatus{"build": models.SUCCESS, "test": models.CANCELED}))
+	assert.Equal(t, models.SUCCESS, AggregatePipelineStatus(stageOrder, map[string]models.ExecStatus{"build": models.SUCCESS, "test": models.SUCCESS}))
+}
+
+func TestUpdateJobStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewStatusService(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT s.stage_id, s.pipeline_id FROM Jobs").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"stage_id", "pipeline_id"}).AddRow(2, 1))
+	mock.ExpectQuery("SELECT stage_order FROM Pipelines WHERE pipeline_id = \\? FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"stage_order"}).AddRow("build,test"))
+	mock.ExpectQuery("SELECT stage_id FROM Stages WHERE stage_id = \\? FOR UPDATE").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"stage_id"}).AddRow(2))
+	mock.ExpectExec("UPDATE Jobs SET container_id = \\?, status = \\?, end_time = \\? WHERE job_id = \\? AND status = \\?").
+		WithArgs("container", models.SUCCESS, sqlmock.AnyArg(), 3, models.PENDING).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT name, status FROM Jobs WHERE stage_id = \\?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "status"}).
+			AddRow("unit-test", "SUCCESS").
+			AddRow("coverage", "SUCCESS"))
+	mock.ExpectExec("UPDATE Stages SET status = \\?, end_time = \\? WHERE stage_id = \\?").
+		WithArgs(models.SUCCESS, sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT name, status FROM Stages WHERE pipeline_id = \\?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "status"}).
+			AddRow("build", "SUCCESS").
+			AddRow("test", "SUCCESS"))
+	mock.ExpectExec("UPDATE Pipelines SET status = \\?, end_time = \\? WHERE pipeline_id = \\?").
+		WithArgs(models.SUCCESS, sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	stageStatus, pipelineStatus, err := service.UpdateJobStatus(3, "container", models.SUCCESS)
+	assert.NoError(t, err)
+	assert.Equal(t, models.SUCCESS, stageStatus)
+	assert.Equal(t, models.SUCCESS, pipelineStatus)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateJobStatus_AlreadyFinished(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewStatusService(db)
+
+	// The job is terminal: its status, end time, stage and pipeline are left as they are
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT s.stage_id, s.pipeline_id FROM Jobs").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"stage_id", "pipeline_id"}).AddRow(2, 1))
+	mock.ExpectQuery("SELECT stage_order FROM Pipelines WHERE pipeline_id = \\? FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"stage_order"}).AddRow("build,test"))
+	mock.ExpectQuery("SELECT stage_id FROM Stages WHERE stage_id = \\? FOR UPDATE").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"stage_id"}).AddRow(2))
+	mock.ExpectExec("UPDATE Jobs SET container_id = \\?, status = \\?, end_time = \\? WHERE job_id = \\? AND status = \\?").
+		WithArgs("container", models.SUCCESS, sqlmock.AnyArg(), 3, models.PENDING).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	_, _, err = service.UpdateJobStatus(3, "container", models.SUCCESS)
+	assert.ErrorIs(t, err, ErrJobFinished)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateJobStatus_RollbackOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewStatusService(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT s.stage_id, s.pipeline_id FROM Jobs").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"stage_id", "pipeline_id"}))
+	mock.ExpectRollback()
+
+	_, _, err = service.UpdateJobStatus(3, "", models.FAILED)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "UpdateJobStatus")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -99,7 +99,7 @@ type CacheConfig struct {
 
 	// +kubebuilder:validation:Minimum=1
 	// +kubebuilder:validation:Maximum=65535
-	// +kubebuilder:default=3306
+	// +kubebuilder:default=6379
 	Port int32 `json:"port,omitempty"`
 
 	// +kubebuilder:validation:Required
@@ -124,13 +124,78 @@ type WorkerPoolConfig struct {
 	// +kubebuilder:default="latest"
 	WorkerImageTag string `json:"workerImageTag,omitempty"`
 
+	// Resources of the worker container, 500m CPU and 256Mi to 512Mi memory when not set
+	// +optional
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Labels of the nodes the workers may run on
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the worker pods, e.g. to run on dedicated build nodes
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Service account of the worker pods, the namespace default when not set
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// Workload started per message: a bare Pod, or a batch/v1 Job retried on failure
+	// +kubebuilder:validation:Enum=Pod;Job
+	// +kubebuilder:default=Pod
+	Mode string `json:"mode,omitempty"`
+
+	// Settings of the worker Jobs in Job mode
+	// +optional
+	Job WorkerJobConfig `json:"job,omitempty"`
+
+	// Runtime of the job containers: the Docker socket of the node, or a Kubernetes pod per job.
+	// The kubernetes runtime needs a service account allowed to manage pods, see ServiceAccountName
+	// +kubebuilder:validation:Enum=docker;kubernetes
+	// +kubebuilder:default=docker
+	Runtime string `json:"runtime,omitempty"`
+}
+
+// Worker pool roles
+const (
+	PoolScalerRolePipeline = "pipeline"
+	PoolScalerRoleJob      = "job"
+)
+
+// Container runtimes of the workers
+const (
+	WorkerRuntimeDocker     = "docker"
+	WorkerRuntimeKubernetes = "kubernetes"
+)
+
+// Worker pool modes
+const (
+	WorkerPoolModePod = "Pod"
+	WorkerPoolModeJob = "Job"
+)
+
+type WorkerJobConfig struct {
+	// Retries of a worker Job before it is marked failed
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=2
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// Time a finished worker Job is kept before Kubernetes deletes it
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=300
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// Deadline of a worker Job whose pipeline sets no timeout
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=3600
+	DefaultDeadlineSeconds *int64 `json:"defaultDeadlineSeconds,omitempty"`
 }
 
 type ScalingConfig struct {
-	// // +kubebuilder:validation:Minimum=1
-	// // +kubebuilder:default=1
-	// MessagesPerWorker int32 `json:"messagesPerWorker,omitempty"`
+	// Queued messages per worker, the pool grows to one worker every MessagesPerWorker messages
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	MessagesPerWorker int32 `json:"messagesPerWorker,omitempty"`
 
 	// // +kubebuilder:validation:Minimum=1
 	// // +kubebuilder:default=30
@@ -140,16 +205,36 @@ type ScalingConfig struct {
 	// // +kubebuilder:default=5
 	// ScaleDownThreshold int32 `json:"scaleDownThreshold,omitempty"`
 
+	// Seconds between two checks of the input queue depth, at most 5: new messages trigger a reconciliation within it
 	// +kubebuilder:validation:Minimum=1
-	// +kubebuilder:default=30
+	// +kubebuilder:default=5
 	PollingIntervalSeconds int32 `json:"pollingIntervalSeconds,omitempty"`
 
-	// // +kubebuilder:default=300
-	// CooldownPeriodSeconds int32 `json:"cooldownPeriodSeconds,omitempty"`
+	// Minimum time between two scale ups
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=30
+	CooldownPeriodSeconds int32 `json:"cooldownPeriodSeconds,omitempty"`
+}
+
+type CleanupConfig struct {
+	// Time a succeeded worker pod is kept after it finished
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=300
+	SucceededPodTTLSeconds *int32 `json:"succeededPodTTLSeconds,omitempty"`
+
+	// Time a failed worker pod is kept after it finished, to inspect its logs
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=86400
+	FailedPodRetentionSeconds *int32 `json:"failedPodRetentionSeconds,omitempty"`
 }
 
 // PoolScalerSpec defines the desired state of PoolScaler.
 type PoolScalerSpec struct {
+	// Tier served by the pool: pipeline workers publishing jobs to the output queue, or job executors
+	// +kubebuilder:validation:Enum=pipeline;job
+	// +kubebuilder:default=pipeline
+	Role string `json:"role,omitempty"`
+
 	// +kubebuilder:validation:Required
 	InputQueue RabbitMQConfig `json:"inputQueue"`
 
@@ -170,24 +255,59 @@ type PoolScalerSpec struct {
 
 	// +kubebuilder:validation:Required
 	Scaling ScalingConfig `json:"scaling"`
+
+	// +kubebuilder:validation:Optional
+	Cleanup CleanupConfig `json:"cleanup,omitempty"`
 }
 
+// Phases of a PoolScaler, summarizing its conditions
+const (
+	PoolScalerPhasePending = "Pending" // Not every condition is known yet
+	PoolScalerPhaseRunning = "Running" // Every condition is true
+	PoolScalerPhaseError   = "Error"   // A condition is false
+)
+
+// Condition types of a PoolScaler
+const (
+	// The Secrets read by the operator were found
+	ConditionSecretsResolved = "SecretsResolved"
+	// The input queue answered, its depth is in QueueMessages
+	ConditionQueueReachable = "QueueReachable"
+	// Workers are listed and started for the waiting messages
+	ConditionScaling = "Scaling"
+)
+
 // PoolScalerStatus defines the observed state of PoolScaler.
 type PoolScalerStatus struct {
 	// +kubebuilder:validation:Enum=Pending;Running;Error
 	Phase string `json:"phase,omitempty"`
 
+	// Worker pods pending or running
 	CurrentReplicas int32 `json:"currentReplicas,omitempty"`
 
 	QueueMessages int32 `json:"queueMessages,omitempty"`
 
+	// Last time worker pods were started
 	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
 
+	// Worker pods that succeeded, counted once when they finish
+	SucceededPods int32 `json:"succeededPods,omitempty"`
+
+	// Worker pods that failed, counted once when they finish
+	FailedPods int32 `json:"failedPods,omitempty"`
+
+	// Failed worker pods per exit reason, e.g. OOMKilled, Error or DeadlineExceeded
+	FailureReasons map[string]int32 `json:"failureReasons,omitempty"`
+
+	// Latest observations of the PoolScaler, one per type: SecretsResolved, QueueReachable and Scaling
+	// +listType=map
+	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Role",type="string",JSONPath=".spec.role"
 // +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
 // +kubebuilder:printcolumn:name="Replicas",type="integer",JSONPath=".status.currentReplicas"
 // +kubebuilder:printcolumn:name="Messages",type="integer",JSONPath=".status.queueMessages"
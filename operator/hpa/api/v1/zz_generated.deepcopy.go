@@ -21,6 +21,7 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -41,6 +42,31 @@ func (in *CacheConfig) DeepCopy() *CacheConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CleanupConfig) DeepCopyInto(out *CleanupConfig) {
+	*out = *in
+	if in.SucceededPodTTLSeconds != nil {
+		in, out := &in.SucceededPodTTLSeconds, &out.SucceededPodTTLSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailedPodRetentionSeconds != nil {
+		in, out := &in.FailedPodRetentionSeconds, &out.FailedPodRetentionSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CleanupConfig.
+func (in *CleanupConfig) DeepCopy() *CleanupConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CleanupConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DatabaseConfig) DeepCopyInto(out *DatabaseConfig) {
 	*out = *in
@@ -131,6 +157,7 @@ func (in *PoolScalerSpec) DeepCopyInto(out *PoolScalerSpec) {
 	out.Cache = in.Cache
 	in.WorkerPool.DeepCopyInto(&out.WorkerPool)
 	out.Scaling = in.Scaling
+	in.Cleanup.DeepCopyInto(&out.Cleanup)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolScalerSpec.
@@ -150,6 +177,13 @@ func (in *PoolScalerStatus) DeepCopyInto(out *PoolScalerStatus) {
 		in, out := &in.LastScaleTime, &out.LastScaleTime
 		*out = (*in).DeepCopy()
 	}
+	if in.FailureReasons != nil {
+		in, out := &in.FailureReasons, &out.FailureReasons
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]metav1.Condition, len(*in))
@@ -232,10 +266,55 @@ func (in *StorageConfig) DeepCopy() *StorageConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkerJobConfig) DeepCopyInto(out *WorkerJobConfig) {
+	*out = *in
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DefaultDeadlineSeconds != nil {
+		in, out := &in.DefaultDeadlineSeconds, &out.DefaultDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkerJobConfig.
+func (in *WorkerJobConfig) DeepCopy() *WorkerJobConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkerJobConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkerPoolConfig) DeepCopyInto(out *WorkerPoolConfig) {
 	*out = *in
 	in.Resources.DeepCopyInto(&out.Resources)
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Job.DeepCopyInto(&out.Job)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkerPoolConfig.
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+)
+
+const (
+	// Annotation of the finished worker pods already counted in the status
+	accountedAnnotation = "hpa.cicd.operator/accounted"
+	// Defaults of CleanupConfig, for resources created before it existed
+	defaultSucceededPodTTL    = 5 * time.Minute
+	defaultFailedPodRetention = 24 * time.Hour
+)
+
+// Check if a pod reached a terminal phase
+func isPodFinished(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+// Exit reason of a failed pod: the reason of its failed container, e.g. OOMKilled, else of the pod, e.g. Evicted
+func podFailureReason(pod *corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			if terminated.Reason != "" {
+				return terminated.Reason
+			}
+			return "Error"
+		}
+	}
+	if pod.Status.Reason != "" {
+		return pod.Status.Reason
+	}
+	return "Unknown"
+}
+
+// Time a pod finished, from its containers, falling back to its start then creation time
+func podFinishTime(pod *corev1.Pod) time.Time {
+	var finished time.Time
+	for _, status := range pod.Status.ContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil && terminated.FinishedAt.After(finished) {
+			finished = terminated.FinishedAt.Time
+		}
+	}
+	if !finished.IsZero() {
+		return finished
+	}
+	if pod.Status.StartTime != nil {
+		return pod.Status.StartTime.Time
+	}
+	return pod.CreationTimestamp.Time
+}
+
+// Time a finished pod is kept: its TTL when it succeeded, the retention window when it failed
+func podRetention(pod *corev1.Pod, cleanup hpav1.CleanupConfig) time.Duration {
+	if pod.Status.Phase == corev1.PodSucceeded {
+		if cleanup.SucceededPodTTLSeconds == nil {
+			return defaultSucceededPodTTL
+		}
+		return time.Duration(*cleanup.SucceededPodTTLSeconds) * time.Second
+	}
+	if cleanup.FailedPodRetentionSeconds == nil {
+		return defaultFailedPodRetention
+	}
+	return time.Duration(*cleanup.FailedPodRetentionSeconds) * time.Second
+}
+
+// Count the outcome of a finished pod in the status
+func recordPodOutcome(status *hpav1.PoolScalerStatus, pod *corev1.Pod) {
+	if pod.Status.Phase == corev1.PodSucceeded {
+		status.SucceededPods++
+		return
+	}
+	status.FailedPods++
+	if status.FailureReasons == nil {
+		status.FailureReasons = make(map[string]int32)
+	}
+	status.FailureReasons[podFailureReason(pod)]++
+}
+
+/*
+Observe the start latency of every started worker pod once, count every finished
+worker pod once in the status, then delete it when its TTL or retention window is over. Returns the time until the next finished pod expires,
+zero when no finished pod is waiting. The status is saved by the caller.
+*/
+func (r *PoolScalerReconciler) collectFinishedWorkers(ctx context.Context, poolScaler *hpav1.PoolScaler) (time.Duration, error) {
+	log := logf.FromContext(ctx)
+
+	pods := &corev1.PodList{}
+	err := r.List(ctx, pods,
+		client.InNamespace(poolScaler.Namespace),
+		client.MatchingLabels{instanceLabel: poolScaler.Name},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	var next time.Duration
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if err := r.observeWorkerStart(ctx, poolScaler, pod); err != nil {
+			return 0, err
+		}
+		if !isPodFinished(pod) {
+			continue
+		}
+
+		// Mark the pod before counting it, a failed status update undercounts rather than counts twice
+		if pod.Annotations[accountedAnnotation] == "" {
+			patch := client.MergeFrom(pod.DeepCopy())
+			if pod.Annotations == nil {
+				pod.Annotations = make(map[string]string)
+			}
+			pod.Annotations[accountedAnnotation] = "true"
+			if err := r.Patch(ctx, pod, patch); err != nil {
+				return 0, err
+			}
+			recordPodOutcome(&poolScaler.Status, pod)
+			if pod.Status.Phase == corev1.PodFailed {
+				workerPodsFailed.With(poolScalerLabels(poolScaler)).Inc()
+				log.Info("Worker pod failed", "pod", pod.Name, "reason", podFailureReason(pod))
+			}
+		}
+
+		// Pods of worker Jobs are deleted with their Job, see ttlSecondsAfterFinished
+		if owner := metav1.GetControllerOf(pod); owner != nil && owner.Kind == "Job" {
+			continue
+		}
+
+		remaining := podFinishTime(pod).Add(podRetention(pod, poolScaler.Spec.Cleanup)).Sub(now)
+		if remaining > 0 {
+			if next == 0 || remaining < next {
+				next = remaining
+			}
+			continue
+		}
+		err := r.Delete(ctx, pod, client.PropagationPolicy("Background"))
+		if err != nil && !errors.IsNotFound(err) {
+			return 0, err
+		}
+		log.Info("Deleted finished worker pod", "pod", pod.Name, "phase", pod.Status.Phase)
+	}
+	return next, nil
+}
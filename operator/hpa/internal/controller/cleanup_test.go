@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+)
+
+var _ = Describe("Finished worker pods", func() {
+	finishedAt := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	// Pod whose worker container terminated with the given exit code and reason
+	terminatedPod := func(phase corev1.PodPhase, exitCode int32, reason string) *corev1.Pod {
+		return &corev1.Pod{
+			Status: corev1.PodStatus{
+				Phase: phase,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name: "worker",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						ExitCode:   exitCode,
+						Reason:     reason,
+						FinishedAt: metav1.NewTime(finishedAt),
+					}},
+				}},
+			},
+		}
+	}
+
+	It("should report the exit reason of failed pods", func() {
+		Expect(podFailureReason(terminatedPod(corev1.PodFailed, 137, "OOMKilled"))).To(Equal("OOMKilled"))
+		Expect(podFailureReason(terminatedPod(corev1.PodFailed, 1, ""))).To(Equal("Error"))
+
+		evicted := &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"}}
+		Expect(podFailureReason(evicted)).To(Equal("Evicted"))
+		Expect(podFailureReason(&corev1.Pod{})).To(Equal("Unknown"))
+	})
+
+	It("should keep succeeded pods for their TTL and failed pods for the retention window", func() {
+		succeeded := terminatedPod(corev1.PodSucceeded, 0, "Completed")
+		failed := terminatedPod(corev1.PodFailed, 1, "Error")
+		Expect(podFinishTime(succeeded)).To(Equal(finishedAt))
+
+		Expect(podRetention(succeeded, hpav1.CleanupConfig{})).To(Equal(defaultSucceededPodTTL))
+		Expect(podRetention(failed, hpav1.CleanupConfig{})).To(Equal(defaultFailedPodRetention))
+
+		cleanup := hpav1.CleanupConfig{SucceededPodTTLSeconds: ptr.To(int32(0)), FailedPodRetentionSeconds: ptr.To(int32(600))}
+		Expect(podRetention(succeeded, cleanup)).To(BeZero())
+		Expect(podRetention(failed, cleanup)).To(Equal(10 * time.Minute))
+	})
+
+	It("should count outcomes and failure reasons", func() {
+		status := hpav1.PoolScalerStatus{}
+		recordPodOutcome(&status, terminatedPod(corev1.PodSucceeded, 0, "Completed"))
+		recordPodOutcome(&status, terminatedPod(corev1.PodFailed, 137, "OOMKilled"))
+		recordPodOutcome(&status, terminatedPod(corev1.PodFailed, 137, "OOMKilled"))
+		recordPodOutcome(&status, terminatedPod(corev1.PodFailed, 1, ""))
+
+		Expect(status.SucceededPods).To(Equal(int32(1)))
+		Expect(status.FailedPods).To(Equal(int32(3)))
+		Expect(status.FailureReasons).To(Equal(map[string]int32{"OOMKilled": 2, "Error": 1}))
+	})
+})
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+)
+
+// Condition appended on every failure by earlier versions of the operator
+const legacyReadyCondition = "Ready"
+
+// Conditions that make up the phase of a PoolScaler
+var phaseConditions = []string{hpav1.ConditionSecretsResolved, hpav1.ConditionQueueReachable, hpav1.ConditionScaling}
+
+// Sets a condition of a PoolScaler, its transition time only moves when its status changes
+func setCondition(poolScaler *hpav1.PoolScaler, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&poolScaler.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: poolScaler.Generation,
+	})
+	poolScaler.Status.Phase = poolScalerPhase(poolScaler.Status.Conditions)
+}
+
+/*
+Phase of a PoolScaler from its conditions: Error as soon as one is false,
+Running once all of them are true, Pending until then.
+*/
+func poolScalerPhase(conditions []metav1.Condition) string {
+	phase := hpav1.PoolScalerPhaseRunning
+	for _, conditionType := range phaseConditions {
+		condition := meta.FindStatusCondition(conditions, conditionType)
+		switch {
+		case condition == nil || condition.Status == metav1.ConditionUnknown:
+			phase = hpav1.PoolScalerPhasePending
+		case condition.Status == metav1.ConditionFalse:
+			return hpav1.PoolScalerPhaseError
+		}
+	}
+	return phase
+}
+
+// Drops the Ready conditions of earlier versions, duplicated types are rejected by the API server
+func removeLegacyConditions(status *hpav1.PoolScalerStatus) {
+	conditions := status.Conditions[:0]
+	for _, condition := range status.Conditions {
+		if condition.Type != legacyReadyCondition {
+			conditions = append(conditions, condition)
+		}
+	}
+	status.Conditions = conditions
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+)
+
+var _ = Describe("PoolScaler conditions", func() {
+	It("should keep one condition per type", func() {
+		poolScaler := &hpav1.PoolScaler{}
+		setCondition(poolScaler, hpav1.ConditionQueueReachable, metav1.ConditionFalse, "QueueCheckFailed", "connection refused")
+		transition := poolScaler.Status.Conditions[0].LastTransitionTime
+
+		setCondition(poolScaler, hpav1.ConditionQueueReachable, metav1.ConditionFalse, "QueueCheckFailed", "connection reset")
+		Expect(poolScaler.Status.Conditions).To(HaveLen(1))
+		Expect(poolScaler.Status.Conditions[0].Message).To(Equal("connection reset"))
+		Expect(poolScaler.Status.Conditions[0].LastTransitionTime).To(Equal(transition))
+	})
+
+	It("should derive the phase from the conditions", func() {
+		poolScaler := &hpav1.PoolScaler{}
+		setCondition(poolScaler, hpav1.ConditionSecretsResolved, metav1.ConditionTrue, "SecretsFound", "")
+		setCondition(poolScaler, hpav1.ConditionQueueReachable, metav1.ConditionTrue, "QueueChecked", "")
+		Expect(poolScaler.Status.Phase).To(Equal(hpav1.PoolScalerPhasePending))
+
+		setCondition(poolScaler, hpav1.ConditionScaling, metav1.ConditionTrue, "Stable", "")
+		Expect(poolScaler.Status.Phase).To(Equal(hpav1.PoolScalerPhaseRunning))
+
+		setCondition(poolScaler, hpav1.ConditionQueueReachable, metav1.ConditionFalse, "QueueCheckFailed", "")
+		Expect(poolScaler.Status.Phase).To(Equal(hpav1.PoolScalerPhaseError))
+
+		setCondition(poolScaler, hpav1.ConditionQueueReachable, metav1.ConditionTrue, "QueueChecked", "")
+		Expect(poolScaler.Status.Phase).To(Equal(hpav1.PoolScalerPhaseRunning))
+	})
+
+	It("should drop the Ready conditions of earlier versions", func() {
+		status := &hpav1.PoolScalerStatus{Conditions: []metav1.Condition{
+			{Type: legacyReadyCondition, Reason: "QueueCheckFailed"},
+			{Type: hpav1.ConditionScaling, Reason: "Stable"},
+			{Type: legacyReadyCondition, Reason: "QueueCheckFailed"},
+		}}
+		removeLegacyConditions(status)
+		Expect(status.Conditions).To(HaveLen(1))
+		Expect(status.Conditions[0].Type).To(Equal(hpav1.ConditionScaling))
+	})
+
+	It("should record a failure as a condition and an event", func() {
+		scheme := runtime.NewScheme()
+		Expect(hpav1.AddToScheme(scheme)).To(Succeed())
+		poolScaler := &hpav1.PoolScaler{ObjectMeta: metav1.ObjectMeta{Name: "pipeline-scaler", Namespace: "default"}}
+		recorder := record.NewFakeRecorder(10)
+		reconciler := &PoolScalerReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(poolScaler).WithStatusSubresource(poolScaler).Build(),
+			Scheme:   scheme,
+			Recorder: recorder,
+		}
+
+		for range 3 {
+			_, err := reconciler.handleError(context.Background(), poolScaler, hpav1.ConditionSecretsResolved, "FailedToGetPassword", errors.New(`secrets "rabbitmq" not found`))
+			Expect(err).To(HaveOccurred())
+		}
+
+		Expect(poolScaler.Status.Phase).To(Equal(hpav1.PoolScalerPhaseError))
+		Expect(poolScaler.Status.Conditions).To(HaveLen(1))
+		condition := meta.FindStatusCondition(poolScaler.Status.Conditions, hpav1.ConditionSecretsResolved)
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("FailedToGetPassword"))
+		Expect(recorder.Events).To(Receive(Equal(`Warning FailedToGetPassword secrets "rabbitmq" not found`)))
+	})
+})
@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+)
+
+// Execution statuses stored by the workers, see backend/db/init.sql
+const (
+	statusSuccess  = "SUCCESS"
+	statusFailed   = "FAILED"
+	statusCanceled = "CANCELED"
+)
+
+// Opens the pipeline database of a PoolScaler, the same database its workers report to
+func (r *PoolScalerReconciler) openDatabase(poolScaler *hpav1.PoolScaler) (*sql.DB, error) {
+	spec := poolScaler.Spec.Database
+	password, err := r.getSecretValue(poolScaler.Namespace, spec.PasswordSecretRef)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := mysql.Config{
+		User:      spec.Username,
+		Passwd:    password,
+		Net:       "tcp",
+		Addr:      fmt.Sprintf("%s:%d", spec.Host, spec.Port),
+		DBName:    spec.Name,
+		ParseTime: true,
+	}
+
+	// Configure SSL if enabled, like the workers
+	if spec.SSLMode == "true" && spec.SSLCASecretRef != nil {
+		pem, err := r.getSecretValue(poolScaler.Namespace, *spec.SSLCASecretRef)
+		if err != nil {
+			return nil, err
+		}
+		rootCertPool := x509.NewCertPool()
+		if ok := rootCertPool.AppendCertsFromPEM([]byte(pem)); !ok {
+			return nil, fmt.Errorf("failed to append CA cert")
+		}
+
+		tlsConfigName := fmt.Sprintf("poolscaler-%s-%s", poolScaler.Namespace, poolScaler.Name)
+		if err := mysql.RegisterTLSConfig(tlsConfigName, &tls.Config{RootCAs: rootCertPool, MinVersion: tls.VersionTLS13}); err != nil {
+			return nil, fmt.Errorf("failed to register TLS config: %w", err)
+		}
+		cfg.TLSConfig = tlsConfigName
+	}
+
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return db, nil
+}
+
+/*
+Marks a pipeline execution and its unfinished stages and jobs as failed, when its worker died before it could report.
+Returns false when the execution does not exist or already finished.
+*/
+func failPipelineExecution(db *sql.DB, executionId string) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failPipelineExecution: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	now := time.Now()
+	result, err := tx.Exec(
+		"UPDATE Pipelines SET status = ?, end_time = ? WHERE execution_id = ? AND status NOT IN (?, ?, ?)",
+		statusFailed, now, executionId, statusSuccess, statusFailed, statusCanceled,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failPipelineExecution: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failPipelineExecution: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(
+		"UPDATE Stages SET status = ?, end_time = ? WHERE pipeline_id = (SELECT pipeline_id FROM Pipelines WHERE execution_id = ?) AND status NOT IN (?, ?, ?)",
+		statusFailed, now, executionId, statusSuccess, statusFailed, statusCanceled,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failPipelineExecution: %w", err)
+	}
+
+	_, err = tx.Exec(
+		"UPDATE Jobs SET status = ?, end_time = ? WHERE stage_id IN (SELECT stage_id FROM Stages WHERE pipeline_id = (SELECT pipeline_id FROM Pipelines WHERE execution_id = ?)) AND status NOT IN (?, ?, ?)",
+		statusFailed, now, executionId, statusSuccess, statusFailed, statusCanceled,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failPipelineExecution: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failPipelineExecution: %w", err)
+	}
+	return true, nil
+}
+
+// Job event of the job tier, read by the worker scheduling the pipeline, see the queue module
+type jobEvent struct {
+	Id         string `json:"id"` // Job execution id
+	JobId      int    `json:"jobId"`
+	StageId    int    `json:"stageId"`
+	PipelineId int    `json:"pipelineId"`
+	Status     string `json:"status"`
+}
+
+/*
+Marks an unfinished job as failed, when its executor died before it could report.
+Its stage and pipeline fail with it once the other jobs of the stage finished, like the workers aggregate them.
+Returns the event of the job with its terminal status, nil when the job does not exist.
+*/
+func failJobExecution(db *sql.DB, executionId string) (*jobEvent, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failJobExecution: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	event := &jobEvent{Id: executionId}
+	err = tx.QueryRow(
+		"SELECT j.job_id, s.stage_id, s.pipeline_id FROM Jobs j JOIN Stages s ON j.stage_id = s.stage_id WHERE j.execution_id = ?",
+		executionId,
+	).Scan(&event.JobId, &event.StageId, &event.PipelineId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failJobExecution: %w", err)
+	}
+
+	// Lock pipeline then stage, in the order of the workers
+	if _, err := tx.Exec("SELECT pipeline_id FROM Pipelines WHERE pipeline_id = ? FOR UPDATE", event.PipelineId); err != nil {
+		return nil, fmt.Errorf("failJobExecution: %w", err)
+	}
+	if _, err := tx.Exec("SELECT stage_id FROM Stages WHERE stage_id = ? FOR UPDATE", event.StageId); err != nil {
+		return nil, fmt.Errorf("failJobExecution: %w", err)
+	}
+
+	now := time.Now()
+	result, err := tx.Exec(
+		"UPDATE Jobs SET status = ?, end_time = ? WHERE job_id = ? AND status NOT IN (?, ?, ?)",
+		statusFailed, now, event.JobId, statusSuccess, statusFailed, statusCanceled,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failJobExecution: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failJobExecution: %w", err)
+	}
+
+	// A failed stage ends its pipeline
+	if rowsAffected > 0 {
+		var unfinished int
+		err = tx.QueryRow(
+			"SELECT COUNT(*) FROM Jobs WHERE stage_id = ? AND status NOT IN (?, ?, ?)",
+			event.StageId, statusSuccess, statusFailed, statusCanceled,
+		).Scan(&unfinished)
+		if err != nil {
+			return nil, fmt.Errorf("failJobExecution: %w", err)
+		}
+		if unfinished == 0 {
+			if _, err := tx.Exec("UPDATE Stages SET status = ?, end_time = ? WHERE stage_id = ?", statusFailed, now, event.StageId); err != nil {
+				return nil, fmt.Errorf("failJobExecution: %w", err)
+			}
+			_, err = tx.Exec(
+				"UPDATE Pipelines SET status = ?, end_time = ? WHERE pipeline_id = ? AND status NOT IN (?, ?, ?)",
+				statusFailed, now, event.PipelineId, statusSuccess, statusFailed, statusCanceled,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failJobExecution: %w", err)
+			}
+		}
+	}
+
+	// Reported by the executor meanwhile, its status is published again
+	if err := tx.QueryRow("SELECT status FROM Jobs WHERE job_id = ?", event.JobId).Scan(&event.Status); err != nil {
+		return nil, fmt.Errorf("failJobExecution: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failJobExecution: %w", err)
+	}
+	return event, nil
+}
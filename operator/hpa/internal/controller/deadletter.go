@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	// Exchange receiving the rejected messages of every queue, routed by queue name
+	deadLetterExchange = "dead_letters"
+	// Failed pod creations before a message is dead-lettered
+	maxDeliveryCount = 3
+	// Highest message priority, must match the backend and worker declarations
+	maxPriority = 2
+
+	retryCountHeader = "x-retry-count" // Number of failed deliveries
+	lastErrorHeader  = "x-last-error"  // Error of the last failed delivery
+)
+
+// Name of the queue holding the dead messages of a queue
+func deadLetterQueueName(queueName string) string {
+	return queueName + ".dead"
+}
+
+/*
+Arguments of a priority queue whose rejected messages are dead-lettered, must match the backend and worker declarations.
+Queues declared before them are re-created by the queue migration, priorities cannot be set by a policy.
+*/
+func queueArgs() amqp.Table {
+	return amqp.Table{
+		"x-dead-letter-exchange": deadLetterExchange,
+		"x-max-priority":         int32(maxPriority),
+	}
+}
+
+// Whether a declaration failed on a queue declared with other arguments
+func isArgumentsMismatch(err error) bool {
+	var amqpErr *amqp.Error
+	return errors.As(err, &amqpErr) && amqpErr.Code == amqp.PreconditionFailed
+}
+
+// Declares the dead-letter exchange and the dead-letter queue of a queue
+func declareDeadLetterQueue(ch *amqp.Channel, queueName string) error {
+	if err := ch.ExchangeDeclare(deadLetterExchange, amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare exchange: %w", err)
+	}
+	q, err := ch.QueueDeclare(deadLetterQueueName(queueName), true, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+	// Dead-lettered messages keep the routing key they were published with
+	if err := ch.QueueBind(q.Name, queueName, deadLetterExchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind queue: %w", err)
+	}
+	return nil
+}
+
+// Number of failed deliveries recorded on a message
+func retryCount(headers amqp.Table) int {
+	switch count := headers[retryCountHeader].(type) {
+	case int32:
+		return int(count)
+	case int64:
+		return int(count)
+	case int:
+		return count
+	default:
+		return 0
+	}
+}
+
+/*
+Publishes a message that could not be processed again with an incremented retry count,
+or to the dead-letter queue when permanent or out of retries, then acks the original.
+Falls back to a reject, dead-lettered by the broker through the queue arguments.
+*/
+func retryOrDeadLetter(ch *amqp.Channel, queueName string, msg *amqp.Delivery, cause error, permanent bool) error {
+	headers := amqp.Table{}
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	retries := retryCount(msg.Headers) + 1
+	headers[retryCountHeader] = int32(retries)
+	headers[lastErrorHeader] = cause.Error()
+
+	exchange := "" // Default exchange routes back to the queue
+	if permanent || retries >= maxDeliveryCount {
+		exchange = deadLetterExchange
+	}
+
+	err := ch.Publish(exchange, queueName, false, false, amqp.Publishing{
+		Headers:      headers,
+		DeliveryMode: amqp.Persistent,
+		ContentType:  msg.ContentType,
+		Body:         msg.Body,
+		Priority:     msg.Priority,
+		Timestamp:    msg.Timestamp, // A retry keeps the publication time of the message
+	})
+	if err != nil {
+		if nackErr := ch.Nack(msg.DeliveryTag, false, false); nackErr != nil {
+			return fmt.Errorf("failed to reject message: %w", nackErr)
+		}
+		return nil
+	}
+	if err := ch.Ack(msg.DeliveryTag, false); err != nil {
+		return fmt.Errorf("failed to ack message: %w", err)
+	}
+	return nil
+}
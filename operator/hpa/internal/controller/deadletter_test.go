@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var _ = Describe("Dead-letter queues", func() {
+	It("should tell a queue declared with other arguments", func() {
+		err := fmt.Errorf("failed to declare queue: %w", &amqp.Error{Code: amqp.PreconditionFailed, Reason: "PRECONDITION_FAILED - inequivalent arg 'x-max-priority'"})
+		Expect(isArgumentsMismatch(err)).To(BeTrue())
+		Expect(isArgumentsMismatch(&amqp.Error{Code: amqp.ChannelError})).To(BeFalse())
+		Expect(isArgumentsMismatch(errors.New("connection refused"))).To(BeFalse())
+		Expect(queueArgs()).To(HaveKeyWithValue("x-max-priority", int32(maxPriority)))
+	})
+})
@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+)
+
+const (
+	// Annotation of the worker Jobs with the execution id of their task
+	executionIdAnnotation = "hpa.cicd.operator/execution-id"
+	// Annotation of the failed worker Jobs whose pipeline execution was marked failed
+	reportedAnnotation = "hpa.cicd.operator/reported"
+	// Prefix of the job events queues, the default JOB_EVENTS_QUEUE of workers and executors
+	jobEventsQueuePrefix = "job_events"
+	// Defaults of WorkerJobConfig, for resources created before it existed
+	defaultBackoffLimit            = 2
+	defaultTTLSecondsAfterFinished = 300
+	defaultDeadlineSeconds         = 3600
+)
+
+// Fields of a task message read by the operator, see the backend producer
+type taskMessage struct {
+	Id      string `json:"id"`
+	Message struct {
+		Pipeline struct {
+			Pipeline *struct {
+				Value struct {
+					Timeout *struct {
+						Value string
+					}
+				}
+			}
+		} `json:"pipeline"`
+	} `json:"message"`
+}
+
+// Parse the fields of a task message read by the operator, zero values when malformed
+func parseTaskMessage(messageBody []byte) taskMessage {
+	var task taskMessage
+	_ = json.Unmarshal(messageBody, &task)
+	return task
+}
+
+// Timeout of the pipeline of a task, zero when not set or invalid
+func (task taskMessage) timeout() time.Duration {
+	pipeline := task.Message.Pipeline.Pipeline
+	if pipeline == nil || pipeline.Value.Timeout == nil {
+		return 0
+	}
+	timeout, err := time.ParseDuration(pipeline.Value.Timeout.Value)
+	if err != nil || timeout <= 0 {
+		return 0
+	}
+	return timeout
+}
+
+// Deadline of a worker Job: the pipeline timeout, or the default deadline of the pool
+func jobDeadlineSeconds(config hpav1.WorkerJobConfig, task taskMessage) int64 {
+	if timeout := task.timeout(); timeout > 0 {
+		return int64(math.Ceil(timeout.Seconds()))
+	}
+	if config.DefaultDeadlineSeconds != nil {
+		return *config.DefaultDeadlineSeconds
+	}
+	return defaultDeadlineSeconds
+}
+
+// Create a worker Job to execute one pipeline, its pod is retried up to the backoff limit
+func (r *PoolScalerReconciler) createWorkerJob(poolScaler *hpav1.PoolScaler, index int32, messageBody []byte) *batchv1.Job {
+	config := poolScaler.Spec.WorkerPool.Job
+	task := parseTaskMessage(messageBody)
+
+	name := workerName(poolScaler, index)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   poolScaler.Namespace,
+			Labels:      workerLabels(poolScaler),
+			Annotations: map[string]string{executionIdAnnotation: task.Id},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            ptr.To(ptr.Deref(config.BackoffLimit, defaultBackoffLimit)),
+			TTLSecondsAfterFinished: ptr.To(ptr.Deref(config.TTLSecondsAfterFinished, defaultTTLSecondsAfterFinished)),
+			ActiveDeadlineSeconds:   ptr.To(jobDeadlineSeconds(config, task)),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: workerLabels(poolScaler)},
+				Spec:       r.workerPodSpec(poolScaler, name),
+			},
+		},
+	}
+
+	// Set owner reference
+	if err := ctrl.SetControllerReference(poolScaler, job, r.Scheme); err != nil {
+		logf.FromContext(context.Background()).Error(err, "Failed to set owner reference")
+	}
+
+	return job
+}
+
+// Terminal condition of a Job, nil while it is active
+func jobFinishedCondition(job *batchv1.Job) *batchv1.JobCondition {
+	for i, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) && condition.Status == corev1.ConditionTrue {
+			return &job.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// Lists the worker Jobs of a PoolScaler
+func (r *PoolScalerReconciler) listWorkerJobs(ctx context.Context, poolScaler *hpav1.PoolScaler) ([]batchv1.Job, error) {
+	jobs := &batchv1.JobList{}
+	err := r.List(ctx, jobs,
+		client.InNamespace(poolScaler.Namespace),
+		client.MatchingLabels{instanceLabel: poolScaler.Name},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return jobs.Items, nil
+}
+
+/*
+Marks the execution of every failed worker Job as failed in the database,
+when its pods died before the worker could report, e.g. out of retries or past the deadline.
+Failed jobs of the job tier are also published as job events, to unblock the worker waiting for them.
+Each Job is reported once.
+*/
+func (r *PoolScalerReconciler) reportFailedJobs(ctx context.Context, poolScaler *hpav1.PoolScaler, ch *amqp.Channel) error {
+	log := logf.FromContext(ctx)
+
+	jobs, err := r.listWorkerJobs(ctx, poolScaler)
+	if err != nil {
+		return err
+	}
+
+	var failed []*batchv1.Job
+	for i := range jobs {
+		job := &jobs[i]
+		condition := jobFinishedCondition(job)
+		if condition == nil || condition.Type != batchv1.JobFailed || job.Annotations[reportedAnnotation] != "" {
+			continue
+		}
+		failed = append(failed, job)
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	db, err := r.openDatabase(poolScaler)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, job := range failed {
+		if executionId := job.Annotations[executionIdAnnotation]; executionId != "" {
+			if poolRole(poolScaler) == hpav1.PoolScalerRoleJob {
+				event, err := failJobExecution(db, executionId)
+				if err != nil {
+					return err
+				}
+				if event != nil {
+					if err := publishJobEvent(ctx, ch, event); err != nil {
+						return err
+					}
+					log.Info("Reported job execution", "job", job.Name, "executionId", executionId, "status", event.Status, "reason", jobFinishedCondition(job).Reason)
+				}
+			} else {
+				updated, err := failPipelineExecution(db, executionId)
+				if err != nil {
+					return err
+				}
+				if updated {
+					log.Info("Marked pipeline execution failed", "job", job.Name, "executionId", executionId, "reason", jobFinishedCondition(job).Reason)
+				}
+			}
+		}
+
+		patch := client.MergeFrom(job.DeepCopy())
+		if job.Annotations == nil {
+			job.Annotations = make(map[string]string)
+		}
+		job.Annotations[reportedAnnotation] = "true"
+		if err := r.Patch(ctx, job, patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Publishes a job event to the queue of its pipeline execution, on the broker of the job queue.
+The queue is declared by the worker subscribing to it, the event is dropped when nobody waits for it.
+*/
+func publishJobEvent(ctx context.Context, ch *amqp.Channel, event *jobEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job event: %w", err)
+	}
+	err = ch.PublishWithContext(ctx,
+		"",
+		fmt.Sprintf("%s.pipeline.%d", jobEventsQueuePrefix, event.PipelineId),
+		false,
+		false,
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+			Timestamp:   time.Now(),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish job event: %w", err)
+	}
+	return nil
+}
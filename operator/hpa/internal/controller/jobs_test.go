@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+)
+
+var _ = Describe("Worker Jobs", func() {
+	task := func(timeout string) []byte {
+		return []byte(`{"id":"4f1c","message":{"pipeline":{"Pipeline":{"Value":{"Name":{"Value":"deploy"},"Timeout":{"Value":"` + timeout + `"}}}}}}`)
+	}
+
+	It("should take the deadline from the pipeline timeout", func() {
+		config := hpav1.WorkerJobConfig{DefaultDeadlineSeconds: ptr.To(int64(600))}
+		Expect(parseTaskMessage(task("90m")).Id).To(Equal("4f1c"))
+		Expect(jobDeadlineSeconds(config, parseTaskMessage(task("90m")))).To(Equal(int64(5400)))
+		Expect(jobDeadlineSeconds(config, parseTaskMessage(task("1.5s")))).To(Equal(int64(2)))
+
+		// Pipelines without a valid timeout get the default deadline of the pool
+		Expect(jobDeadlineSeconds(config, parseTaskMessage(task("soon")))).To(Equal(int64(600)))
+		Expect(jobDeadlineSeconds(config, parseTaskMessage([]byte(`{"id":"4f1c"}`)))).To(Equal(int64(600)))
+		Expect(jobDeadlineSeconds(hpav1.WorkerJobConfig{}, parseTaskMessage([]byte(`{`)))).To(Equal(int64(defaultDeadlineSeconds)))
+	})
+
+	It("should tell finished Jobs from active ones", func() {
+		job := &batchv1.Job{}
+		Expect(jobFinishedCondition(job)).To(BeNil())
+
+		job.Status.Conditions = []batchv1.JobCondition{
+			{Type: batchv1.JobSuspended, Status: corev1.ConditionTrue},
+			{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "DeadlineExceeded"},
+		}
+		Expect(jobFinishedCondition(job).Reason).To(Equal("DeadlineExceeded"))
+	})
+
+	It("should fail the unfinished pipeline execution of a dead worker", func() {
+		db, mock, err := sqlmock.New()
+		Expect(err).NotTo(HaveOccurred())
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE Pipelines SET status = \\?, end_time = \\? WHERE execution_id = \\? AND status NOT IN").
+			WithArgs(statusFailed, sqlmock.AnyArg(), "4f1c", statusSuccess, statusFailed, statusCanceled).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE Stages SET status = \\?, end_time = \\? WHERE pipeline_id = ").
+			WithArgs(statusFailed, sqlmock.AnyArg(), "4f1c", statusSuccess, statusFailed, statusCanceled).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("UPDATE Jobs SET status = \\?, end_time = \\? WHERE stage_id IN ").
+			WithArgs(statusFailed, sqlmock.AnyArg(), "4f1c", statusSuccess, statusFailed, statusCanceled).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+
+		updated, err := failPipelineExecution(db, "4f1c")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated).To(BeTrue())
+
+		// Finished executions are left as they are
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE Pipelines SET status").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		updated, err = failPipelineExecution(db, "4f1c")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated).To(BeFalse())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("should fail the unfinished job execution of a dead job worker", func() {
+		db, mock, err := sqlmock.New()
+		Expect(err).NotTo(HaveOccurred())
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT j.job_id, s.stage_id, s.pipeline_id FROM Jobs j JOIN Stages s").
+			WithArgs("job_4f1c").
+			WillReturnRows(sqlmock.NewRows([]string{"job_id", "stage_id", "pipeline_id"}).AddRow(7, 3, 2))
+		mock.ExpectExec("SELECT pipeline_id FROM Pipelines WHERE pipeline_id = \\? FOR UPDATE").WithArgs(2).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SELECT stage_id FROM Stages WHERE stage_id = \\? FOR UPDATE").WithArgs(3).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE Jobs SET status = \\?, end_time = \\? WHERE job_id = \\? AND status NOT IN").
+			WithArgs(statusFailed, sqlmock.AnyArg(), 7, statusSuccess, statusFailed, statusCanceled).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM Jobs WHERE stage_id = \\?").
+			WithArgs(3, statusSuccess, statusFailed, statusCanceled).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec("UPDATE Stages SET status = \\?, end_time = \\? WHERE stage_id = \\?").
+			WithArgs(statusFailed, sqlmock.AnyArg(), 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE Pipelines SET status = \\?, end_time = \\? WHERE pipeline_id = \\?").
+			WithArgs(statusFailed, sqlmock.AnyArg(), 2, statusSuccess, statusFailed, statusCanceled).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT status FROM Jobs WHERE job_id = \\?").WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(statusFailed))
+		mock.ExpectCommit()
+
+		event, err := failJobExecution(db, "job_4f1c")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*event).To(Equal(jobEvent{Id: "job_4f1c", JobId: 7, StageId: 3, PipelineId: 2, Status: statusFailed}))
+
+		// Jobs reported by their executor meanwhile keep their status, which is published again
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT j.job_id").
+			WillReturnRows(sqlmock.NewRows([]string{"job_id", "stage_id", "pipeline_id"}).AddRow(7, 3, 2))
+		mock.ExpectExec("SELECT pipeline_id").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SELECT stage_id").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE Jobs SET status").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT status FROM Jobs").
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(statusSuccess))
+		mock.ExpectCommit()
+
+		event, err = failJobExecution(db, "job_4f1c")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(event.Status).To(Equal(statusSuccess))
+
+		// Unknown jobs are not reported
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT j.job_id").WillReturnRows(sqlmock.NewRows([]string{"job_id", "stage_id", "pipeline_id"}))
+		mock.ExpectRollback()
+
+		event, err = failJobExecution(db, "job_4f1c")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(event).To(BeNil())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+})
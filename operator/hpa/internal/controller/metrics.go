@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	amqp "github.com/rabbitmq/amqp091-go"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+)
+
+const (
+	// Annotation of the workers with the time their message was published, for the start latency
+	enqueuedAtAnnotation = "hpa.cicd.operator/enqueued-at"
+	// Annotation of the worker pods whose start latency was already observed
+	startObservedAnnotation = "hpa.cicd.operator/start-observed"
+)
+
+// Metrics of the PoolScalers, served with the controller-runtime metrics on --metrics-bind-address
+var (
+	queueMessages = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "poolscaler_queue_messages",
+		Help: "Messages waiting in the input queue of a PoolScaler.",
+	}, []string{"namespace", "poolscaler"})
+
+	workerPodsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "poolscaler_worker_pods_created_total",
+		Help: "Workers created by a PoolScaler, worker Jobs in Job mode.",
+	}, []string{"namespace", "poolscaler"})
+
+	workerPodsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "poolscaler_worker_pods_failed_total",
+		Help: "Worker pods of a PoolScaler that finished in the Failed phase.",
+	}, []string{"namespace", "poolscaler"})
+
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "poolscaler_reconcile_errors_total",
+		Help: "Failed reconciliations of a PoolScaler by reason.",
+	}, []string{"namespace", "poolscaler", "reason"})
+
+	workerStartLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "poolscaler_worker_start_latency_seconds",
+		Help:    "Time from the publication of a message to the start of its worker pod.",
+		Buckets: prometheus.ExponentialBuckets(0.5, 2, 12),
+	}, []string{"namespace", "poolscaler"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(queueMessages, workerPodsCreated, workerPodsFailed, reconcileErrors, workerStartLatency)
+}
+
+// Label values of the metrics of a PoolScaler
+func poolScalerLabels(poolScaler *hpav1.PoolScaler) prometheus.Labels {
+	return prometheus.Labels{"namespace": poolScaler.Namespace, "poolscaler": poolScaler.Name}
+}
+
+// Removes the series of a deleted PoolScaler
+func deletePoolScalerMetrics(namespace, name string) {
+	labels := prometheus.Labels{"namespace": namespace, "poolscaler": name}
+	queueMessages.DeletePartialMatch(labels)
+	workerPodsCreated.DeletePartialMatch(labels)
+	workerPodsFailed.DeletePartialMatch(labels)
+	reconcileErrors.DeletePartialMatch(labels)
+	workerStartLatency.DeletePartialMatch(labels)
+}
+
+// Records the publication time of a message on its worker, on the pod template of a worker Job
+func setEnqueuedAt(worker client.Object, msg *amqp.Delivery) {
+	if msg.Timestamp.IsZero() {
+		return // Published without a timestamp, the latency is not observed
+	}
+	var object metav1.Object = worker
+	if job, ok := worker.(*batchv1.Job); ok {
+		object = &job.Spec.Template
+	}
+	annotations := object.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[enqueuedAtAnnotation] = msg.Timestamp.UTC().Format(time.RFC3339)
+	object.SetAnnotations(annotations)
+}
+
+// Time from the publication of the message of a worker pod to its start, false until it started
+func podStartLatency(pod *corev1.Pod) (time.Duration, bool) {
+	if pod.Status.StartTime == nil {
+		return 0, false
+	}
+	enqueuedAt, err := time.Parse(time.RFC3339, pod.Annotations[enqueuedAtAnnotation])
+	if err != nil {
+		return 0, false
+	}
+	return max(pod.Status.StartTime.Sub(enqueuedAt), 0), true
+}
+
+/*
+Observes the start latency of a worker pod once, the pod is marked before observing it.
+Every pod of a retried worker Job is observed, from the publication of the same message.
+*/
+func (r *PoolScalerReconciler) observeWorkerStart(ctx context.Context, poolScaler *hpav1.PoolScaler, pod *corev1.Pod) error {
+	if pod.Annotations[startObservedAnnotation] != "" {
+		return nil
+	}
+	latency, ok := podStartLatency(pod)
+	if !ok {
+		return nil
+	}
+
+	patch := client.MergeFrom(pod.DeepCopy())
+	pod.Annotations[startObservedAnnotation] = "true"
+	if err := r.Patch(ctx, pod, patch); err != nil {
+		return err
+	}
+	workerStartLatency.With(poolScalerLabels(poolScaler)).Observe(latency.Seconds())
+	return nil
+}
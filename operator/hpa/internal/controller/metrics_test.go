@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	amqp "github.com/rabbitmq/amqp091-go"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+)
+
+var _ = Describe("Metrics", func() {
+	enqueuedAt := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	It("should record the publication time of the message on the worker", func() {
+		pod := &corev1.Pod{}
+		setEnqueuedAt(pod, &amqp.Delivery{Timestamp: enqueuedAt})
+		Expect(pod.Annotations).To(HaveKeyWithValue(enqueuedAtAnnotation, "2025-04-01T12:00:00Z"))
+
+		job := &batchv1.Job{}
+		setEnqueuedAt(job, &amqp.Delivery{Timestamp: enqueuedAt})
+		Expect(job.Annotations).To(BeEmpty())
+		Expect(job.Spec.Template.Annotations).To(HaveKeyWithValue(enqueuedAtAnnotation, "2025-04-01T12:00:00Z"))
+
+		unstamped := &corev1.Pod{}
+		setEnqueuedAt(unstamped, &amqp.Delivery{})
+		Expect(unstamped.Annotations).To(BeEmpty())
+	})
+
+	It("should measure the start latency of started pods only", func() {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{enqueuedAtAnnotation: "2025-04-01T12:00:00Z"},
+		}}
+		_, ok := podStartLatency(pod)
+		Expect(ok).To(BeFalse())
+
+		pod.Status.StartTime = &metav1.Time{Time: enqueuedAt.Add(42 * time.Second)}
+		latency, ok := podStartLatency(pod)
+		Expect(ok).To(BeTrue())
+		Expect(latency).To(Equal(42 * time.Second))
+
+		delete(pod.Annotations, enqueuedAtAnnotation)
+		_, ok = podStartLatency(pod)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should remove the series of a deleted PoolScaler", func() {
+		deleted := &hpav1.PoolScaler{ObjectMeta: metav1.ObjectMeta{Name: "deleted-pool", Namespace: "metrics"}}
+		kept := &hpav1.PoolScaler{ObjectMeta: metav1.ObjectMeta{Name: "kept-pool", Namespace: "metrics"}}
+		for _, poolScaler := range []*hpav1.PoolScaler{deleted, kept} {
+			queueMessages.With(poolScalerLabels(poolScaler)).Set(3)
+			labels := poolScalerLabels(poolScaler)
+			labels["reason"] = "QueueCheckFailed"
+			reconcileErrors.With(labels).Inc()
+		}
+		before := testutil.CollectAndCount(reconcileErrors)
+
+		deletePoolScalerMetrics("metrics", "deleted-pool")
+		Expect(testutil.CollectAndCount(reconcileErrors)).To(Equal(before - 1))
+		Expect(testutil.ToFloat64(queueMessages.With(poolScalerLabels(kept)))).To(Equal(3.0))
+		deletePoolScalerMetrics("metrics", "kept-pool")
+	})
+})
@@ -18,6 +18,7 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"reflect"
@@ -27,6 +28,7 @@ import (
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/redis/go-redis/v9"
 
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -36,9 +38,15 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	hpav1 "cicd.operator/hpa/api/v1"
 )
@@ -65,12 +73,24 @@ type PoolScalerReconciler struct {
 	client.Client
 	Scheme    *runtime.Scheme
 	Clientset *kubernetes.Clientset
+	// Events of the PoolScalers, shown by kubectl describe
+	Recorder record.EventRecorder
+	// PoolScalers reconciled in parallel, each one manages its own queue and workers
+	MaxConcurrentReconciles int
+
+	// AMQP connection of every PoolScaler, and the poller of its input queue
+	connections  rabbitMQConnections
+	queuePollers queuePollers
 }
 
 // +kubebuilder:rbac:groups=hpa.cicd.operator,resources=poolscalers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=hpa.cicd.operator,resources=poolscalers/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=hpa.cicd.operator,resources=poolscalers/finalizers,verbs=update
 // +kubebuilder:rbac:groups=hpa.cicd.operator,resources=services;endpoints,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;create;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;patch;delete
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -82,7 +102,7 @@ type PoolScalerReconciler struct {
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.20.4/pkg/reconcile
 func (r *PoolScalerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = logf.FromContext(ctx)
+	log := logf.FromContext(ctx)
 
 	// Fetch the PoolScaler instance
 	// The purpose is check if the Custom Resource for the Kind PoolScaler
@@ -90,97 +110,188 @@ func (r *PoolScalerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	poolScaler := &hpav1.PoolScaler{}
 	if err := r.Get(ctx, req.NamespacedName, poolScaler); err != nil {
 		if errors.IsNotFound(err) {
+			r.queuePollers.stop(req.NamespacedName)
+			r.connections.close(req.NamespacedName)
+			deletePoolScalerMetrics(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
 	}
+	removeLegacyConditions(&poolScaler.Status)
+
+	// Count and clean up finished workers, even when the queue is unavailable
+	cleanupAfter, err := r.collectFinishedWorkers(ctx, poolScaler)
+	if err != nil {
+		return r.handleError(ctx, poolScaler, hpav1.ConditionScaling, "CleanupFailed", err)
+	}
 
 	// Get RabbitMQ message count
 	password, err := r.getSecretValue(poolScaler.Namespace, poolScaler.Spec.InputQueue.PasswordSecretRef)
 	if err != nil {
-		return r.handleError(ctx, poolScaler, "FailedToGetPassword", err)
+		return r.handleError(ctx, poolScaler, hpav1.ConditionSecretsResolved, "FailedToGetPassword", err)
+	}
+	setCondition(poolScaler, hpav1.ConditionSecretsResolved, metav1.ConditionTrue, "SecretsFound",
+		fmt.Sprintf("Password of the input queue read from Secret %s", poolScaler.Spec.InputQueue.PasswordSecretRef.Name))
+
+	// One channel per reconciliation, on the cached connection of the PoolScaler
+	url, err := r.inputQueueURL(poolScaler, password)
+	if err != nil {
+		return r.handleError(ctx, poolScaler, hpav1.ConditionQueueReachable, "RabbitMQConnectionFailed", err)
 	}
+	rmq, err := r.connectRabbitMQ(poolScaler, url)
+	if err != nil {
+		return r.handleError(ctx, poolScaler, hpav1.ConditionQueueReachable, "RabbitMQConnectionFailed", err)
+	}
+	defer r.closeRabbitMQ(rmq)
 
-	messageCount, err := r.getQueueMessageCount(poolScaler, password)
+	// Fail the executions of workers that died before they could report
+	if err := r.reportFailedJobs(ctx, poolScaler, rmq.Channel); err != nil {
+		log.Error(err, "Failed to report failed worker jobs")
+	}
+
+	messageCount, err := r.getQueueMessageCount(rmq, poolScaler)
+	if isArgumentsMismatch(err) {
+		return r.handleError(ctx, poolScaler, hpav1.ConditionQueueReachable, "QueueArgumentsMismatch", err)
+	}
 	if err != nil {
-		return r.handleError(ctx, poolScaler, "QueueCheckFailed", err)
+		return r.handleError(ctx, poolScaler, hpav1.ConditionQueueReachable, "QueueCheckFailed", err)
 	}
+	setCondition(poolScaler, hpav1.ConditionQueueReachable, metav1.ConditionTrue, "QueueChecked",
+		fmt.Sprintf("%d messages waiting in queue %s", messageCount, poolScaler.Spec.InputQueue.QueueName))
 
 	// Update status with current queue size
 	poolScaler.Status.QueueMessages = messageCount
+	queueMessages.With(poolScalerLabels(poolScaler)).Set(float64(messageCount))
 	if err := r.Status().Update(ctx, poolScaler); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	return r.reconcileDeploymentScaling(ctx, poolScaler, messageCount, password)
+	// New messages trigger the next reconciliation
+	r.queuePollers.ensure(ctx, &r.connections, poolScaler, url)
+
+	result, err := r.reconcileDeploymentScaling(ctx, poolScaler, rmq, messageCount)
+	if err == nil && cleanupAfter > 0 && cleanupAfter < result.RequeueAfter {
+		// Come back when the next finished worker expires
+		result.RequeueAfter = cleanupAfter
+	}
+	return result, err
 }
 
-func (r *PoolScalerReconciler) reconcileDeploymentScaling(ctx context.Context, poolScaler *hpav1.PoolScaler, messageCount int32, password string) (ctrl.Result, error) {
+/*
+Start worker pods for the messages waiting in the queue.
+Active workers are capped at MaxReplicas, and the pool scales up at most once per cooldown period.
+Messages beyond the capacity of the pool stay in the queue until the next reconciliation,
+triggered by a finished worker, a new message or the resync period.
+*/
+func (r *PoolScalerReconciler) reconcileDeploymentScaling(ctx context.Context, poolScaler *hpav1.PoolScaler, rmq *RabbitMQConnection, messageCount int32) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
+	requeueAfter := resyncPeriod
 
-	// 1. Establish RabbitMQ connection
-	rmqConn, err := r.connectRabbitMQ(poolScaler, password)
+	// 1. Count the workers still pending or running
+	active, err := r.countActiveWorkers(ctx, poolScaler)
 	if err != nil {
-		return r.handleError(ctx, poolScaler, "RabbitMQConnectionFailed", err)
-	}
-	defer r.closeRabbitMQ(rmqConn)
-
-	// 2. Establish Redis connection
-	// redisPassword, err := r.getRedisPassword(ctx, poolScaler)
-	// if err != nil {
-	// 	return r.handleError(ctx, poolScaler, "GetRedisPasswordFailed", err)
-	// }
-
-	// redisConn, err := r.connectRedis(poolScaler, redisPassword)
-	// if err != nil {
-	// 	return r.handleError(ctx, poolScaler, "RedisConnectionFailed", err)
-	// }
-	// defer r.closeRedis(redisConn)
-
-	// 3. Process new messages polling from the queue
-	for i := int32(0); i < messageCount; i++ {
-		// Get message from queue
-		msg, err := r.consumeMessage(rmqConn, poolScaler.Spec.InputQueue.QueueName)
-		if err != nil {
-			log.Error(err, "Failed to get message")
-			continue
-		}
-		if msg == nil {
-			break // No more messages
+		return r.handleError(ctx, poolScaler, hpav1.ConditionScaling, "ListWorkersFailed", err)
+	}
+
+	// 2. Workers to start, none during the cooldown period
+	toStart := workersToStart(messageCount, active, poolScaler.Spec)
+	scalingReason, scalingMessage := "Stable", fmt.Sprintf("%d workers active", active)
+	if toStart == 0 && messageCount > 0 && active >= poolScaler.Spec.WorkerPool.MaxReplicas {
+		scalingReason, scalingMessage = "MaxReplicasReached", fmt.Sprintf("%d workers active, messages wait for a worker to finish", active)
+	}
+	if toStart > 0 {
+		if wait := cooldownRemaining(poolScaler.Status.LastScaleTime, poolScaler.Spec, time.Now()); wait > 0 {
+			log.Info("Scale up delayed by the cooldown period", "workers", toStart, "remaining", wait)
+			scalingReason, scalingMessage = "CooldownPeriod", fmt.Sprintf("Scale up of %d workers delayed for %s", toStart, wait.Round(time.Second))
+			toStart = 0
+			requeueAfter = min(requeueAfter, wait)
 		}
+	}
 
-		// Process one message
-		if err := r.processSingleMessage(ctx, poolScaler, rmqConn.Channel, msg, i); err != nil {
-			log.Error(err, "Failed to process message")
-			continue
+	var started int32
+	var lastErr error
+	if toStart > 0 {
+		// 3. Start one worker pod per message, up to the capacity of the pool
+		for i := int32(0); i < toStart; i++ {
+			// Get message from queue
+			msg, err := r.consumeMessage(rmq, poolScaler.Spec.InputQueue.QueueName)
+			if err != nil {
+				log.Error(err, "Failed to get message")
+				continue
+			}
+			if msg == nil {
+				break // No more messages
+			}
+
+			// Process one message
+			if err := r.processSingleMessage(ctx, poolScaler, rmq.Channel, msg, i); err != nil {
+				log.Error(err, "Failed to process message")
+				lastErr = err
+				continue
+			}
+			started++
 		}
 	}
 
-	return ctrl.Result{
-		RequeueAfter: time.Duration(poolScaler.Spec.Scaling.PollingIntervalSeconds) * time.Second,
-	}, nil
+	// 4. Record the size of the pool
+	poolScaler.Status.CurrentReplicas = active + started
+	if started > 0 {
+		now := metav1.Now()
+		poolScaler.Status.LastScaleTime = &now
+		log.Info("Scaled up worker pool", "started", started, "replicas", poolScaler.Status.CurrentReplicas)
+		scalingReason, scalingMessage = "ScaledUp", fmt.Sprintf("Started %d workers, %d workers active", started, poolScaler.Status.CurrentReplicas)
+		r.Recorder.Eventf(poolScaler, corev1.EventTypeNormal, "ScaledUp", "Started %d workers for %d messages, %d workers active",
+			started, messageCount, poolScaler.Status.CurrentReplicas)
+	}
+	if started == 0 && lastErr != nil {
+		// No worker could be started, the messages were requeued or dead-lettered
+		setCondition(poolScaler, hpav1.ConditionScaling, metav1.ConditionFalse, "WorkerCreationFailed", lastErr.Error())
+	} else {
+		setCondition(poolScaler, hpav1.ConditionScaling, metav1.ConditionTrue, scalingReason, scalingMessage)
+	}
+	if err := r.Status().Update(ctx, poolScaler); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
+// Worker pods and Jobs trigger a reconciliation when they change, e.g. finish, and queue pollers when messages arrive.
 func (r *PoolScalerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.queuePollers.events = make(chan event.GenericEvent, 64)
+
+	// Stop the queue pollers and close the connections with the manager
+	err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		r.queuePollers.stopAll()
+		r.connections.closeAll()
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&hpav1.PoolScaler{}).
+		Owns(&corev1.Pod{}).
+		Owns(&batchv1.Job{}).
+		WatchesRawSource(source.Channel(r.queuePollers.events, &handler.EnqueueRequestForObject{})).
+		WithOptions(controller.Options{MaxConcurrentReconciles: max(r.MaxConcurrentReconciles, 1)}).
 		Named("poolscaler").
 		Complete(r)
 }
 
-func (r *PoolScalerReconciler) handleError(ctx context.Context, poolScaler *hpav1.PoolScaler, reason string, err error) (ctrl.Result, error) {
+// Sets the failed condition of a PoolScaler, moving it to the Error phase, and retries later
+func (r *PoolScalerReconciler) handleError(ctx context.Context, poolScaler *hpav1.PoolScaler, conditionType, reason string, err error) (ctrl.Result, error) {
 	log := logf.FromContext(ctx)
 
-	poolScaler.Status.Phase = "Error"
-	condition := metav1.Condition{
-		Type:               "Ready",
-		Status:             metav1.ConditionFalse,
-		Reason:             reason,
-		Message:            err.Error(),
-		LastTransitionTime: metav1.Now(),
-	}
-	poolScaler.Status.Conditions = append(poolScaler.Status.Conditions, condition)
+	labels := poolScalerLabels(poolScaler)
+	labels["reason"] = reason
+	reconcileErrors.With(labels).Inc()
+
+	r.Recorder.Event(poolScaler, corev1.EventTypeWarning, reason, err.Error())
+	setCondition(poolScaler, conditionType, metav1.ConditionFalse, reason, err.Error())
 
 	if updateErr := r.Status().Update(ctx, poolScaler); updateErr != nil {
 		log.Error(updateErr, "Failed to update status")
@@ -247,25 +358,26 @@ func (r *PoolScalerReconciler) getSecretValue(namespace string, secretRef hpav1.
 }
 
 // Gets the current message count in the queue
-func (r *PoolScalerReconciler) getQueueMessageCount(poolScaler *hpav1.PoolScaler, password string) (int32, error) {
-	// Establish connection
-	rmq, err := r.connectRabbitMQ(poolScaler, password)
-	if err != nil {
+func (r *PoolScalerReconciler) getQueueMessageCount(rmq *RabbitMQConnection, poolScaler *hpav1.PoolScaler) (int32, error) {
+	if err := declareDeadLetterQueue(rmq.Channel, poolScaler.Spec.InputQueue.QueueName); err != nil {
 		return 0, err
 	}
-	defer r.closeRabbitMQ(rmq)
 
 	// QueueDeclare declares a queue to hold messages and deliver to consumers.
 	// Declaring creates a queue if it doesn't already exist,
 	// or ensures that an existing queue matches the same parameters.
 	queue, err := rmq.Channel.QueueDeclare(
 		poolScaler.Spec.InputQueue.QueueName,
-		true,  // durable
-		false, // autoDelete
-		false, // exclusive
-		false, // noWait
-		nil,   // arguments
+		true,        // durable
+		false,       // autoDelete
+		false,       // exclusive
+		false,       // noWait
+		queueArgs(), // arguments
 	)
+	if isArgumentsMismatch(err) {
+		return 0, fmt.Errorf("queue %s was declared with other arguments by a previous release, re-create it with the queue migration: %w",
+			poolScaler.Spec.InputQueue.QueueName, err)
+	}
 	if err != nil {
 		return 0, fmt.Errorf("failed to declare queue: %w", err)
 	}
@@ -274,28 +386,30 @@ func (r *PoolScalerReconciler) getQueueMessageCount(poolScaler *hpav1.PoolScaler
 	return int32(queue.Messages), nil
 }
 
-// Establishes a new connection to RabbitMQ
-func (r *PoolScalerReconciler) connectRabbitMQ(poolScaler *hpav1.PoolScaler, password string) (*RabbitMQConnection, error) {
+// AMQP URL of the input queue of a PoolScaler
+func (r *PoolScalerReconciler) inputQueueURL(poolScaler *hpav1.PoolScaler, password string) (string, error) {
 	host, err := r.getServiceEndpoint(context.Background(), poolScaler.Spec.InputQueue.Host, poolScaler)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	connString := fmt.Sprintf("amqp://%s:%s@%s:%d",
+	return fmt.Sprintf("amqp://%s:%s@%s:%d",
 		poolScaler.Spec.InputQueue.Username,
 		password,
 		host,
 		poolScaler.Spec.InputQueue.Port,
-	)
+	), nil
+}
 
-	conn, err := amqp.Dial(connString)
+// Opens a channel on the cached connection of a PoolScaler, dialed when missing or closed
+func (r *PoolScalerReconciler) connectRabbitMQ(poolScaler *hpav1.PoolScaler, url string) (*RabbitMQConnection, error) {
+	conn, err := r.connections.get(types.NamespacedName{Name: poolScaler.Name, Namespace: poolScaler.Namespace}, url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+		return nil, err
 	}
 
 	ch, err := conn.Channel()
 	if err != nil {
-		conn.Close() // Close connection if channel creation fails
 		return nil, fmt.Errorf("failed to open channel: %w", err)
 	}
 
@@ -305,18 +419,12 @@ func (r *PoolScalerReconciler) connectRabbitMQ(poolScaler *hpav1.PoolScaler, pas
 	}, nil
 }
 
-// Safely closes the connection and channel
+// Closes the channel of a reconciliation, the connection stays cached for the next one
 func (r *PoolScalerReconciler) closeRabbitMQ(rmq *RabbitMQConnection) {
-	if rmq == nil {
+	if rmq == nil || rmq.Channel == nil {
 		return
 	}
-
-	if rmq.Channel != nil {
-		rmq.Channel.Close()
-	}
-	if rmq.Conn != nil {
-		rmq.Conn.Close()
-	}
+	rmq.Channel.Close()
 }
 
 // Consumes a single message from the queue
@@ -334,31 +442,103 @@ func (r *PoolScalerReconciler) consumeMessage(rmq *RabbitMQConnection, queueName
 	return &msg, nil
 }
 
-// Handles creation of pod for a single message
+// Handles creation of a worker for a single message
 func (r *PoolScalerReconciler) processSingleMessage(ctx context.Context, poolScaler *hpav1.PoolScaler, ch *amqp.Channel, msg *amqp.Delivery, index int32) error {
 	log := logf.FromContext(ctx)
+	queueName := poolScaler.Spec.InputQueue.QueueName
+
+	// A malformed message would fail every worker, dead-letter it right away
+	if !json.Valid(msg.Body) {
+		err := fmt.Errorf("invalid message: not a JSON document")
+		r.Recorder.Event(poolScaler, corev1.EventTypeWarning, "InvalidMessage", "Dead-lettered a message that is not a JSON document")
+		if dlErr := retryOrDeadLetter(ch, queueName, msg, err, true); dlErr != nil {
+			log.Error(dlErr, "Failed to dead-letter invalid message")
+		}
+		return err
+	}
+
+	// Create worker
+	worker := r.createWorker(poolScaler, index, msg.Body)
+	setEnqueuedAt(worker, msg)
+	if err := r.Create(ctx, worker); err != nil {
+		// Retry on the next reconciliation, dead-letter once out of retries
+		err = fmt.Errorf("failed to create worker: %w", err)
+		r.Recorder.Event(poolScaler, corev1.EventTypeWarning, "WorkerCreationFailed", err.Error())
+		if retryErr := retryOrDeadLetter(ch, queueName, msg, err, false); retryErr != nil {
+			log.Error(retryErr, "Failed to requeue message after worker creation failure")
+		}
+		return err
+	}
 
-	// Create pod
-	pod := r.createWorkerPod(poolScaler, index, msg.Body)
-	if err := r.Create(ctx, pod); err != nil {
-		// Requeue message on failure
-		if nackErr := ch.Nack(msg.DeliveryTag, false, true); nackErr != nil {
-			log.Error(nackErr, "Failed to requeue message after pod creation failure")
+	// Hand the task to the worker, a worker without its task is deleted and the message retried
+	if err := r.createTaskSecret(ctx, worker, msg.Body); err != nil {
+		err = fmt.Errorf("failed to create task secret: %w", err)
+		r.Recorder.Event(poolScaler, corev1.EventTypeWarning, "WorkerCreationFailed", err.Error())
+		if deleteErr := r.Delete(ctx, worker, client.PropagationPolicy("Background")); deleteErr != nil && !errors.IsNotFound(deleteErr) {
+			log.Error(deleteErr, "Failed to delete worker without task", "worker", worker.GetName())
 		}
-		return fmt.Errorf("failed to create pod: %w", err)
+		if retryErr := retryOrDeadLetter(ch, queueName, msg, err, false); retryErr != nil {
+			log.Error(retryErr, "Failed to requeue message after task secret creation failure")
+		}
+		return err
 	}
 
+	workerPodsCreated.With(poolScalerLabels(poolScaler)).Inc()
+
 	// Acknowledge message
 	if err := ch.Ack(msg.DeliveryTag, false); err != nil {
 		return fmt.Errorf("failed to ack message: %w", err)
 	}
 
-	log.Info("Successfully processed message", "pod", pod.Name, "messageLength", len(msg.Body))
+	log.Info("Successfully processed message", "worker", worker.GetName(), "messageLength", len(msg.Body))
 	return nil
 }
 
+// Create a worker to execute one pipeline, a Job in Job mode and a bare Pod otherwise
+func (r *PoolScalerReconciler) createWorker(poolScaler *hpav1.PoolScaler, index int32, messageBody []byte) client.Object {
+	if poolScaler.Spec.WorkerPool.Mode == hpav1.WorkerPoolModeJob {
+		return r.createWorkerJob(poolScaler, index, messageBody)
+	}
+	return r.createWorkerPod(poolScaler, index)
+}
+
+// Name of a new worker, unique within the PoolScaler
+func workerName(poolScaler *hpav1.PoolScaler, index int32) string {
+	return fmt.Sprintf("%s-worker-%d-%d", poolScaler.Name, time.Now().Unix(), index)
+}
+
+// Labels of the workers of a PoolScaler
+func workerLabels(poolScaler *hpav1.PoolScaler) map[string]string {
+	return map[string]string{
+		"app":                "poolscaler-worker",
+		instanceLabel:        poolScaler.Name,
+		roleLabel:            poolRole(poolScaler),
+		"pod-restart-policy": "never", // never restart
+	}
+}
+
 // Create a worker pod to execute one pipeline
-func (r *PoolScalerReconciler) createWorkerPod(poolScaler *hpav1.PoolScaler, index int32, messageBody []byte) *corev1.Pod {
+func (r *PoolScalerReconciler) createWorkerPod(poolScaler *hpav1.PoolScaler, index int32) *corev1.Pod {
+	name := workerName(poolScaler, index)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: poolScaler.Namespace,
+			Labels:    workerLabels(poolScaler),
+		},
+		Spec: r.workerPodSpec(poolScaler, name),
+	}
+
+	// Set owner reference
+	if err := ctrl.SetControllerReference(poolScaler, pod, r.Scheme); err != nil {
+		logf.FromContext(context.Background()).Error(err, "Failed to set owner reference")
+	}
+
+	return pod
+}
+
+// Pod spec of a worker executing one pipeline, its task is mounted from the task Secret of the worker
+func (r *PoolScalerReconciler) workerPodSpec(poolScaler *hpav1.PoolScaler, workerName string) corev1.PodSpec {
 	// Get service endpoints
 	inputQueueHost, err := r.getServiceEndpoint(context.Background(), poolScaler.Spec.InputQueue.Host, poolScaler)
 	if err != nil {
@@ -369,23 +549,14 @@ func (r *PoolScalerReconciler) createWorkerPod(poolScaler *hpav1.PoolScaler, ind
 		logf.FromContext(context.Background()).Error(err, "Failed to get MinIO Endpoint")
 	}
 
-	podName := fmt.Sprintf("%s-worker-%d-%d", poolScaler.Name, time.Now().Unix(), index)
-
-	// Get secrets
-	inputQueuePassword, _ := r.getSecretValue(poolScaler.Namespace, poolScaler.Spec.InputQueue.PasswordSecretRef)
-	dbPassword, _ := r.getSecretValue(poolScaler.Namespace, poolScaler.Spec.Database.PasswordSecretRef)
-	storageAccessKey, _ := r.getSecretValue(poolScaler.Namespace, poolScaler.Spec.Storage.AccessKeyRef)
-	storageSecretKey, _ := r.getSecretValue(poolScaler.Namespace, poolScaler.Spec.Storage.SecretKeyRef)
-	cachePassword, _ := r.getSecretValue(poolScaler.Namespace, poolScaler.Spec.Cache.PasswordSecretRef)
-
-	// Worker environment variables
+	// Worker environment variables, secrets are read from their Secret by the kubelet
 	envVars := []corev1.EnvVar{
-		// InputQueue
+		// InputQueue, the URL expands the password variable defined before it
+		secretEnvVar("RABBITMQ_PASSWORD", poolScaler.Spec.InputQueue.PasswordSecretRef),
 		{
 			Name: "RABBITMQ_URL",
-			Value: fmt.Sprintf("amqp://%s:%s@%s:%d",
+			Value: fmt.Sprintf("amqp://%s:$(RABBITMQ_PASSWORD)@%s:%d",
 				poolScaler.Spec.InputQueue.Username,
-				inputQueuePassword,
 				inputQueueHost,
 				poolScaler.Spec.InputQueue.Port,
 			),
@@ -397,37 +568,99 @@ func (r *PoolScalerReconciler) createWorkerPod(poolScaler *hpav1.PoolScaler, ind
 		{Name: "DB_USER", Value: poolScaler.Spec.Database.Username},
 		{Name: "DB_NAME", Value: poolScaler.Spec.Database.Name},
 		{Name: "DB_SSL_MODE", Value: poolScaler.Spec.Database.SSLMode},
-		{Name: "DB_PASSWORD", Value: dbPassword},
+		secretEnvVar("DB_PASSWORD", poolScaler.Spec.Database.PasswordSecretRef),
 		{Name: "DB_SSL_CA", Value: "/etc/ssl/certs/ca.pem"},
 		// Storage
 		{Name: "MINIO_ENDPOINT", Value: storageHost},
-		{Name: "MINIO_ACCESS_KEY", Value: storageAccessKey},
-		{Name: "MINIO_SECRET_KEY", Value: storageSecretKey},
+		secretEnvVar("MINIO_ACCESS_KEY", poolScaler.Spec.Storage.AccessKeyRef),
+		secretEnvVar("MINIO_SECRET_KEY", poolScaler.Spec.Storage.SecretKeyRef),
 		{Name: "DEFAULT_BUCKET", Value: poolScaler.Spec.Storage.DefaultBucket},
 		// Cache
 		{Name: "REDIS_HOST", Value: poolScaler.Spec.Cache.Host},
 		{Name: "REDIS_PORT", Value: fmt.Sprintf("%d", poolScaler.Spec.Cache.Port)},
 		{Name: "REDIS_USERNAME", Value: poolScaler.Spec.Cache.Username},
-		{Name: "REDIS_PASSWORD", Value: cachePassword},
+		secretEnvVar("REDIS_PASSWORD", poolScaler.Spec.Cache.PasswordSecretRef),
+	}
+
+	// OutputQueue, the pipeline workers publish their jobs to the job tier
+	if poolRole(poolScaler) == hpav1.PoolScalerRolePipeline && !reflect.DeepEqual(poolScaler.Spec.OutputQueue, hpav1.RabbitMQConfig{}) {
+		envVars = append(envVars,
+			secretEnvVar("JOB_QUEUE_PASSWORD", poolScaler.Spec.OutputQueue.PasswordSecretRef),
+			corev1.EnvVar{
+				Name: "JOB_QUEUE_URL",
+				Value: fmt.Sprintf("amqp://%s:$(JOB_QUEUE_PASSWORD)@%s:%d",
+					poolScaler.Spec.OutputQueue.Username,
+					poolScaler.Spec.OutputQueue.Host,
+					poolScaler.Spec.OutputQueue.Port,
+				),
+			},
+			corev1.EnvVar{Name: "JOB_QUEUE_NAME", Value: poolScaler.Spec.OutputQueue.QueueName},
+		)
+	}
+
+	// Task volume, the task is read from a file rather than passed as an argument
+	taskVolume, taskVolumeMount := taskVolume(workerName)
+	args := []string{"--input-file", taskMountPath + "/" + taskFileName}
+
+	// Task, SSL and runtime volumes
+	volumes := []corev1.Volume{taskVolume}
+	volumeMounts := []corev1.VolumeMount{taskVolumeMount}
+	if caRef := poolScaler.Spec.Database.SSLCASecretRef; caRef != nil {
+		volumes = append(volumes, corev1.Volume{
+			Name: "ca-cert",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: caRef.Name,
+					Items:      []corev1.KeyToPath{{Key: caRef.Key, Path: "ca.pem"}},
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "ca-cert",
+			MountPath: "/etc/ssl/certs/ca.pem",
+			SubPath:   "ca.pem",
+		})
 	}
 
-	// OutputQueue
-	if !reflect.DeepEqual(poolScaler.Spec.OutputQueue, hpav1.RabbitMQConfig{}) {
-		outputQueuePassword, _ := r.getSecretValue(poolScaler.Namespace, poolScaler.Spec.OutputQueue.PasswordSecretRef)
+	// Container runtime of the job tier, pipeline workers only publish jobs and never get the host socket
+	if poolRole(poolScaler) == hpav1.PoolScalerRoleJob {
+		runtime := workerRuntime(poolScaler.Spec.WorkerPool)
+		envVars = append(envVars, corev1.EnvVar{Name: "CONTAINER_RUNTIME", Value: runtime})
+		if runtime == hpav1.WorkerRuntimeDocker {
+			dockerVolume, dockerVolumeMount := dockerSocketVolume()
+			volumes = append(volumes, dockerVolume)
+			volumeMounts = append(volumeMounts, dockerVolumeMount)
+		} else {
+			// Job pods are created next to the worker, with the service account of the pool
+			envVars = append(envVars, corev1.EnvVar{
+				Name:      "POD_NAMESPACE",
+				ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"}},
+			})
+		}
+	}
 
-		envVars = append(envVars, corev1.EnvVar{
-			Name: "JOB_QUEUE_URL",
-			Value: fmt.Sprintf("amqp://%s:%s@%s:%d",
-				poolScaler.Spec.OutputQueue.Username,
-				outputQueuePassword,
-				poolScaler.Spec.OutputQueue.Host,
-				poolScaler.Spec.OutputQueue.Port,
-			),
-		})
-		envVars = append(envVars, corev1.EnvVar{Name: "JOB_QUEUE_NAME", Value: poolScaler.Spec.OutputQueue.QueueName})
+	return corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyNever,
+		Containers: []corev1.Container{
+			{
+				Name:         "worker",
+				Image:        fmt.Sprintf("%s:%s", poolScaler.Spec.WorkerPool.WorkerImage, poolScaler.Spec.WorkerPool.WorkerImageTag),
+				Args:         args,
+				Env:          envVars,
+				VolumeMounts: volumeMounts,
+				Resources:    workerResources(poolScaler.Spec.WorkerPool),
+			},
+		},
+		Volumes:                       volumes,
+		TerminationGracePeriodSeconds: ptr.To(int64(30)),
+		NodeSelector:                  poolScaler.Spec.WorkerPool.NodeSelector,
+		Tolerations:                   poolScaler.Spec.WorkerPool.Tolerations,
+		ServiceAccountName:            poolScaler.Spec.WorkerPool.ServiceAccountName,
 	}
+}
 
-	// Docker Volume
+// Docker socket of the node, mounted in the workers running job containers with the docker runtime
+func dockerSocketVolume() (corev1.Volume, corev1.VolumeMount) {
 	var hostPathType corev1.HostPathType = corev1.HostPathSocket
 	dockerVolume := corev1.Volume{
 		Name: "docker-socket",
@@ -442,72 +675,38 @@ func (r *PoolScalerReconciler) createWorkerPod(poolScaler *hpav1.PoolScaler, ind
 		Name:      "docker-socket",
 		MountPath: "/var/run/docker.sock", // Mount Docker socket inside container
 	}
+	return dockerVolume, dockerVolumeMount
+}
 
-	// SSL Volume
-	sslVolume := corev1.Volume{
-		Name: "ca-cert",
-		VolumeSource: corev1.VolumeSource{
-			Secret: &corev1.SecretVolumeSource{
-				SecretName: poolScaler.Spec.Database.SSLCASecretRef.Name,
-				Items: []corev1.KeyToPath{
-					{
-						Key:  poolScaler.Spec.Database.SSLCASecretRef.Key,
-						Path: "ca.pem",
-					},
-				},
-			},
-		},
+// Container runtime of the workers, docker for resources created before runtimes existed
+func workerRuntime(workerPool hpav1.WorkerPoolConfig) string {
+	if workerPool.Runtime == "" {
+		return hpav1.WorkerRuntimeDocker
 	}
-	sslVolumeMount := corev1.VolumeMount{
-		Name:      "ca-cert",
-		MountPath: "/etc/ssl/certs/ca.pem",
-		SubPath:   "ca.pem",
-	}
-
-	// Arguments
-	args := []string{"--input", string(messageBody)}
+	return workerPool.Runtime
+}
 
-	// Create pod with SSL and Docker volumes
-	pod := &corev1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      podName,
-			Namespace: poolScaler.Namespace,
-			Labels: map[string]string{
-				"app":                 "poolscaler-worker",
-				"poolscaler-instance": poolScaler.Name,
-				"pod-restart-policy":  "never", // never restart
-			},
+// Resources of the worker container, the defaults when the pool sets none
+func workerResources(workerPool hpav1.WorkerPoolConfig) corev1.ResourceRequirements {
+	if len(workerPool.Resources.Requests) > 0 || len(workerPool.Resources.Limits) > 0 {
+		return *workerPool.Resources.DeepCopy()
+	}
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),  // 0.5 CPU
+			corev1.ResourceMemory: resource.MustParse("256Mi"), // 256MB memory
 		},
-		Spec: corev1.PodSpec{
-			RestartPolicy: corev1.RestartPolicyNever,
-			Containers: []corev1.Container{
-				{
-					Name:         "worker",
-					Image:        fmt.Sprintf("%s:%s", poolScaler.Spec.WorkerPool.WorkerImage, poolScaler.Spec.WorkerPool.WorkerImageTag),
-					Args:         args,
-					Env:          envVars,
-					VolumeMounts: []corev1.VolumeMount{dockerVolumeMount, sslVolumeMount},
-					Resources: corev1.ResourceRequirements{
-						Requests: corev1.ResourceList{
-							corev1.ResourceCPU:    resource.MustParse("500m"),  // 0.5 CPU
-							corev1.ResourceMemory: resource.MustParse("256Mi"), // 256MB memory
-						},
-						Limits: corev1.ResourceList{
-							corev1.ResourceCPU:    resource.MustParse("500m"),  // 0.5 CPU
-							corev1.ResourceMemory: resource.MustParse("512Mi"), // 256GB memory
-						},
-					},
-				},
-			},
-			Volumes:                       []corev1.Volume{sslVolume, dockerVolume},
-			TerminationGracePeriodSeconds: ptr.To(int64(30)),
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),  // 0.5 CPU
+			corev1.ResourceMemory: resource.MustParse("512Mi"), // 512MB memory
 		},
 	}
+}
 
-	// Set owner reference
-	if err := ctrl.SetControllerReference(poolScaler, pod, r.Scheme); err != nil {
-		logf.FromContext(context.Background()).Error(err, "Failed to set owner reference")
+// Tier served by a PoolScaler, pipeline for resources created before roles existed
+func poolRole(poolScaler *hpav1.PoolScaler) string {
+	if poolScaler.Spec.Role == "" {
+		return hpav1.PoolScalerRolePipeline
 	}
-
-	return pod
+	return poolScaler.Spec.Role
 }
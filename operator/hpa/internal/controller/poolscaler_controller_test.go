@@ -21,8 +21,11 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -69,8 +72,9 @@ var _ = Describe("PoolScaler Controller", func() {
 		It("should successfully reconcile the resource", func() {
 			By("Reconciling the created resource")
 			controllerReconciler := &PoolScalerReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(10),
 			}
 
 			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
@@ -82,3 +86,81 @@ var _ = Describe("PoolScaler Controller", func() {
 		})
 	})
 })
+
+var _ = Describe("Worker pods", func() {
+	poolScaler := func(role string, workerPool hpav1.WorkerPoolConfig) *hpav1.PoolScaler {
+		return &hpav1.PoolScaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "executor-autoscale", Namespace: "default"},
+			Spec: hpav1.PoolScalerSpec{
+				Role:        role,
+				InputQueue:  hpav1.RabbitMQConfig{Host: "job-queue.default.svc"},
+				OutputQueue: hpav1.RabbitMQConfig{Host: "next-queue.default.svc", QueueName: "next"},
+				Database:    hpav1.DatabaseConfig{SSLCASecretRef: &hpav1.SecretReference{Name: "db-ca", Key: "ca.pem"}},
+				Storage:     hpav1.StorageConfig{Host: "minio.default.svc"},
+				WorkerPool:  workerPool,
+			},
+		}
+	}
+
+	It("should schedule workers with the resources and placement of their pool", func() {
+		workerPool := hpav1.WorkerPoolConfig{
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			},
+			NodeSelector:       map[string]string{"pool": "builds"},
+			Tolerations:        []corev1.Toleration{{Key: "builds", Operator: corev1.TolerationOpExists}},
+			ServiceAccountName: "executor",
+		}
+		spec := (&PoolScalerReconciler{}).workerPodSpec(poolScaler(hpav1.PoolScalerRoleJob, workerPool), "executor-autoscale-worker-1-0")
+
+		Expect(spec.Containers[0].Resources.Limits.Cpu().String()).To(Equal("2"))
+		Expect(spec.Containers[0].Resources.Requests).To(BeEmpty())
+		Expect(spec.NodeSelector).To(Equal(map[string]string{"pool": "builds"}))
+		Expect(spec.Tolerations).To(HaveLen(1))
+		Expect(spec.ServiceAccountName).To(Equal("executor"))
+	})
+
+	It("should give the output queue to the pipeline tier only", func() {
+		envNames := func(spec corev1.PodSpec) []string {
+			var names []string
+			for _, envVar := range spec.Containers[0].Env {
+				names = append(names, envVar.Name)
+			}
+			return names
+		}
+
+		pipeline := (&PoolScalerReconciler{}).workerPodSpec(poolScaler("", hpav1.WorkerPoolConfig{}), "pipeline-worker-1-0")
+		Expect(envNames(pipeline)).To(ContainElement("JOB_QUEUE_URL"))
+		Expect(pipeline.Containers[0].Resources.Limits.Memory().String()).To(Equal("512Mi"))
+
+		job := (&PoolScalerReconciler{}).workerPodSpec(poolScaler(hpav1.PoolScalerRoleJob, hpav1.WorkerPoolConfig{}), "executor-worker-1-0")
+		Expect(envNames(job)).NotTo(ContainElement("JOB_QUEUE_URL"))
+		Expect(workerLabels(poolScaler(hpav1.PoolScalerRoleJob, hpav1.WorkerPoolConfig{}))).To(HaveKeyWithValue(roleLabel, "job"))
+	})
+
+	It("should mount the Docker socket in docker job workers only", func() {
+		hostPaths := func(spec corev1.PodSpec) []string {
+			var paths []string
+			for _, volume := range spec.Volumes {
+				if volume.HostPath != nil {
+					paths = append(paths, volume.HostPath.Path)
+				}
+			}
+			return paths
+		}
+
+		docker := (&PoolScalerReconciler{}).workerPodSpec(poolScaler(hpav1.PoolScalerRoleJob, hpav1.WorkerPoolConfig{}), "executor-worker-1-0")
+		Expect(hostPaths(docker)).To(Equal([]string{"/var/run/docker.sock"}))
+		Expect(docker.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "CONTAINER_RUNTIME", Value: "docker"}))
+
+		pipeline := (&PoolScalerReconciler{}).workerPodSpec(poolScaler("", hpav1.WorkerPoolConfig{}), "pipeline-worker-1-0")
+		Expect(hostPaths(pipeline)).To(BeEmpty())
+		Expect(pipeline.Containers[0].Env).NotTo(ContainElement(HaveField("Name", "CONTAINER_RUNTIME")))
+
+		workerPool := hpav1.WorkerPoolConfig{Runtime: hpav1.WorkerRuntimeKubernetes, ServiceAccountName: "executor"}
+		kubernetes := (&PoolScalerReconciler{}).workerPodSpec(poolScaler(hpav1.PoolScalerRoleJob, workerPool), "executor-worker-1-0")
+		Expect(hostPaths(kubernetes)).To(BeEmpty())
+		Expect(kubernetes.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "CONTAINER_RUNTIME", Value: "kubernetes"}))
+		Expect(kubernetes.Containers[0].Env).To(ContainElement(HaveField("Name", "POD_NAMESPACE")))
+	})
+})
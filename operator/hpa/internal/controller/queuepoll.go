@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+)
+
+const (
+	// Reconciliation of a PoolScaler without any event, new messages and worker changes trigger one before
+	resyncPeriod = 5 * time.Minute
+	// Longest time between two checks of a queue depth, a new message is seen within it
+	maxQueuePollInterval = 5 * time.Second
+)
+
+// AMQP connections cached per PoolScaler, shared by its reconciliations and its queue poller
+type rabbitMQConnections struct {
+	mu    sync.Mutex
+	conns map[types.NamespacedName]*cachedConnection
+}
+
+type cachedConnection struct {
+	url  string // Credentials included, a rotated password dials again
+	conn *amqp.Connection
+}
+
+// Connection of a PoolScaler, dialed when missing, closed or when its URL changed
+func (c *rabbitMQConnections) get(key types.NamespacedName, url string) (*amqp.Connection, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.conns[key]; ok {
+		if cached.url == url && !cached.conn.IsClosed() {
+			return cached.conn, nil
+		}
+		cached.conn.Close()
+		delete(c.conns, key)
+	}
+
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	if c.conns == nil {
+		c.conns = make(map[types.NamespacedName]*cachedConnection)
+	}
+	c.conns[key] = &cachedConnection{url: url, conn: conn}
+	return conn, nil
+}
+
+// Closes the connection of a deleted PoolScaler
+func (c *rabbitMQConnections) close(key types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.conns[key]; ok {
+		cached.conn.Close()
+		delete(c.conns, key)
+	}
+}
+
+// Closes every connection, when the manager stops
+func (c *rabbitMQConnections) closeAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, cached := range c.conns {
+		cached.conn.Close()
+		delete(c.conns, key)
+	}
+}
+
+// Depth of the input queue of a PoolScaler, on a channel of its cached connection
+type queueProbe struct {
+	connections *rabbitMQConnections
+	key         types.NamespacedName
+	url         string
+	queueName   string
+	channel     *amqp.Channel
+}
+
+// Messages in the queue, the channel is opened again after a failure closed it
+func (p *queueProbe) depth() (int, error) {
+	if p.channel == nil || p.channel.IsClosed() {
+		conn, err := p.connections.get(p.key, p.url)
+		if err != nil {
+			return 0, err
+		}
+		if p.channel, err = conn.Channel(); err != nil {
+			return 0, fmt.Errorf("failed to open channel: %w", err)
+		}
+	}
+	queue, err := p.channel.QueueDeclarePassive(p.queueName, true, false, false, false, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect queue: %w", err)
+	}
+	return queue.Messages, nil
+}
+
+func (p *queueProbe) close() {
+	if p.channel != nil {
+		p.channel.Close()
+	}
+}
+
+/*
+Checks the depth of a queue every interval until the context is done, and notifies
+when it rose: new messages were published since the previous check.
+Messages published and consumed between two checks are not notified, a worker already took them.
+Failed checks are logged once per distinct error, the periodic resync reports them in the status.
+
+RabbitMQ has no event for a message entering a queue, so the depth is polled on the cached connection:
+  - consuming the input queue would take the messages of the workers, which Get them one at a time
+  - the firehose tracer copies every message of the vhost and has to be enabled by an administrator
+  - a fanout exchange notifying the operator would change how every publisher routes its messages
+  - the management API only refreshes its queue statistics every few seconds, no sooner than a poll
+*/
+func pollQueue(ctx context.Context, interval time.Duration, depth func() (int, error), notify func()) {
+	log := logf.FromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last, lastErr := -1, ""
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		messages, err := depth()
+		if err != nil {
+			if err.Error() != lastErr {
+				log.Error(err, "Failed to check queue depth")
+				lastErr = err.Error()
+			}
+			continue
+		}
+		lastErr = ""
+		if last >= 0 && messages > last {
+			notify()
+		}
+		last = messages
+	}
+}
+
+// Queue pollers of the PoolScalers, their events trigger reconciliations
+type queuePollers struct {
+	mu      sync.Mutex
+	pollers map[types.NamespacedName]*queuePoller
+	events  chan event.GenericEvent // Source of the controller, nil until SetupWithManager
+}
+
+type queuePoller struct {
+	url       string
+	queueName string
+	interval  time.Duration
+	cancel    context.CancelFunc
+}
+
+/*
+Starts the queue poller of a PoolScaler, once per queue: a poller of the same queue
+keeps running, a poller of a changed queue, address or interval is replaced.
+*/
+func (w *queuePollers) ensure(ctx context.Context, connections *rabbitMQConnections, poolScaler *hpav1.PoolScaler, url string) {
+	if w.events == nil {
+		return // Reconciler not set up with a manager
+	}
+	key := types.NamespacedName{Name: poolScaler.Name, Namespace: poolScaler.Namespace}
+	queueName := poolScaler.Spec.InputQueue.QueueName
+	interval := queuePollInterval(poolScaler)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if poller, ok := w.pollers[key]; ok {
+		if poller.url == url && poller.queueName == queueName && poller.interval == interval {
+			return
+		}
+		poller.cancel()
+	}
+
+	pollCtx, cancel := context.WithCancel(logf.IntoContext(context.Background(), logf.FromContext(ctx)))
+	if w.pollers == nil {
+		w.pollers = make(map[types.NamespacedName]*queuePoller)
+	}
+	w.pollers[key] = &queuePoller{url: url, queueName: queueName, interval: interval, cancel: cancel}
+
+	probe := &queueProbe{connections: connections, key: key, url: url, queueName: queueName}
+	trigger := event.GenericEvent{Object: &hpav1.PoolScaler{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}}
+	go func() {
+		defer probe.close()
+		pollQueue(pollCtx, interval, probe.depth, func() {
+			select {
+			case w.events <- trigger:
+			case <-pollCtx.Done():
+			}
+		})
+	}()
+}
+
+// Polling interval of a PoolScaler, capped far below the resync period
+func queuePollInterval(poolScaler *hpav1.PoolScaler) time.Duration {
+	interval := time.Duration(max(poolScaler.Spec.Scaling.PollingIntervalSeconds, 1)) * time.Second
+	return min(interval, maxQueuePollInterval)
+}
+
+// Stops the queue poller of a deleted PoolScaler
+func (w *queuePollers) stop(key types.NamespacedName) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if poller, ok := w.pollers[key]; ok {
+		poller.cancel()
+		delete(w.pollers, key)
+	}
+}
+
+// Stops every queue poller, when the manager stops
+func (w *queuePollers) stopAll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for key, poller := range w.pollers {
+		poller.cancel()
+		delete(w.pollers, key)
+	}
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+)
+
+const (
+	// Label of the worker pods of a PoolScaler
+	instanceLabel = "poolscaler-instance"
+	// Label of the worker pods with the tier of their PoolScaler
+	roleLabel = "poolscaler-role"
+)
+
+// Counts the workers of a PoolScaler that are pending or running, Jobs in Job mode and pods otherwise
+func (r *PoolScalerReconciler) countActiveWorkers(ctx context.Context, poolScaler *hpav1.PoolScaler) (int32, error) {
+	if poolScaler.Spec.WorkerPool.Mode == hpav1.WorkerPoolModeJob {
+		jobs, err := r.listWorkerJobs(ctx, poolScaler)
+		if err != nil {
+			return 0, err
+		}
+		var active int32
+		for i := range jobs {
+			if jobs[i].DeletionTimestamp == nil && jobFinishedCondition(&jobs[i]) == nil {
+				active++
+			}
+		}
+		return active, nil
+	}
+
+	pods := &corev1.PodList{}
+	err := r.List(ctx, pods,
+		client.InNamespace(poolScaler.Namespace),
+		client.MatchingLabels{instanceLabel: poolScaler.Name},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var active int32
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		active++
+	}
+	return active, nil
+}
+
+/*
+Number of workers wanted for the messages waiting in the queue:
+one every MessagesPerWorker messages, at least MinReplicas while messages are waiting, at most MaxReplicas.
+*/
+func desiredReplicas(messages int32, spec hpav1.PoolScalerSpec) int32 {
+	if messages <= 0 {
+		return 0
+	}
+	perWorker := max(spec.Scaling.MessagesPerWorker, 1)
+	maxReplicas := max(spec.WorkerPool.MaxReplicas, 1)
+
+	desired := (messages + perWorker - 1) / perWorker
+	desired = max(desired, spec.WorkerPool.MinReplicas)
+	return min(desired, maxReplicas)
+}
+
+/*
+Number of worker pods to start on top of the active ones.
+Active pods already took their message off the queue, so the waiting messages are only bounded by the room left
+under MaxReplicas. Every pod takes a single message, the others stay in the queue until a worker finishes.
+*/
+func workersToStart(messages, active int32, spec hpav1.PoolScalerSpec) int32 {
+	room := max(spec.WorkerPool.MaxReplicas-active, 0)
+	return min(desiredReplicas(messages, spec), room, max(messages, 0))
+}
+
+// Time left before the pool may scale up again, zero once the cooldown period is over
+func cooldownRemaining(lastScaleTime *metav1.Time, spec hpav1.PoolScalerSpec, now time.Time) time.Duration {
+	if lastScaleTime == nil {
+		return 0
+	}
+	cooldown := time.Duration(spec.Scaling.CooldownPeriodSeconds) * time.Second
+	return max(lastScaleTime.Add(cooldown).Sub(now), 0)
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+)
+
+var _ = Describe("Worker pool scaling", func() {
+	spec := hpav1.PoolScalerSpec{
+		WorkerPool: hpav1.WorkerPoolConfig{MinReplicas: 2, MaxReplicas: 5},
+		Scaling:    hpav1.ScalingConfig{MessagesPerWorker: 3, CooldownPeriodSeconds: 30},
+	}
+
+	It("should want one worker every MessagesPerWorker messages within the replica bounds", func() {
+		Expect(desiredReplicas(0, spec)).To(Equal(int32(0)))
+		Expect(desiredReplicas(1, spec)).To(Equal(int32(2)))
+		Expect(desiredReplicas(9, spec)).To(Equal(int32(3)))
+		Expect(desiredReplicas(10, spec)).To(Equal(int32(4)))
+		Expect(desiredReplicas(100, spec)).To(Equal(int32(5)))
+	})
+
+	It("should keep messages in the queue once the pool is at capacity", func() {
+		Expect(workersToStart(100, 0, spec)).To(Equal(int32(5)))
+		Expect(workersToStart(100, 3, spec)).To(Equal(int32(2)))
+		Expect(workersToStart(100, 5, spec)).To(Equal(int32(0)))
+		Expect(workersToStart(100, 7, spec)).To(Equal(int32(0)))
+		// Active workers already took their message, the waiting ones still need workers
+		Expect(workersToStart(3, 1, spec)).To(Equal(int32(2)))
+		Expect(workersToStart(9, 3, spec)).To(Equal(int32(2)))
+		Expect(workersToStart(1, 1, hpav1.PoolScalerSpec{
+			WorkerPool: hpav1.WorkerPoolConfig{MaxReplicas: 5},
+			Scaling:    hpav1.ScalingConfig{MessagesPerWorker: 1},
+		})).To(Equal(int32(1)))
+		// Every worker takes a single message
+		Expect(workersToStart(1, 0, spec)).To(Equal(int32(1)))
+	})
+
+	It("should not scale up again during the cooldown period", func() {
+		now := time.Now()
+		Expect(cooldownRemaining(nil, spec, now)).To(BeZero())
+
+		lastScaleTime := metav1.NewTime(now.Add(-10 * time.Second))
+		Expect(cooldownRemaining(&lastScaleTime, spec, now)).To(BeNumerically("~", 20*time.Second, time.Second))
+
+		lastScaleTime = metav1.NewTime(now.Add(-time.Minute))
+		Expect(cooldownRemaining(&lastScaleTime, spec, now)).To(BeZero())
+	})
+})
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+)
+
+const (
+	// Volume of the task Secret in the worker pods
+	taskVolumeName = "task"
+	taskMountPath  = "/etc/worker"
+	taskFileName   = "task.json"
+)
+
+// Environment variable read from a key of a Secret, the value never appears in the pod spec
+func secretEnvVar(name string, secretRef hpav1.SecretReference) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretRef.Name},
+				Key:                  secretRef.Key,
+			},
+		},
+	}
+}
+
+// Name of the Secret holding the task of a worker
+func taskSecretName(workerName string) string {
+	return workerName + "-task"
+}
+
+// Volume mounting the task Secret of a worker, read by the worker with --input-file
+func taskVolume(workerName string) (corev1.Volume, corev1.VolumeMount) {
+	volume := corev1.Volume{
+		Name: taskVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: taskSecretName(workerName),
+				Items:      []corev1.KeyToPath{{Key: taskFileName, Path: taskFileName}},
+			},
+		},
+	}
+	mount := corev1.VolumeMount{
+		Name:      taskVolumeName,
+		MountPath: taskMountPath,
+		ReadOnly:  true,
+	}
+	return volume, mount
+}
+
+/*
+Creates the Secret holding the task of a worker. The worker owns it, so it is deleted with
+the worker. The worker pod waits for its volume until the Secret exists.
+*/
+func (r *PoolScalerReconciler) createTaskSecret(ctx context.Context, worker client.Object, messageBody []byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      taskSecretName(worker.GetName()),
+			Namespace: worker.GetNamespace(),
+			Labels:    worker.GetLabels(),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{taskFileName: messageBody},
+	}
+	if err := controllerutil.SetOwnerReference(worker, secret, r.Scheme); err != nil {
+		return err
+	}
+	return r.Create(ctx, secret)
+}
@@ -0,0 +1,304 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var poolscalerlog = logf.Log.WithName("poolscaler-resource")
+
+// Default ports of the services a PoolScaler connects to
+const (
+	defaultRabbitMQPort int32 = 5672
+	defaultMySQLPort    int32 = 3306
+	defaultRedisPort    int32 = 6379
+)
+
+// Well-known ports and the service listening on them, a port of another service is a misconfiguration
+var wellKnownPorts = map[int32]string{
+	5671:  "RabbitMQ",
+	5672:  "RabbitMQ",
+	15672: "RabbitMQ management",
+	3306:  "MySQL",
+	6379:  "Redis",
+	9000:  "MinIO",
+	9001:  "MinIO console",
+}
+
+// SetupPoolScalerWebhookWithManager registers the webhook for PoolScaler in the manager.
+// Secrets are read with the API reader, the validator does not need a cache of every Secret.
+func SetupPoolScalerWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&hpav1.PoolScaler{}).
+		WithValidator(&PoolScalerCustomValidator{Reader: mgr.GetAPIReader()}).
+		WithDefaulter(&PoolScalerCustomDefaulter{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-hpa-cicd-operator-v1-poolscaler,mutating=true,failurePolicy=fail,sideEffects=None,groups=hpa.cicd.operator,resources=poolscalers,verbs=create;update,versions=v1,name=mpoolscaler-v1.kb.io,admissionReviewVersions=v1
+
+// PoolScalerCustomDefaulter struct is responsible for setting default values on the custom resource of the
+// Kind PoolScaler when those are created or updated.
+type PoolScalerCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &PoolScalerCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind PoolScaler.
+func (d *PoolScalerCustomDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	poolscaler, ok := obj.(*hpav1.PoolScaler)
+	if !ok {
+		return fmt.Errorf("expected a PoolScaler object but got %T", obj)
+	}
+	poolscalerlog.Info("Defaulting for PoolScaler", "name", poolscaler.GetName())
+
+	spec := &poolscaler.Spec
+	if spec.Role == "" {
+		spec.Role = hpav1.PoolScalerRolePipeline
+	}
+
+	// Each service on its own default port
+	if spec.InputQueue.Port == 0 {
+		spec.InputQueue.Port = defaultRabbitMQPort
+	}
+	if spec.OutputQueue.Host != "" && spec.OutputQueue.Port == 0 {
+		spec.OutputQueue.Port = defaultRabbitMQPort
+	}
+	if spec.Database.Port == 0 {
+		spec.Database.Port = defaultMySQLPort
+	}
+	if spec.Database.SSLMode == "" {
+		spec.Database.SSLMode = "false"
+	}
+	if spec.Cache.Port == 0 {
+		spec.Cache.Port = defaultRedisPort
+	}
+
+	// Worker pool, at least MinReplicas workers fit in the pool
+	pool := &spec.WorkerPool
+	if pool.MinReplicas == 0 {
+		pool.MinReplicas = 1
+	}
+	if pool.MaxReplicas == 0 {
+		pool.MaxReplicas = max(5, pool.MinReplicas)
+	}
+	if pool.WorkerImageTag == "" {
+		pool.WorkerImageTag = "latest"
+	}
+	if pool.Mode == "" {
+		pool.Mode = hpav1.WorkerPoolModePod
+	}
+	if pool.Runtime == "" {
+		pool.Runtime = hpav1.WorkerRuntimeDocker
+	}
+	if pool.Mode == hpav1.WorkerPoolModeJob {
+		pool.Job.BackoffLimit = ptr.To(ptr.Deref(pool.Job.BackoffLimit, 2))
+		pool.Job.TTLSecondsAfterFinished = ptr.To(ptr.Deref(pool.Job.TTLSecondsAfterFinished, 300))
+		pool.Job.DefaultDeadlineSeconds = ptr.To(ptr.Deref(pool.Job.DefaultDeadlineSeconds, 3600))
+	}
+
+	// Scaling and cleanup
+	if spec.Scaling.MessagesPerWorker == 0 {
+		spec.Scaling.MessagesPerWorker = 1
+	}
+	if spec.Scaling.PollingIntervalSeconds == 0 {
+		spec.Scaling.PollingIntervalSeconds = 5
+	}
+	if spec.Scaling.CooldownPeriodSeconds == 0 {
+		spec.Scaling.CooldownPeriodSeconds = 30
+	}
+	spec.Cleanup.SucceededPodTTLSeconds = ptr.To(ptr.Deref(spec.Cleanup.SucceededPodTTLSeconds, 300))
+	spec.Cleanup.FailedPodRetentionSeconds = ptr.To(ptr.Deref(spec.Cleanup.FailedPodRetentionSeconds, 86400))
+
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-hpa-cicd-operator-v1-poolscaler,mutating=false,failurePolicy=fail,sideEffects=None,groups=hpa.cicd.operator,resources=poolscalers,verbs=create;update,versions=v1,name=vpoolscaler-v1.kb.io,admissionReviewVersions=v1
+
+// PoolScalerCustomValidator struct is responsible for validating the PoolScaler resource
+// when it is created, updated, or deleted.
+type PoolScalerCustomValidator struct {
+	// Reads the Secrets referenced by a PoolScaler
+	Reader client.Reader
+}
+
+var _ webhook.CustomValidator = &PoolScalerCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type PoolScaler.
+func (v *PoolScalerCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	poolscaler, ok := obj.(*hpav1.PoolScaler)
+	if !ok {
+		return nil, fmt.Errorf("expected a PoolScaler object but got %T", obj)
+	}
+	poolscalerlog.Info("Validation for PoolScaler upon creation", "name", poolscaler.GetName())
+
+	return v.validatePoolScaler(ctx, poolscaler)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type PoolScaler.
+func (v *PoolScalerCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	poolscaler, ok := newObj.(*hpav1.PoolScaler)
+	if !ok {
+		return nil, fmt.Errorf("expected a PoolScaler object for the newObj but got %T", newObj)
+	}
+	poolscalerlog.Info("Validation for PoolScaler upon update", "name", poolscaler.GetName())
+
+	return v.validatePoolScaler(ctx, poolscaler)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type PoolScaler.
+func (v *PoolScalerCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// Validates a PoolScaler, every problem is reported at once
+func (v *PoolScalerCustomValidator) validatePoolScaler(ctx context.Context, poolscaler *hpav1.PoolScaler) (admission.Warnings, error) {
+	var allErrs field.ErrorList
+	var warnings admission.Warnings
+	spec := poolscaler.Spec
+	specPath := field.NewPath("spec")
+
+	// Worker pool
+	poolPath := specPath.Child("workerPool")
+	if spec.WorkerPool.MinReplicas > spec.WorkerPool.MaxReplicas {
+		allErrs = append(allErrs, field.Invalid(poolPath.Child("minReplicas"), spec.WorkerPool.MinReplicas,
+			fmt.Sprintf("must be less than or equal to maxReplicas (%d)", spec.WorkerPool.MaxReplicas)))
+	}
+
+	// Ports of the services
+	allErrs = append(allErrs, validatePort(specPath.Child("inputQueue", "port"), spec.InputQueue.Port, "RabbitMQ")...)
+	allErrs = append(allErrs, validatePort(specPath.Child("database", "port"), spec.Database.Port, "MySQL")...)
+	allErrs = append(allErrs, validatePort(specPath.Child("cache", "port"), spec.Cache.Port, "Redis")...)
+
+	// Output queue, complete when set
+	outputPath := specPath.Child("outputQueue")
+	hasOutputQueue := !reflect.DeepEqual(spec.OutputQueue, hpav1.RabbitMQConfig{})
+	if hasOutputQueue {
+		required := []struct {
+			path  *field.Path
+			value string
+		}{
+			{outputPath.Child("host"), spec.OutputQueue.Host},
+			{outputPath.Child("username"), spec.OutputQueue.Username},
+			{outputPath.Child("queueName"), spec.OutputQueue.QueueName},
+			{outputPath.Child("passwordSecretRef", "name"), spec.OutputQueue.PasswordSecretRef.Name},
+			{outputPath.Child("passwordSecretRef", "key"), spec.OutputQueue.PasswordSecretRef.Key},
+		}
+		for _, requiredField := range required {
+			if requiredField.value == "" {
+				allErrs = append(allErrs, field.Required(requiredField.path, "required when outputQueue is set"))
+			}
+		}
+		allErrs = append(allErrs, validatePort(outputPath.Child("port"), spec.OutputQueue.Port, "RabbitMQ")...)
+		if spec.OutputQueue.Host == spec.InputQueue.Host && spec.OutputQueue.Port == spec.InputQueue.Port &&
+			spec.OutputQueue.QueueName == spec.InputQueue.QueueName {
+			allErrs = append(allErrs, field.Invalid(outputPath.Child("queueName"), spec.OutputQueue.QueueName,
+				"must differ from the input queue, workers would consume their own messages"))
+		}
+	}
+	switch {
+	case spec.Role != hpav1.PoolScalerRoleJob && !hasOutputQueue:
+		warnings = append(warnings, "spec.outputQueue is not set, pipeline workers cannot hand jobs to the job tier")
+	case spec.Role == hpav1.PoolScalerRoleJob && hasOutputQueue:
+		warnings = append(warnings, "spec.outputQueue is ignored by job pools")
+	}
+
+	// Database SSL
+	if spec.Database.SSLMode == "true" && spec.Database.SSLCASecretRef == nil {
+		allErrs = append(allErrs, field.Required(specPath.Child("database", "sslCASecretRef"), "required when sslMode is true"))
+	}
+
+	// Referenced Secrets and keys
+	type secretRefField struct {
+		path      *field.Path
+		secretRef hpav1.SecretReference
+	}
+	secretRefs := []secretRefField{
+		{specPath.Child("inputQueue", "passwordSecretRef"), spec.InputQueue.PasswordSecretRef},
+		{specPath.Child("database", "passwordSecretRef"), spec.Database.PasswordSecretRef},
+		{specPath.Child("storage", "accessKeyRef"), spec.Storage.AccessKeyRef},
+		{specPath.Child("storage", "secretKeyRef"), spec.Storage.SecretKeyRef},
+		{specPath.Child("cache", "passwordSecretRef"), spec.Cache.PasswordSecretRef},
+	}
+	if hasOutputQueue && spec.OutputQueue.PasswordSecretRef.Name != "" {
+		secretRefs = append(secretRefs, secretRefField{outputPath.Child("passwordSecretRef"), spec.OutputQueue.PasswordSecretRef})
+	}
+	if spec.Database.SSLCASecretRef != nil {
+		secretRefs = append(secretRefs, secretRefField{specPath.Child("database", "sslCASecretRef"), *spec.Database.SSLCASecretRef})
+	}
+	for _, ref := range secretRefs {
+		secretErrs, err := v.validateSecretRef(ctx, poolscaler.Namespace, ref.path, ref.secretRef)
+		if err != nil {
+			return warnings, apierrors.NewInternalError(err)
+		}
+		allErrs = append(allErrs, secretErrs...)
+	}
+
+	if len(allErrs) == 0 {
+		return warnings, nil
+	}
+	return warnings, apierrors.NewInvalid(hpav1.GroupVersion.WithKind("PoolScaler").GroupKind(), poolscaler.Name, allErrs)
+}
+
+// Rejects the well-known port of another service, e.g. the MySQL port for Redis
+func validatePort(path *field.Path, port int32, service string) field.ErrorList {
+	if other, ok := wellKnownPorts[port]; ok && other != service {
+		return field.ErrorList{field.Invalid(path, port, fmt.Sprintf("%d is the %s port, not a %s port", port, other, service))}
+	}
+	return nil
+}
+
+// Checks that a referenced Secret exists in the namespace of the PoolScaler and holds the key
+func (v *PoolScalerCustomValidator) validateSecretRef(ctx context.Context, namespace string, path *field.Path, secretRef hpav1.SecretReference) (field.ErrorList, error) {
+	if secretRef.Name == "" || secretRef.Key == "" {
+		return field.ErrorList{field.Required(path, "name and key are required")}, nil
+	}
+
+	secret := &corev1.Secret{}
+	err := v.Reader.Get(ctx, types.NamespacedName{Name: secretRef.Name, Namespace: namespace}, secret)
+	if apierrors.IsNotFound(err) {
+		return field.ErrorList{field.NotFound(path.Child("name"), secretRef.Name)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", secretRef.Name, err)
+	}
+
+	if _, ok := secret.Data[secretRef.Key]; !ok {
+		if _, ok := secret.StringData[secretRef.Key]; !ok {
+			return field.ErrorList{field.Invalid(path.Child("key"), secretRef.Key,
+				fmt.Sprintf("key not found in secret %s", secretRef.Name))}, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hpav1 "cicd.operator/hpa/api/v1"
+	// TODO (user): Add any additional imports if needed
+)
+
+var _ = Describe("PoolScaler Webhook", func() {
+	var (
+		obj       *hpav1.PoolScaler
+		validator PoolScalerCustomValidator
+		defaulter PoolScalerCustomDefaulter
+	)
+
+	secretRef := func(name string) hpav1.SecretReference {
+		return hpav1.SecretReference{Name: name, Key: "password"}
+	}
+
+	// Valid PoolScaler, its Secrets are created by BeforeEach
+	validPoolScaler := func(name string) *hpav1.PoolScaler {
+		return &hpav1.PoolScaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: hpav1.PoolScalerSpec{
+				Role:        hpav1.PoolScalerRolePipeline,
+				InputQueue:  hpav1.RabbitMQConfig{Host: "pipeline-queue", Port: 5672, Username: "guest", PasswordSecretRef: secretRef("webhook-rabbitmq"), QueueName: "task_queue"},
+				OutputQueue: hpav1.RabbitMQConfig{Host: "job-queue", Port: 5673, Username: "guest", PasswordSecretRef: secretRef("webhook-rabbitmq"), QueueName: "job_queue"},
+				Database:    hpav1.DatabaseConfig{Host: "mysql", Port: 3306, Username: "root", PasswordSecretRef: secretRef("webhook-mysql"), Name: "CicdApplication", SSLMode: "false"},
+				Storage:     hpav1.StorageConfig{Host: "minio", AccessKeyRef: secretRef("webhook-minio"), SecretKeyRef: secretRef("webhook-minio"), DefaultBucket: "default"},
+				Cache:       hpav1.CacheConfig{Host: "redis", Port: 6379, Username: "default", PasswordSecretRef: secretRef("webhook-redis")},
+				WorkerPool:  hpav1.WorkerPoolConfig{MinReplicas: 1, MaxReplicas: 5, WorkerImage: "minh160302/worker-api"},
+				Scaling:     hpav1.ScalingConfig{PollingIntervalSeconds: 5},
+			},
+		}
+	}
+
+	// Field paths of the causes of an Invalid error
+	invalidFields := func(err error) []string {
+		var fields []string
+		statusErr, ok := err.(*apierrors.StatusError)
+		Expect(ok).To(BeTrue(), "expected a StatusError, got %v", err)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue(), err.Error())
+		for _, cause := range statusErr.ErrStatus.Details.Causes {
+			fields = append(fields, cause.Field)
+		}
+		return fields
+	}
+
+	BeforeEach(func() {
+		obj = validPoolScaler("webhook-test")
+		validator = PoolScalerCustomValidator{Reader: k8sClient}
+		defaulter = PoolScalerCustomDefaulter{}
+
+		By("creating the referenced Secrets")
+		for _, name := range []string{"webhook-rabbitmq", "webhook-mysql", "webhook-minio", "webhook-redis"} {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Data:       map[string][]byte{"password": []byte("secret")},
+			}
+			if err := k8sClient.Create(ctx, secret); err != nil {
+				Expect(apierrors.IsAlreadyExists(err)).To(BeTrue())
+			}
+		}
+	})
+
+	Context("When creating PoolScaler under Defaulting Webhook", func() {
+		It("Should apply defaults when a required attribute is empty", func() {
+			obj.Spec = hpav1.PoolScalerSpec{
+				OutputQueue: hpav1.RabbitMQConfig{Host: "job-queue"},
+				WorkerPool:  hpav1.WorkerPoolConfig{MinReplicas: 8, Mode: hpav1.WorkerPoolModeJob},
+			}
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+
+			Expect(obj.Spec.Role).To(Equal(hpav1.PoolScalerRolePipeline))
+			Expect(obj.Spec.InputQueue.Port).To(Equal(int32(5672)))
+			Expect(obj.Spec.OutputQueue.Port).To(Equal(int32(5672)))
+			Expect(obj.Spec.Database.Port).To(Equal(int32(3306)))
+			Expect(obj.Spec.Cache.Port).To(Equal(int32(6379)))
+			Expect(obj.Spec.WorkerPool.MaxReplicas).To(Equal(int32(8)))
+			Expect(obj.Spec.WorkerPool.Runtime).To(Equal(hpav1.WorkerRuntimeDocker))
+			Expect(obj.Spec.WorkerPool.Job.BackoffLimit).To(Equal(ptr.To(int32(2))))
+			Expect(obj.Spec.Scaling.CooldownPeriodSeconds).To(Equal(int32(30)))
+			Expect(obj.Spec.Cleanup.FailedPodRetentionSeconds).To(Equal(ptr.To(int32(86400))))
+		})
+
+		It("Should keep the values that are set", func() {
+			obj.Spec.Cache.Port = 16379
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Cache.Port).To(Equal(int32(16379)))
+			Expect(obj.Spec.WorkerPool.MaxReplicas).To(Equal(int32(5)))
+			Expect(obj.Spec.WorkerPool.Job.BackoffLimit).To(BeNil())
+		})
+	})
+
+	Context("When creating or updating PoolScaler under Validating Webhook", func() {
+		It("Should admit a valid PoolScaler", func() {
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny creation if MinReplicas is above MaxReplicas", func() {
+			obj.Spec.WorkerPool.MinReplicas = 6
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(invalidFields(err)).To(Equal([]string{"spec.workerPool.minReplicas"}))
+		})
+
+		It("Should deny creation if a referenced Secret or key does not exist", func() {
+			obj.Spec.Database.PasswordSecretRef = secretRef("webhook-missing")
+			obj.Spec.Cache.PasswordSecretRef.Key = "REDIS_PASSWORD"
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(invalidFields(err)).To(Equal([]string{"spec.database.passwordSecretRef.name", "spec.cache.passwordSecretRef.key"}))
+		})
+
+		It("Should deny creation if a port belongs to another service", func() {
+			obj.Spec.Cache.Port = 3306
+			obj.Spec.InputQueue.Port = 15672
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(invalidFields(err)).To(Equal([]string{"spec.inputQueue.port", "spec.cache.port"}))
+			Expect(err.Error()).To(ContainSubstring("3306 is the MySQL port, not a Redis port"))
+		})
+
+		It("Should deny creation if the output queue is incomplete", func() {
+			obj.Spec.OutputQueue = hpav1.RabbitMQConfig{Host: "job-queue", Port: 5672}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(invalidFields(err)).To(Equal([]string{
+				"spec.outputQueue.username",
+				"spec.outputQueue.queueName",
+				"spec.outputQueue.passwordSecretRef.name",
+				"spec.outputQueue.passwordSecretRef.key",
+			}))
+		})
+
+		It("Should deny an output queue that is the input queue", func() {
+			obj.Spec.OutputQueue = obj.Spec.InputQueue
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(invalidFields(err)).To(Equal([]string{"spec.outputQueue.queueName"}))
+		})
+
+		It("Should require the CA certificate when SSL is enabled", func() {
+			obj.Spec.Database.SSLMode = "true"
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(invalidFields(err)).To(Equal([]string{"spec.database.sslCASecretRef"}))
+		})
+
+		It("Should warn about the output queue of the role", func() {
+			obj.Spec.OutputQueue = hpav1.RabbitMQConfig{}
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(HaveLen(1))
+
+			obj.Spec.Role = hpav1.PoolScalerRoleJob
+			warnings, err = validator.ValidateUpdate(ctx, validPoolScaler("webhook-test"), obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should validate updates like creations", func() {
+			oldObj := validPoolScaler("webhook-test")
+			obj.Spec.WorkerPool.MaxReplicas = 0
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(invalidFields(err)).To(Equal([]string{"spec.workerPool.minReplicas"}))
+		})
+	})
+
+	Context("When creating PoolScaler through the API server", func() {
+		It("Should default then admit a valid PoolScaler", func() {
+			obj := validPoolScaler("webhook-admitted")
+			obj.Spec.Role = ""
+			Expect(k8sClient.Create(ctx, obj)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, obj))).To(Succeed())
+			})
+
+			created := &hpav1.PoolScaler{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "webhook-admitted", Namespace: "default"}, created)).To(Succeed())
+			Expect(created.Spec.Role).To(Equal(hpav1.PoolScalerRolePipeline))
+		})
+
+		It("Should reject an invalid PoolScaler", func() {
+			obj := validPoolScaler("webhook-rejected")
+			obj.Spec.Storage.AccessKeyRef = secretRef("webhook-missing")
+			err := k8sClient.Create(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.storage.accessKeyRef.name"))
+		})
+	})
+})
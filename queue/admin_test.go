@@ -0,0 +1,81 @@
+package queue
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToDeadLetter(t *testing.T) {
+	// Dead-lettered by a consumer
+	deadLetter := toDeadLetter(1, Message{
+		Headers: map[string]interface{}{RetryCountHeader: int32(3), LastErrorHeader: "failed to connect to database"},
+		Body:    []byte(`{"id":"1"}`),
+	})
+	assert.Equal(t, DeadLetter{Position: 1, RetryCount: 3, LastError: "failed to connect to database", Body: `{"id":"1"}`}, deadLetter)
+
+	// Rejected by the operator, dead-lettered by the broker
+	deadLetter = toDeadLetter(2, Message{
+		Headers: amqp.Table{"x-death": []interface{}{amqp.Table{"reason": "rejected", "queue": "task_queue"}}},
+		Body:    []byte("not json"),
+	})
+	assert.Equal(t, "rejected", deadLetter.Reason)
+	assert.Equal(t, 0, deadLetter.RetryCount)
+}
+
+func TestOpenQueueBroker(t *testing.T) {
+	_, err := OpenQueueBroker("other_queue")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown queue `other_queue`")
+	assert.Equal(t, "job_queue.dead", DeadLetterQueueName("job_queue"))
+
+	os.Setenv("QUEUE_BACKEND", "memory")
+	defer os.Unsetenv("QUEUE_BACKEND")
+	q, err := OpenQueueBroker("job_queue")
+	require.NoError(t, err)
+	assert.Equal(t, sharedMemoryQueue, q)
+}
+
+func TestDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+	deadLetterQueue := DeadLetterQueueName("task_queue")
+	for _, body := range []string{"1", "2", "3"} {
+		err := q.Publish(ctx, deadLetterQueue, Message{
+			Body:    []byte(body),
+			Headers: map[string]interface{}{RetryCountHeader: int32(3), LastErrorHeader: "failed", "x-trace": body},
+		})
+		require.NoError(t, err)
+	}
+
+	// Listed messages stay in order
+	deadLetters, err := ListDeadLetters(q, "task_queue", 2)
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 2)
+	assert.Equal(t, "1", deadLetters[0].Body)
+	assert.Equal(t, 2, deadLetters[1].Position)
+	deadLetters, err = ListDeadLetters(q, "task_queue", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, []string{deadLetters[0].Body, deadLetters[1].Body, deadLetters[2].Body})
+
+	// Replayed messages start over their retries
+	replayed, err := ReplayDeadLetters(ctx, q, "task_queue", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	msg, ok, err := q.Get("task_queue")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "1", string(msg.Body))
+	assert.Equal(t, map[string]interface{}{"x-trace": "1"}, msg.Headers)
+	require.NoError(t, q.Ack(msg))
+
+	purged, err := PurgeDeadLetters(q, "task_queue")
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+	depth, _ := q.Depth(deadLetterQueue)
+	assert.Equal(t, 0, depth)
+}
@@ -0,0 +1,289 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RabbitMQ queue backend
+type amqpQueue struct {
+	conn      *amqp.Connection
+	ch        *amqp.Channel  // Declaration channel
+	publisher *amqpPublisher // Shared by the process, publishing outlives the queue
+	mu        sync.Mutex
+	declared  map[string]bool
+	channels  []*amqp.Channel // Consumer channels, kept open to settle in-flight messages
+}
+
+// Number of consumers started by this process, makes consumer tags unique
+var consumerCount atomic.Int64
+
+// Connects to RabbitMQ
+func DialAMQP(url string) (Queue, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %v", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open a channel: %v", err)
+	}
+
+	return &amqpQueue{conn: conn, ch: ch, publisher: sharedPublisher(url), declared: make(map[string]bool)}, nil
+}
+
+/*
+Declare a durable queue with its dead-letter queue.
+Dead-letter and deferred queues are declared through the queue they belong to.
+*/
+func declareQueue(ch *amqp.Channel, queueName string) error {
+	if name, ok := strings.CutSuffix(queueName, deadLetterQueueSuffix); ok {
+		return DeclareDeadLetterQueue(ch, name)
+	}
+	if name, ok := strings.CutSuffix(queueName, deferredQueueSuffix); ok {
+		return declareDeferredQueue(ch, name)
+	}
+	if err := DeclareDeadLetterQueue(ch, queueName); err != nil {
+		return err
+	}
+	_, err := ch.QueueDeclare(
+		queueName,
+		true,        // Durable
+		false,       // Auto-delete
+		false,       // Exclusive
+		false,       // No-wait
+		QueueArgs(), // Args
+	)
+	if IsArgumentsMismatch(err) {
+		return fmt.Errorf("queue `%s` was declared with other arguments by a previous release, re-create it with cmd/migrate: %w", queueName, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to declare a queue: %v", err)
+	}
+	return nil
+}
+
+/*
+Declare the deferred queue of a queue, without consumer: its messages expire
+into the queue through the default exchange once their delay passed.
+*/
+func declareDeferredQueue(ch *amqp.Channel, queueName string) error {
+	if err := declareQueue(ch, queueName); err != nil {
+		return err
+	}
+	_, err := ch.QueueDeclare(
+		DeferredQueueName(queueName),
+		true,  // Durable
+		false, // Auto-delete
+		false, // Exclusive
+		false, // No-wait
+		amqp.Table{
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queueName,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare a queue: %v", err)
+	}
+	return nil
+}
+
+// Declare a durable queue once per connection
+func (q *amqpQueue) declare(ch *amqp.Channel, queueName string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.declared[queueName] {
+		return nil
+	}
+	if err := declareQueue(ch, queueName); err != nil {
+		return err
+	}
+	q.declared[queueName] = true
+	return nil
+}
+
+// Published with confirms through the shared publisher of the broker
+func (q *amqpQueue) Publish(ctx context.Context, queueName string, msg Message) error {
+	return q.publisher.Publish(ctx, queueName, msg)
+}
+
+func (q *amqpQueue) Consume(ctx context.Context, queueName string, options ConsumeOptions) (<-chan Message, error) {
+	ch, err := q.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel: %v", err)
+	}
+	q.mu.Lock()
+	q.channels = append(q.channels, ch)
+	q.mu.Unlock()
+
+	if options.Transient {
+		_, err = ch.QueueDeclare(
+			queueName,
+			false, // Durable
+			true,  // Auto-delete
+			true,  // Exclusive
+			false, // No-wait
+			nil,   // Args
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to declare a queue: %v", err)
+		}
+	} else if err := q.declare(ch, queueName); err != nil {
+		return nil, err
+	}
+
+	if err := ch.Qos(options.Prefetch, 0, false); err != nil {
+		return nil, fmt.Errorf("failed to set prefetch: %v", err)
+	}
+
+	hostname, _ := os.Hostname()
+	consumerTag := fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), consumerCount.Add(1))
+	deliveries, err := ch.Consume(
+		queueName,
+		consumerTag,
+		false, // Auto-ack
+		options.Transient,
+		false, // No-local
+		false, // No-wait
+		nil,   // Args
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume: %v", err)
+	}
+
+	messages := make(chan Message)
+	go func() {
+		defer close(messages)
+		for {
+			select {
+			case <-ctx.Done():
+				requeuePrefetched(ch, consumerTag, deliveries)
+				return
+			case delivery, ok := <-deliveries:
+				if !ok {
+					return
+				}
+				select {
+				case messages <- Message{
+					Body:        delivery.Body,
+					ContentType: delivery.ContentType,
+					Headers:     delivery.Headers,
+					Priority:    delivery.Priority,
+					tag:         delivery.DeliveryTag,
+					source:      delivery,
+				}:
+				case <-ctx.Done():
+					if err := delivery.Nack(false, true); err != nil {
+						log.Printf("[Queue] Failed to requeue message: %v", err)
+					}
+					requeuePrefetched(ch, consumerTag, deliveries)
+					return
+				}
+			}
+		}
+	}()
+	return messages, nil
+}
+
+// Stop deliveries of a channel and requeue the messages prefetched but not received
+func requeuePrefetched(ch *amqp.Channel, consumerTag string, deliveries <-chan amqp.Delivery) {
+	if err := ch.Cancel(consumerTag, false); err != nil {
+		// The broker requeues them when the channel closes
+		log.Printf("[Queue] Failed to cancel consumer: %v", err)
+		return
+	}
+	// Closed by the broker once the cancellation is confirmed
+	for delivery := range deliveries {
+		if err := delivery.Nack(false, true); err != nil {
+			log.Printf("[Queue] Failed to requeue message: %v", err)
+		}
+	}
+}
+
+// Delivery of a message received from RabbitMQ
+func amqpDelivery(msg Message) (amqp.Delivery, error) {
+	delivery, ok := msg.source.(amqp.Delivery)
+	if !ok {
+		return delivery, errors.New("message was not delivered by RabbitMQ")
+	}
+	return delivery, nil
+}
+
+func (q *amqpQueue) Ack(msg Message) error {
+	delivery, err := amqpDelivery(msg)
+	if err != nil {
+		return err
+	}
+	return delivery.Ack(false)
+}
+
+func (q *amqpQueue) Nack(msg Message, requeue bool) error {
+	delivery, err := amqpDelivery(msg)
+	if err != nil {
+		return err
+	}
+	return delivery.Nack(false, requeue)
+}
+
+func (q *amqpQueue) Depth(queueName string) (int, error) {
+	if err := q.declare(q.ch, queueName); err != nil {
+		return 0, err
+	}
+	state, err := q.ch.QueueDeclarePassive(queueName, true, false, false, false, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect queue: %v", err)
+	}
+	return state.Messages, nil
+}
+
+func (q *amqpQueue) Get(queueName string) (Message, bool, error) {
+	if err := q.declare(q.ch, queueName); err != nil {
+		return Message{}, false, err
+	}
+	delivery, ok, err := q.ch.Get(queueName, false)
+	if err != nil {
+		return Message{}, false, fmt.Errorf("failed to get message: %v", err)
+	}
+	if !ok {
+		return Message{}, false, nil
+	}
+	return Message{
+		Body:        delivery.Body,
+		ContentType: delivery.ContentType,
+		Headers:     delivery.Headers,
+		Priority:    delivery.Priority,
+		tag:         delivery.DeliveryTag,
+		source:      delivery,
+	}, true, nil
+}
+
+func (q *amqpQueue) Purge(queueName string) (int, error) {
+	if err := q.declare(q.ch, queueName); err != nil {
+		return 0, err
+	}
+	purged, err := q.ch.QueuePurge(queueName, false)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge queue: %v", err)
+	}
+	return purged, nil
+}
+
+func (q *amqpQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, ch := range q.channels {
+		ch.Close()
+	}
+	q.ch.Close()
+	return q.conn.Close()
+}
@@ -0,0 +1,219 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldDeadLetter(t *testing.T) {
+	os.Setenv("MAX_DELIVERY_COUNT", "3")
+	defer os.Unsetenv("MAX_DELIVERY_COUNT")
+
+	transient := errors.New("database unavailable")
+	permanent := Permanent(errors.New("invalid character"))
+
+	// Transient failures are retried up to the max delivery count
+	assert.False(t, shouldDeadLetter(transient, 1))
+	assert.False(t, shouldDeadLetter(transient, 2))
+	assert.True(t, shouldDeadLetter(transient, 3))
+
+	// Permanent failures are never retried, even when wrapped
+	assert.True(t, shouldDeadLetter(permanent, 1))
+	assert.True(t, shouldDeadLetter(fmt.Errorf("processTask: %w", permanent), 1))
+	assert.Equal(t, "invalid character", permanent.Error())
+}
+
+func TestFailedMessage(t *testing.T) {
+	headers := amqp.Table{RetryCountHeader: int32(1), "trace": "abc"}
+
+	msg := failedMessage(Message{Headers: headers, ContentType: "application/json", Body: []byte("{}"), Priority: 2}, errors.New("boom"))
+	assert.Equal(t, 2, RetryCount(msg.Headers))
+	assert.Equal(t, "boom", msg.Headers[LastErrorHeader])
+	assert.Equal(t, "abc", msg.Headers["trace"])
+	assert.Equal(t, "application/json", msg.ContentType)
+	assert.Equal(t, uint8(2), msg.Priority, "retries keep their priority")
+
+	// Original headers are untouched
+	assert.Equal(t, 1, RetryCount(headers))
+	assert.Equal(t, 0, RetryCount(nil))
+}
+
+func TestMaxDeliveryCount(t *testing.T) {
+	os.Unsetenv("MAX_DELIVERY_COUNT")
+	assert.Equal(t, 3, MaxDeliveryCount())
+
+	os.Setenv("MAX_DELIVERY_COUNT", "5")
+	defer os.Unsetenv("MAX_DELIVERY_COUNT")
+	assert.Equal(t, 5, MaxDeliveryCount())
+	assert.Equal(t, "task_queue.dead", DeadLetterQueueName("task_queue"))
+}
+
+func TestConsume_RetryThenDeadLetter(t *testing.T) {
+	os.Setenv("MAX_DELIVERY_COUNT", "2")
+	defer os.Unsetenv("MAX_DELIVERY_COUNT")
+
+	q := NewMemoryQueue()
+	assert.NoError(t, q.Publish(context.Background(), "task_queue", Message{Body: []byte("ok")}))
+	assert.NoError(t, q.Publish(context.Background(), "task_queue", Message{Body: []byte("fail")}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	processed := make(chan string, 10)
+	done := make(chan error)
+	go func() {
+		config := ConsumerConfig{QueueName: "task_queue", Prefetch: 2, ShutdownTimeout: time.Second}
+		done <- consume(ctx, q, config, func(ctx context.Context, body []byte) error {
+			processed <- string(body)
+			if string(body) == "fail" {
+				return errors.New("boom")
+			}
+			return nil
+		})
+	}()
+
+	// Delivered once, then retried once before being dead-lettered
+	received := []string{<-processed, <-processed, <-processed}
+	assert.ElementsMatch(t, []string{"ok", "fail", "fail"}, received)
+	assert.Eventually(t, func() bool {
+		depth, _ := q.Depth(DeadLetterQueueName("task_queue"))
+		return depth == 1
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+	depth, err := q.Depth("task_queue")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, depth)
+
+	// Dead letter keeps its retry headers
+	letters, err := q.Consume(context.Background(), DeadLetterQueueName("task_queue"), ConsumeOptions{})
+	assert.NoError(t, err)
+	letter := <-letters
+	assert.Equal(t, "fail", string(letter.Body))
+	assert.Equal(t, 2, RetryCount(letter.Headers))
+	assert.Equal(t, "boom", letter.Headers[LastErrorHeader])
+}
+
+func TestPublishDeadLetter(t *testing.T) {
+	q := NewMemoryQueue()
+	assert.NoError(t, PublishDeadLetter(context.Background(), q, "task_queue", []byte("{"), Permanent(errors.New("invalid"))))
+
+	depth, err := q.Depth("task_queue.dead")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, depth)
+}
+
+func TestConsume_Deferred(t *testing.T) {
+	defer func(delay time.Duration) { deferDelay = delay }(deferDelay)
+	deferDelay = 10 * time.Millisecond
+
+	q := NewMemoryQueue()
+	assert.NoError(t, q.Publish(context.Background(), "task_queue", Message{Body: []byte("wait"), Priority: 2}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	deliveries := make(chan Message, 10)
+	done := make(chan error)
+	go func() {
+		config := ConsumerConfig{QueueName: "task_queue", Prefetch: 1, ShutdownTimeout: time.Second}
+		attempt := 0
+		done <- consume(ctx, q, config, func(ctx context.Context, body []byte) error {
+			attempt++
+			if attempt <= MaxDeliveryCount() {
+				return Deferred(errors.New("no concurrency slot"))
+			}
+			deliveries <- Message{Body: body}
+			return nil
+		})
+	}()
+
+	// Deferrals are not retries, the message is never dead-lettered
+	assert.Equal(t, "wait", string((<-deliveries).Body))
+	cancel()
+	assert.NoError(t, <-done)
+	depth, _ := q.Depth(DeadLetterQueueName("task_queue"))
+	assert.Equal(t, 0, depth)
+	assert.True(t, IsDeferred(fmt.Errorf("processTask: %w", Deferred(errors.New("busy")))))
+}
+
+func TestConsume_DeferredFreesPrefetch(t *testing.T) {
+	defer func(delay time.Duration) { deferDelay = delay }(deferDelay)
+	deferDelay = time.Hour
+
+	q := NewMemoryQueue()
+	assert.NoError(t, q.Publish(context.Background(), "task_queue", Message{Body: []byte("wait")}))
+	assert.NoError(t, q.Publish(context.Background(), "task_queue", Message{Body: []byte("ready")}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	processed := make(chan string, 10)
+	done := make(chan error)
+	go func() {
+		config := ConsumerConfig{QueueName: "task_queue", Prefetch: 1, ShutdownTimeout: time.Second}
+		done <- consume(ctx, q, config, func(ctx context.Context, body []byte) error {
+			if string(body) == "wait" {
+				return Deferred(errors.New("no concurrency slot"))
+			}
+			processed <- string(body)
+			return nil
+		})
+	}()
+
+	// The deferred message is held by the backend, not by the only prefetch slot
+	select {
+	case body := <-processed:
+		assert.Equal(t, "ready", body)
+	case <-time.After(time.Second):
+		t.Fatal("deferred message held the prefetch slot")
+	}
+	cancel()
+	assert.NoError(t, <-done)
+	depth, _ := q.Depth("task_queue")
+	assert.Equal(t, 0, depth)
+}
+
+func TestConsume_InterruptAfterShutdownTimeout(t *testing.T) {
+	q := NewMemoryQueue()
+	assert.NoError(t, q.Publish(context.Background(), "task_queue", Message{Body: []byte("long")}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		config := ConsumerConfig{QueueName: "task_queue", Prefetch: 1, ShutdownTimeout: 10 * time.Millisecond}
+		done <- consume(ctx, q, config, func(ctx context.Context, body []byte) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+
+	// Interrupted past the shutdown timeout, requeued without counting as a retry
+	<-started
+	cancel()
+	assert.NoError(t, <-done)
+	msg, ok, err := q.Get("task_queue")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "long", string(msg.Body))
+	assert.Equal(t, 0, RetryCount(msg.Headers))
+}
+
+func TestJobConsumerConfig(t *testing.T) {
+	t.Setenv("TASK_QUEUE", "")
+	t.Setenv("JOB_QUEUE_NAME", "")
+	assert.Equal(t, "job_queue", JobConsumerConfig(1, time.Second).QueueName)
+
+	// Input queue of an executor pod, set by the operator
+	t.Setenv("TASK_QUEUE", "priority_job_queue")
+	assert.Equal(t, "priority_job_queue", JobConsumerConfig(1, time.Second).QueueName)
+
+	// A worker and an executor sharing their environment
+	t.Setenv("JOB_QUEUE_NAME", "job_queue")
+	assert.Equal(t, "job_queue", JobConsumerConfig(1, time.Second).QueueName)
+	assert.Equal(t, "priority_job_queue", TaskConsumerConfig(1, time.Second).QueueName)
+}
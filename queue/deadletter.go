@@ -0,0 +1,153 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Headers carried by retried and dead-lettered messages
+const (
+	RetryCountHeader = "x-retry-count" // Number of failed deliveries
+	LastErrorHeader  = "x-last-error"  // Error of the last failed delivery
+)
+
+// Exchange receiving the rejected messages of every queue, routed by queue name
+func deadLetterExchangeName() string {
+	exchange := os.Getenv("DEAD_LETTER_EXCHANGE")
+	if exchange == "" {
+		exchange = "dead_letters"
+	}
+	return exchange
+}
+
+// Suffix of the queue holding the dead messages of a queue
+const deadLetterQueueSuffix = ".dead"
+
+// Name of the queue holding the dead messages of a queue
+func DeadLetterQueueName(queueName string) string {
+	return queueName + deadLetterQueueSuffix
+}
+
+const deferredQueueSuffix = ".deferred"
+
+// Name of the queue holding the deferred messages of a queue until they expire back into it
+func DeferredQueueName(queueName string) string {
+	return queueName + deferredQueueSuffix
+}
+
+// Failed deliveries before a message is dead-lettered
+func MaxDeliveryCount() int {
+	count, err := strconv.Atoi(os.Getenv("MAX_DELIVERY_COUNT"))
+	if err != nil || count < 1 {
+		return 3
+	}
+	return count
+}
+
+// Highest message priority, queues are declared as priority queues up to it
+const MaxPriority = 2
+
+// Arguments of a priority queue whose rejected messages are dead-lettered, every declaration must use them
+func QueueArgs() amqp.Table {
+	return amqp.Table{
+		"x-dead-letter-exchange": deadLetterExchangeName(),
+		"x-max-priority":         int32(MaxPriority),
+	}
+}
+
+// Declares the dead-letter exchange and the dead-letter queue of a queue.
+func DeclareDeadLetterQueue(ch *amqp.Channel, queueName string) error {
+	exchange := deadLetterExchangeName()
+	err := ch.ExchangeDeclare(
+		exchange,
+		amqp.ExchangeDirect,
+		true,  // Durable
+		false, // Auto-delete
+		false, // Internal
+		false, // No-wait
+		nil,   // Args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare an exchange: %v", err)
+	}
+
+	q, err := ch.QueueDeclare(
+		DeadLetterQueueName(queueName),
+		true,  // Durable
+		false, // Auto-delete
+		false, // Exclusive
+		false, // No-wait
+		nil,   // Args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare a queue: %v", err)
+	}
+
+	// Dead-lettered messages keep the routing key they were published with
+	err = ch.QueueBind(q.Name, queueName, exchange, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to bind a queue: %v", err)
+	}
+	return nil
+}
+
+// Number of failed deliveries recorded on a message
+func RetryCount(headers amqp.Table) int {
+	switch count := headers[RetryCountHeader].(type) {
+	case int32:
+		return int(count)
+	case int64:
+		return int(count)
+	case int:
+		return count
+	default:
+		return 0
+	}
+}
+
+// Copy of a failed message with its retry headers updated
+func failedMessage(msg Message, err error) Message {
+	updated := map[string]interface{}{}
+	for key, value := range msg.Headers {
+		updated[key] = value
+	}
+	updated[RetryCountHeader] = int32(RetryCount(msg.Headers) + 1)
+	updated[LastErrorHeader] = err.Error()
+
+	return Message{
+		Headers:     updated,
+		ContentType: msg.ContentType,
+		Body:        msg.Body,
+		Priority:    msg.Priority,
+	}
+}
+
+// A failed message is dead-lettered once permanent or out of retries
+func shouldDeadLetter(err error, retryCount int) bool {
+	return isPermanent(err) || retryCount >= MaxDeliveryCount()
+}
+
+/*
+Publish a failed message to the dead-letter queue of a queue.
+Used when the message was acked before processing, such as `--input` mode.
+*/
+func PublishDeadLetter(ctx context.Context, publisher Publisher, queueName string, body []byte, err error) error {
+	publishErr := publisher.Publish(ctx, DeadLetterQueueName(queueName), failedMessage(Message{ContentType: "application/json", Body: body}, err))
+	if publishErr != nil {
+		return fmt.Errorf("failed to publish dead letter: %v", publishErr)
+	}
+	return nil
+}
+
+// Dead-letters a failed message of a consumed queue
+func DeadLetterInput(config ConsumerConfig, body []byte, err error) error {
+	publisher, openErr := OpenPublisher(config.Url)
+	if openErr != nil {
+		return openErr
+	}
+	return PublishDeadLetter(context.Background(), publisher, config.QueueName, body, err)
+}
@@ -0,0 +1,242 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// In-process queue backend, messages are lost when the process exits
+type memoryQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond // Signaled on every publish, settlement and cancellation
+	queues  map[string][]Message
+	active  map[string]int // Number of consumers of transient queues
+	unacked map[uint64]unackedMessage
+	nextTag uint64
+}
+
+// Delivered message waiting for Ack or Nack
+type unackedMessage struct {
+	queueName string
+	msg       Message
+	consumer  *memoryConsumer
+}
+
+type memoryConsumer struct {
+	inFlight int
+	prefetch int
+}
+
+// Backend shared by every component of the process with QUEUE_BACKEND=memory
+var sharedMemoryQueue = NewMemoryQueue()
+
+// Creates an empty in-process queue backend
+func NewMemoryQueue() Queue {
+	q := &memoryQueue{
+		queues:  make(map[string][]Message),
+		active:  make(map[string]int),
+		unacked: make(map[uint64]unackedMessage),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *memoryQueue) Publish(ctx context.Context, queueName string, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Like RabbitMQ, messages to a transient queue without consumer are dropped
+	if isTransientQueue(queueName) && q.active[queueName] == 0 {
+		return nil
+	}
+	if msg.delay > 0 {
+		delay := msg.delay
+		msg.delay = 0
+		time.AfterFunc(delay, func() {
+			_ = q.Publish(context.Background(), queueName, msg)
+		})
+		return nil
+	}
+	msg.tag, msg.source = 0, nil
+	q.queues[queueName] = enqueue(q.queues[queueName], msg, false)
+	q.cond.Broadcast()
+	return nil
+}
+
+/*
+Insert a message behind the messages of higher priority, like a RabbitMQ priority queue.
+Published messages also go behind those of the same priority, requeued ones in front of them.
+*/
+func enqueue(ready []Message, msg Message, requeue bool) []Message {
+	position := len(ready)
+	for i, queued := range ready {
+		if queued.Priority < msg.Priority || (requeue && queued.Priority == msg.Priority) {
+			position = i
+			break
+		}
+	}
+	ready = append(ready, Message{})
+	copy(ready[position+1:], ready[position:])
+	ready[position] = msg
+	return ready
+}
+
+func (q *memoryQueue) Consume(ctx context.Context, queueName string, options ConsumeOptions) (<-chan Message, error) {
+	q.mu.Lock()
+	if options.Transient {
+		q.active[queueName]++
+	}
+	q.mu.Unlock()
+
+	// Wake up the consumer when canceled
+	stop := context.AfterFunc(ctx, func() {
+		q.mu.Lock()
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	})
+
+	consumer := &memoryConsumer{prefetch: options.Prefetch}
+	messages := make(chan Message)
+	go func() {
+		defer close(messages)
+		defer stop()
+		for {
+			msg, ok := q.next(ctx, queueName, consumer)
+			if !ok {
+				q.release(queueName, options.Transient)
+				return
+			}
+			select {
+			case messages <- msg:
+			case <-ctx.Done():
+				q.settle(msg, true)
+				q.release(queueName, options.Transient)
+				return
+			}
+		}
+	}()
+	return messages, nil
+}
+
+// Wait for the next message of a queue, within the prefetch of the consumer
+func (q *memoryQueue) next(ctx context.Context, queueName string, consumer *memoryConsumer) (Message, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		if ctx.Err() != nil {
+			return Message{}, false
+		}
+		full := consumer.prefetch > 0 && consumer.inFlight >= consumer.prefetch
+		if ready := q.queues[queueName]; len(ready) > 0 && !full {
+			msg := ready[0]
+			q.queues[queueName] = ready[1:]
+			q.nextTag++
+			msg.tag = q.nextTag
+			q.unacked[msg.tag] = unackedMessage{queueName: queueName, msg: msg, consumer: consumer}
+			consumer.inFlight++
+			return msg, true
+		}
+		q.cond.Wait()
+	}
+}
+
+// Remove a transient queue once its last consumer stopped
+func (q *memoryQueue) release(queueName string, transient bool) {
+	if !transient {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.active[queueName]--
+	if q.active[queueName] <= 0 {
+		delete(q.active, queueName)
+		delete(q.queues, queueName)
+	}
+}
+
+/*
+Settle a delivered message.
+Requeued messages go back to the front of their queue, rejected ones to its dead-letter queue.
+*/
+func (q *memoryQueue) settle(msg Message, requeue bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	unacked, ok := q.unacked[msg.tag]
+	if !ok {
+		return errors.New("unknown or already settled message")
+	}
+	delete(q.unacked, msg.tag)
+	unacked.consumer.inFlight--
+
+	queueName := unacked.queueName
+	delivered := unacked.msg
+	delivered.tag = 0
+	switch {
+	case requeue:
+		q.queues[queueName] = enqueue(q.queues[queueName], delivered, true)
+	case isTransientQueue(queueName) || strings.HasSuffix(queueName, deadLetterQueueSuffix):
+		// Not dead-lettered, like queues declared without dead-letter exchange
+	default:
+		deadLetterQueue := DeadLetterQueueName(queueName)
+		q.queues[deadLetterQueue] = enqueue(q.queues[deadLetterQueue], delivered, false)
+	}
+	q.cond.Broadcast()
+	return nil
+}
+
+func (q *memoryQueue) Ack(msg Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	unacked, ok := q.unacked[msg.tag]
+	if !ok {
+		return errors.New("unknown or already settled message")
+	}
+	delete(q.unacked, msg.tag)
+	unacked.consumer.inFlight--
+	q.cond.Broadcast()
+	return nil
+}
+
+func (q *memoryQueue) Nack(msg Message, requeue bool) error {
+	return q.settle(msg, requeue)
+}
+
+func (q *memoryQueue) Depth(queueName string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.queues[queueName]), nil
+}
+
+func (q *memoryQueue) Get(queueName string) (Message, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ready := q.queues[queueName]
+	if len(ready) == 0 {
+		return Message{}, false, nil
+	}
+	msg := ready[0]
+	q.queues[queueName] = ready[1:]
+	q.nextTag++
+	msg.tag = q.nextTag
+	q.unacked[msg.tag] = unackedMessage{queueName: queueName, msg: msg, consumer: &memoryConsumer{inFlight: 1}}
+	return msg, true, nil
+}
+
+func (q *memoryQueue) Purge(queueName string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	purged := len(q.queues[queueName])
+	delete(q.queues, queueName)
+	return purged, nil
+}
+
+// Messages outlive the components using the backend, nothing to release
+func (q *memoryQueue) Close() error {
+	return nil
+}
@@ -0,0 +1,171 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Next message of a channel, fails the test after a second
+func receive(t *testing.T, messages <-chan Message) Message {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+		return Message{}
+	}
+}
+
+func TestMemoryQueue_PublishConsumeAck(t *testing.T) {
+	q := NewMemoryQueue()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.NoError(t, q.Publish(ctx, "jobs", Message{Body: []byte("1"), ContentType: "application/json"}))
+	assert.NoError(t, q.Publish(ctx, "jobs", Message{Body: []byte("2")}))
+	depth, err := q.Depth("jobs")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, depth)
+
+	messages, err := q.Consume(ctx, "jobs", ConsumeOptions{})
+	assert.NoError(t, err)
+	first := receive(t, messages)
+	second := receive(t, messages)
+	assert.Equal(t, "1", string(first.Body))
+	assert.Equal(t, "application/json", first.ContentType)
+	assert.Equal(t, "2", string(second.Body))
+
+	assert.NoError(t, q.Ack(first))
+	assert.Error(t, q.Ack(first), "a message is settled once")
+	assert.Error(t, q.Ack(Message{Body: []byte("never delivered")}))
+	assert.NoError(t, q.Ack(second))
+
+	depth, _ = q.Depth("jobs")
+	assert.Equal(t, 0, depth)
+}
+
+func TestMemoryQueue_Nack(t *testing.T) {
+	q := NewMemoryQueue()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	q.Publish(ctx, "jobs", Message{Body: []byte("1")})
+	q.Publish(ctx, "jobs", Message{Body: []byte("2")})
+	messages, _ := q.Consume(ctx, "jobs", ConsumeOptions{Prefetch: 1})
+
+	// Requeued messages are delivered again first
+	msg := receive(t, messages)
+	assert.NoError(t, q.Nack(msg, true))
+	msg = receive(t, messages)
+	assert.Equal(t, "1", string(msg.Body))
+
+	// Rejected messages are dead-lettered
+	assert.NoError(t, q.Nack(msg, false))
+	msg = receive(t, messages)
+	assert.Equal(t, "2", string(msg.Body))
+	depth, _ := q.Depth("jobs.dead")
+	assert.Equal(t, 1, depth)
+}
+
+func TestMemoryQueue_Prefetch(t *testing.T) {
+	q := NewMemoryQueue()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	for _, body := range []string{"1", "2", "3"} {
+		q.Publish(ctx, "jobs", Message{Body: []byte(body)})
+	}
+	messages, _ := q.Consume(ctx, "jobs", ConsumeOptions{Prefetch: 1})
+	first := receive(t, messages)
+
+	// Nothing more is delivered until the message is settled
+	select {
+	case <-messages:
+		t.Fatal("message delivered beyond the prefetch")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.NoError(t, q.Ack(first))
+	second := receive(t, messages)
+	assert.Equal(t, "2", string(second.Body))
+
+	// Messages not received are requeued on cancellation
+	cancel()
+	for range messages {
+	}
+	depth, _ := q.Depth("jobs")
+	assert.Equal(t, 1, depth)
+	assert.NoError(t, q.Nack(second, true))
+	depth, _ = q.Depth("jobs")
+	assert.Equal(t, 2, depth)
+}
+
+func TestMemoryQueue_TransientQueue(t *testing.T) {
+	q := NewMemoryQueue()
+	name := JobEventsQueueName(7)
+
+	// Dropped without subscriber
+	assert.NoError(t, q.Publish(context.Background(), name, Message{Body: []byte("lost")}))
+	depth, _ := q.Depth(name)
+	assert.Equal(t, 0, depth)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := SubscribeJobEvents(ctx, q, 7)
+	assert.NoError(t, err)
+	assert.NoError(t, q.Publish(context.Background(), name, Message{Body: []byte(`{"jobId": 3, "status": "SUCCESS"}`)}))
+
+	msg := receive(t, events)
+	event, err := ParseJobEvent(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, event.JobId)
+	assert.NoError(t, q.Ack(msg))
+
+	// Deleted with its subscriber
+	q.Publish(context.Background(), name, Message{Body: []byte("pending")})
+	cancel()
+	for range events {
+	}
+	depth, _ = q.Depth(name)
+	assert.Equal(t, 0, depth)
+}
+
+func TestOpen(t *testing.T) {
+	t.Setenv("QUEUE_BACKEND", "memory")
+	first, err := Open("")
+	assert.NoError(t, err)
+	second, err := Open("")
+	assert.NoError(t, err)
+	assert.Same(t, first, second, "components of a process share the memory backend")
+
+	t.Setenv("QUEUE_BACKEND", "kafka")
+	_, err = Open("")
+	assert.Error(t, err)
+}
+
+func TestMemoryQueue_Priority(t *testing.T) {
+	q := NewMemoryQueue()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	q.Publish(ctx, "tasks", Message{Body: []byte("nightly"), Priority: MessagePriority("low")})
+	q.Publish(ctx, "tasks", Message{Body: []byte("review"), Priority: MessagePriority("")})
+	q.Publish(ctx, "tasks", Message{Body: []byte("deploy"), Priority: MessagePriority("high")})
+	q.Publish(ctx, "tasks", Message{Body: []byte("test"), Priority: MessagePriority("normal")})
+
+	messages, _ := q.Consume(ctx, "tasks", ConsumeOptions{Prefetch: 1})
+	var order []string
+	for range 4 {
+		msg := receive(t, messages)
+		order = append(order, string(msg.Body))
+		if string(msg.Body) == "deploy" && len(order) == 1 {
+			// Requeued messages are delivered again first
+			assert.NoError(t, q.Nack(msg, true))
+			msg = receive(t, messages)
+			assert.Equal(t, "deploy", string(msg.Body))
+		}
+		q.Ack(msg)
+	}
+	assert.Equal(t, []string{"deploy", "review", "test", "nightly"}, order)
+}
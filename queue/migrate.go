@@ -0,0 +1,140 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Suffix of the queue holding the messages of a queue while it is re-created
+const migrationQueueSuffix = ".migrating"
+
+/*
+Re-creates a durable queue declared with other arguments than QueueArgs, e.g. by a release before dead-lettering,
+which RabbitMQ refuses to declare again with PRECONDITION_FAILED. Arguments of a queue cannot be changed in place.
+Its messages are moved to a temporary queue and back with publisher confirms, a migration interrupted halfway
+resumes when run again. Publishers and consumers of the queue must be stopped meanwhile.
+Returns the number of messages moved back to the queue.
+*/
+func MigrateQueue(url, queueName string) (int, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to RabbitMQ: %v", err)
+	}
+	defer conn.Close()
+
+	current, err := hasQueueArgs(conn, queueName)
+	if err != nil {
+		return 0, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open a channel: %v", err)
+	}
+	defer ch.Close()
+	if err := ch.Confirm(false); err != nil {
+		return 0, fmt.Errorf("failed to enable publisher confirms: %v", err)
+	}
+
+	migrationQueue := queueName + migrationQueueSuffix
+	if _, err := ch.QueueDeclare(migrationQueue, true, false, false, false, nil); err != nil {
+		return 0, fmt.Errorf("failed to declare a queue: %v", err)
+	}
+
+	if !current {
+		if _, err := moveMessages(ch, queueName, migrationQueue); err != nil {
+			return 0, err
+		}
+		// A message published meanwhile fails the deletion instead of being lost
+		if _, err := ch.QueueDelete(queueName, false, true, false); err != nil {
+			return 0, fmt.Errorf("failed to delete queue `%s`, stop its publishers and run again: %v", queueName, err)
+		}
+	}
+
+	if err := declareQueue(ch, queueName); err != nil {
+		return 0, err
+	}
+	moved, err := moveMessages(ch, migrationQueue, queueName)
+	if err != nil {
+		return moved, err
+	}
+	if _, err := ch.QueueDelete(migrationQueue, false, true, false); err != nil {
+		return moved, fmt.Errorf("failed to delete queue `%s`: %v", migrationQueue, err)
+	}
+	return moved, nil
+}
+
+// Whether a queue is declared with QueueArgs, or missing. Probed on its own channel, a mismatch closes it.
+func hasQueueArgs(conn *amqp.Connection, queueName string) (bool, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return false, fmt.Errorf("failed to open a channel: %v", err)
+	}
+	defer ch.Close()
+
+	_, err = ch.QueueDeclare(queueName, true, false, false, false, QueueArgs())
+	if IsArgumentsMismatch(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to declare a queue: %v", err)
+	}
+	return true, nil
+}
+
+// Whether a declaration failed on a queue declared with other arguments
+func IsArgumentsMismatch(err error) bool {
+	var amqpErr *amqp.Error
+	return errors.As(err, &amqpErr) && amqpErr.Code == amqp.PreconditionFailed
+}
+
+// Moves every message of a queue to another one, each is acked once the broker confirmed its copy
+func moveMessages(ch *amqp.Channel, from, to string) (int, error) {
+	moved := 0
+	for {
+		msg, ok, err := ch.Get(from, false)
+		if err != nil {
+			return moved, fmt.Errorf("failed to get a message from `%s`: %v", from, err)
+		}
+		if !ok {
+			return moved, nil
+		}
+
+		if err := publishConfirmed(ch, to, msg); err != nil {
+			return moved, err
+		}
+		if err := msg.Ack(false); err != nil {
+			return moved, fmt.Errorf("failed to ack message: %v", err)
+		}
+		moved++
+	}
+}
+
+// Publishes a copy of a message, keeping its headers, priority and publication time
+func publishConfirmed(ch *amqp.Channel, queueName string, msg amqp.Delivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", queueName, false, false, amqp.Publishing{
+		Headers:      msg.Headers,
+		DeliveryMode: amqp.Persistent,
+		ContentType:  msg.ContentType,
+		Body:         msg.Body,
+		Priority:     msg.Priority,
+		Timestamp:    msg.Timestamp,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %v", err)
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to confirm message: %v", err)
+	}
+	if !acked {
+		return errNotConfirmed
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	// Idle channels kept open per broker, extra channels are closed once released
+	publisherPoolSize = 8
+	// Time given to the broker to confirm a message
+	publishTimeout = 10 * time.Second
+	// Publications of a message over a fresh channel when the previous one failed
+	publishAttempts = 3
+)
+
+// Returned when the broker rejected a message, it is not retried
+var errNotConfirmed = errors.New("message was not confirmed by the broker")
+
+// Publishes messages, safe for concurrent use
+type Publisher interface {
+	// Publish a message and wait for the broker to confirm it, durable queues are created on first use
+	Publish(ctx context.Context, queueName string, msg Message) error
+}
+
+/*
+Long-lived RabbitMQ publisher shared by the process.
+Publishes over a pool of channels in confirm mode and reconnects on the next message
+once the connection is lost.
+*/
+type amqpPublisher struct {
+	url      string
+	mu       sync.Mutex
+	conn     *amqp.Connection
+	idle     []*amqp.Channel
+	declared map[string]bool // Queues declared on the current connection
+}
+
+var (
+	publishersMu sync.Mutex
+	publishers   = make(map[string]*amqpPublisher)
+)
+
+// Publisher of a broker, shared by every caller of the process
+func sharedPublisher(url string) *amqpPublisher {
+	publishersMu.Lock()
+	defer publishersMu.Unlock()
+	publisher, ok := publishers[url]
+	if !ok {
+		publisher = &amqpPublisher{url: url, declared: make(map[string]bool)}
+		publishers[url] = publisher
+	}
+	return publisher
+}
+
+// Publisher of the backend selected by QUEUE_BACKEND, connections are reused across calls
+func OpenPublisher(url string) (Publisher, error) {
+	switch backend := os.Getenv("QUEUE_BACKEND"); backend {
+	case "", "amqp":
+		return sharedPublisher(url), nil
+	case "memory":
+		return sharedMemoryQueue, nil
+	default:
+		return nil, fmt.Errorf("unknown queue backend `%s`, expected `amqp` or `memory`", backend)
+	}
+}
+
+// Take an idle channel, or open one in confirm mode, reconnecting when needed
+func (p *amqpPublisher) acquire() (*amqp.Channel, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil || p.conn.IsClosed() {
+		if p.conn != nil {
+			log.Printf("[Queue] Connection to the broker lost, reconnecting")
+		}
+		conn, err := amqp.Dial(p.url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to RabbitMQ: %v", err)
+		}
+		p.conn = conn
+		p.idle = nil
+		p.declared = make(map[string]bool)
+	}
+
+	for len(p.idle) > 0 {
+		ch := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if !ch.IsClosed() {
+			return ch, nil
+		}
+	}
+
+	ch, err := p.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel: %v", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %v", err)
+	}
+	return ch, nil
+}
+
+// Give a channel back to the pool
+func (p *amqpPublisher) release(ch *amqp.Channel) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ch.IsClosed() {
+		return
+	}
+	if len(p.idle) >= publisherPoolSize {
+		ch.Close()
+		return
+	}
+	p.idle = append(p.idle, ch)
+}
+
+// Declare a durable queue once per connection, transient queues are declared by their consumer
+func (p *amqpPublisher) declare(ch *amqp.Channel, queueName string) error {
+	if isTransientQueue(queueName) {
+		return nil
+	}
+	p.mu.Lock()
+	declared := p.declared[queueName]
+	p.mu.Unlock()
+	if declared {
+		return nil
+	}
+
+	if err := declareQueue(ch, queueName); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.declared[queueName] = true
+	p.mu.Unlock()
+	return nil
+}
+
+/*
+Publish a message and wait for its confirmation.
+A message whose channel or connection failed is published again on a fresh channel,
+consumers already deduplicate redelivered messages by execution id.
+*/
+func (p *amqpPublisher) Publish(ctx context.Context, queueName string, msg Message) error {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	var err error
+	for attempt := 1; attempt <= publishAttempts; attempt++ {
+		err = p.publish(ctx, queueName, msg)
+		if err == nil || errors.Is(err, errNotConfirmed) || ctx.Err() != nil {
+			return err
+		}
+		log.Printf("[Queue] Publish attempt %d to `%s` failed: %v", attempt, queueName, err)
+	}
+	return err
+}
+
+func (p *amqpPublisher) publish(ctx context.Context, queueName string, msg Message) error {
+	// Delayed messages wait in the deferred queue until they expire
+	expiration := ""
+	if msg.delay > 0 {
+		queueName = DeferredQueueName(queueName)
+		expiration = strconv.FormatInt(max(msg.delay.Milliseconds(), 1), 10)
+	}
+
+	ch, err := p.acquire()
+	if err != nil {
+		return err
+	}
+	if err := p.declare(ch, queueName); err != nil {
+		ch.Close()
+		return err
+	}
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(
+		ctx,
+		"",
+		queueName,
+		false,
+		false,
+		amqp.Publishing{
+			Headers:      amqp.Table(msg.Headers),
+			DeliveryMode: amqp.Persistent,
+			ContentType:  msg.ContentType,
+			Body:         msg.Body,
+			Priority:     msg.Priority,
+			Expiration:   expiration,
+			Timestamp:    time.Now(),
+		},
+	)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to publish message: %v", err)
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		// The confirmation may still arrive, the channel is not reused
+		ch.Close()
+		return fmt.Errorf("failed to confirm message: %v", err)
+	}
+	if !acked {
+		// Pending confirmations are nacked when the channel closes
+		if ch.IsClosed() {
+			return errors.New("channel closed before the message was confirmed")
+		}
+		p.release(ch)
+		return errNotConfirmed
+	}
+	p.release(ch)
+	return nil
+}
@@ -0,0 +1,78 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Message published to or delivered from a queue
+type Message struct {
+	Body        []byte
+	ContentType string
+	Headers     map[string]interface{}
+	Priority    uint8 // From 0 to MaxPriority, higher priorities are delivered first
+
+	delay time.Duration // Held by the backend before it enters its queue, see amqpPublisher.publish and memoryQueue.Publish
+
+	tag    uint64      // Delivery tag, set on delivered messages
+	source interface{} // Backend specific delivery, used to settle the message
+}
+
+// Message priority of a pipeline priority: `high`, `normal` or `low`, normal when not set
+func MessagePriority(priority string) uint8 {
+	switch priority {
+	case "high":
+		return MaxPriority
+	case "low":
+		return 0
+	default:
+		return 1
+	}
+}
+
+// Consumption options of a queue
+type ConsumeOptions struct {
+	Prefetch  int  // Maximum number of unsettled messages, unlimited when 0
+	Transient bool // Queue only lives while consumed, e.g. the job events of a pipeline execution
+}
+
+/*
+Message queue backend.
+Messages of durable queues are dead-lettered to `<queue>.dead` when nacked without requeue.
+*/
+type Queue interface {
+	Publisher
+	/*
+		Deliver messages until the context is canceled, then close the channel.
+		Messages delivered but not received yet are requeued on cancellation,
+		received ones must still be settled with Ack or Nack.
+	*/
+	Consume(ctx context.Context, queueName string, options ConsumeOptions) (<-chan Message, error)
+	// Settle a delivered message as processed
+	Ack(msg Message) error
+	// Settle a delivered message as failed, requeued or dead-lettered
+	Nack(msg Message, requeue bool) error
+	// Number of messages ready for delivery
+	Depth(queueName string) (int, error)
+	// Fetch the next ready message of a queue outside of any consumer, ok is false when empty. It must be settled.
+	Get(queueName string) (msg Message, ok bool, err error)
+	// Delete the ready messages of a queue, returns their number
+	Purge(queueName string) (int, error)
+	// Release the connection, unsettled messages are requeued
+	Close() error
+}
+
+// Queue backend selected by QUEUE_BACKEND: `amqp` (default) or `memory`
+func Open(url string) (Queue, error) {
+	switch backend := os.Getenv("QUEUE_BACKEND"); backend {
+	case "", "amqp":
+		return DialAMQP(url)
+	case "memory":
+		// Shared by every component of the process
+		return sharedMemoryQueue, nil
+	default:
+		return nil, fmt.Errorf("unknown queue backend `%s`, expected `amqp` or `memory`", backend)
+	}
+}
@@ -0,0 +1,115 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+Runs a task through the backend, a worker and an executor of the same process with QUEUE_BACKEND=memory,
+each component going through the entry points it uses in production.
+*/
+func TestMemoryBackend_TaskEndToEnd(t *testing.T) {
+	t.Setenv("QUEUE_BACKEND", "memory")
+	t.Setenv("TASK_QUEUE", "wiring_task_queue")
+	t.Setenv("JOB_QUEUE_NAME", "wiring_job_queue")
+
+	type task struct {
+		Id string `json:"id"`
+	}
+	type job struct {
+		Id         string `json:"id"`
+		JobId      int    `json:"jobId"`
+		StageId    int    `json:"stageId"`
+		PipelineId int    `json:"pipelineId"`
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Executor: runs the job and notifies the worker scheduling its pipeline
+	var executed sync.WaitGroup
+	executed.Add(1)
+	go func() {
+		defer executed.Done()
+		err := Consume(ctx, JobConsumerConfig(1, time.Second), func(ctx context.Context, body []byte) error {
+			var item job
+			if err := json.Unmarshal(body, &item); err != nil {
+				return Permanent(err)
+			}
+			return PublishJobEvent(JobEvent{Id: item.Id, JobId: item.JobId, StageId: item.StageId, PipelineId: item.PipelineId, Status: "SUCCESS"})
+		})
+		assert.NoError(t, err)
+	}()
+
+	// Worker: enqueues the job of the task and waits for its event
+	statuses := make(chan string, 1)
+	executed.Add(1)
+	go func() {
+		defer executed.Done()
+		err := Consume(ctx, TaskConsumerConfig(1, time.Second), func(ctx context.Context, body []byte) error {
+			var item task
+			if err := json.Unmarshal(body, &item); err != nil {
+				return Permanent(err)
+			}
+			q, queueName, err := OpenJobQueue()
+			if err != nil {
+				return err
+			}
+			defer q.Close()
+
+			runCtx, stop := context.WithCancel(ctx)
+			defer stop()
+			events, err := SubscribeJobEvents(runCtx, q, 1)
+			if err != nil {
+				return err
+			}
+			if err := Enqueue(runCtx, q, queueName, job{Id: "job_" + item.Id, JobId: 3, StageId: 2, PipelineId: 1}, MessagePriority("")); err != nil {
+				return err
+			}
+
+			select {
+			case msg := <-events:
+				event, err := ParseJobEvent(msg)
+				if err != nil {
+					return err
+				}
+				statuses <- event.Status
+				return q.Ack(msg)
+			case <-time.After(5 * time.Second):
+				return errors.New("no job event received")
+			}
+		})
+		assert.NoError(t, err)
+	}()
+
+	// Backend: publishes the task
+	publisher, queueName, err := TaskPublisher()
+	require.NoError(t, err)
+	require.NoError(t, Enqueue(ctx, publisher, queueName, task{Id: "1"}, MessagePriority("high")))
+
+	select {
+	case status := <-statuses:
+		assert.Equal(t, "SUCCESS", status)
+	case <-time.After(10 * time.Second):
+		t.Fatal("task did not run end to end")
+	}
+	cancel()
+	executed.Wait()
+
+	// Every message was acked, nothing was retried or dead-lettered
+	q, err := Open("")
+	require.NoError(t, err)
+	for _, name := range []string{"wiring_task_queue", "wiring_job_queue", DeadLetterQueueName("wiring_task_queue"), DeadLetterQueueName("wiring_job_queue")} {
+		depth, err := q.Depth(name)
+		require.NoError(t, err)
+		assert.Zero(t, depth, name)
+	}
+}
@@ -151,3 +151,60 @@ func TestClose(t *testing.T) {
 	_, err = client.Ping(context.Background()).Result()
 	require.Error(t, err, "Should return error after connection closed")
 }
+
+func TestSlots(t *testing.T) {
+	ctx := context.Background()
+	repository := []Slot{{Key: "test_concurrency:repo", Limit: 2}}
+	group := []Slot{repository[0], {Key: "test_concurrency:repo:group:deploy", Limit: 1}}
+	defer instance.Del(ctx, repository[0].Key, group[1].Key)
+
+	acquired, err := AcquireSlots(ctx, "run1", group, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// The group is full, no slot of the repository is taken either
+	acquired, err = AcquireSlots(ctx, "run2", group, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	holders, err := SlotHolders(ctx, repository[0].Key)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"run1"}, holders)
+
+	// A holder keeps its slot, other pipelines of the repository still run
+	acquired, err = AcquireSlots(ctx, "run1", group, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, err = AcquireSlots(ctx, "run3", repository, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	require.NoError(t, RenewSlots(ctx, "run1", group, time.Minute))
+	require.NoError(t, ReleaseSlots(ctx, "run1", group))
+	acquired, err = AcquireSlots(ctx, "run2", group, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// Expired leases are freed
+	acquired, err = AcquireSlots(ctx, "run4", []Slot{{Key: "test_concurrency:expired", Limit: 1}}, time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	time.Sleep(5 * time.Millisecond)
+	acquired, err = AcquireSlots(ctx, "run5", []Slot{{Key: "test_concurrency:expired", Limit: 1}}, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	instance.Del(ctx, "test_concurrency:expired")
+}
+
+func TestRequestCancel(t *testing.T) {
+	ctx := context.Background()
+	defer instance.Del(ctx, cancelKey("test_run"))
+
+	requested, err := IsCancelRequested(ctx, "test_run")
+	require.NoError(t, err)
+	assert.False(t, requested)
+
+	require.NoError(t, RequestCancel(ctx, "test_run", time.Minute))
+	requested, err = IsCancelRequested(ctx, "test_run")
+	require.NoError(t, err)
+	assert.True(t, requested)
+}
@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+)
+
+// Priorities of the pending tasks recorded by the backend, see backend/cache/pending.go
+var pendingPriorities = []string{"high", "normal", "low"}
+
+// Sorted set of the pending tasks of a priority
+func pendingTasksKey(priority string) string {
+	return "pending_tasks:" + priority
+}
+
+// Forget a task once its processing starts, it no longer has a queue position
+func RemovePendingTask(ctx context.Context, executionId string) error {
+	for _, priority := range pendingPriorities {
+		if err := instance.ZRem(ctx, pendingTasksKey(priority), executionId).Err(); err != nil {
+			return fmt.Errorf("failed to remove pending task %s: %w", executionId, err)
+		}
+	}
+	return nil
+}
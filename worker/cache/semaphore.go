@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+/*
+Counting semaphores backed by Redis.
+Each semaphore is a sorted set of its holders scored by lease expiry,
+a holder that stops renewing its lease (e.g. a crashed worker) loses its slot once it expires.
+*/
+
+// Semaphore to acquire, unlimited when Limit <= 0
+type Slot struct {
+	Key   string
+	Limit int
+}
+
+/*
+Take a slot of every semaphore, or none of them.
+KEYS are the semaphores, ARGV the holder, the current time, the lease expiry, then the limit of every key.
+A holder already in a semaphore keeps its slot.
+*/
+var acquireScript = redis.NewScript(`
+local holder, now, expiry = ARGV[1], tonumber(ARGV[2]), tonumber(ARGV[3])
+for i, key in ipairs(KEYS) do
+	redis.call('ZREMRANGEBYSCORE', key, '-inf', now)
+	local limit = tonumber(ARGV[3 + i])
+	if limit > 0 and not redis.call('ZSCORE', key, holder) and redis.call('ZCARD', key) >= limit then
+		return 0
+	end
+end
+for _, key in ipairs(KEYS) do
+	redis.call('ZADD', key, expiry, holder)
+	redis.call('PEXPIREAT', key, expiry)
+end
+return 1
+`)
+
+// Take a slot of every semaphore for `lease`, returns false without taking any when one is full
+func AcquireSlots(ctx context.Context, holder string, slots []Slot, lease time.Duration) (bool, error) {
+	if len(slots) == 0 {
+		return true, nil
+	}
+	now := time.Now()
+	keys := make([]string, len(slots))
+	args := []interface{}{holder, now.UnixMilli(), now.Add(lease).UnixMilli()}
+	for i, slot := range slots {
+		keys[i] = slot.Key
+		args = append(args, strconv.Itoa(slot.Limit))
+	}
+
+	acquired, err := acquireScript.Run(ctx, instance, keys, args...).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire slots for %s: %w", holder, err)
+	}
+	return acquired == 1, nil
+}
+
+// Extend the lease of the slots held
+func RenewSlots(ctx context.Context, holder string, slots []Slot, lease time.Duration) error {
+	expiry := time.Now().Add(lease)
+	for _, slot := range slots {
+		member := redis.Z{Score: float64(expiry.UnixMilli()), Member: holder}
+		if err := instance.ZAddXX(ctx, slot.Key, member).Err(); err != nil {
+			return fmt.Errorf("failed to renew slot %s for %s: %w", slot.Key, holder, err)
+		}
+		if err := instance.ExpireAt(ctx, slot.Key, expiry).Err(); err != nil {
+			return fmt.Errorf("failed to renew slot %s for %s: %w", slot.Key, holder, err)
+		}
+	}
+	return nil
+}
+
+// Give the slots back
+func ReleaseSlots(ctx context.Context, holder string, slots []Slot) error {
+	for _, slot := range slots {
+		if err := instance.ZRem(ctx, slot.Key, holder).Err(); err != nil {
+			return fmt.Errorf("failed to release slot %s for %s: %w", slot.Key, holder, err)
+		}
+	}
+	return nil
+}
+
+// Holders of a semaphore whose lease did not expire
+func SlotHolders(ctx context.Context, key string) ([]string, error) {
+	min := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	holders, err := instance.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: "(" + min, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list holders of %s: %w", key, err)
+	}
+	return holders, nil
+}
+
+// Key flagging a pipeline execution to cancel
+func cancelKey(executionId string) string {
+	return "cancel:" + executionId
+}
+
+// Ask the worker running a pipeline execution to cancel it
+func RequestCancel(ctx context.Context, executionId string, expiration time.Duration) error {
+	if err := instance.Set(ctx, cancelKey(executionId), 1, expiration).Err(); err != nil {
+		return fmt.Errorf("failed to cancel %s: %w", executionId, err)
+	}
+	return nil
+}
+
+// Check if the cancellation of a pipeline execution was requested
+func IsCancelRequested(ctx context.Context, executionId string) (bool, error) {
+	count, err := instance.Exists(ctx, cancelKey(executionId)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check cancellation of %s: %w", executionId, err)
+	}
+	return count > 0, nil
+}
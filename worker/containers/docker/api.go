@@ -2,271 +2,511 @@
 package DockerService
 
 import (
+	"cicd/pipeci/queue"
 	"cicd/pipeci/worker/cache"
 	"cicd/pipeci/worker/db"
 	"cicd/pipeci/worker/models"
-	"cicd/pipeci/worker/queue"
+	"cicd/pipeci/worker/scheduler"
+	DependencyService "cicd/pipeci/worker/services/dependency"
 	JobService "cicd/pipeci/worker/services/job"
 	PipelineService "cicd/pipeci/worker/services/pipeline"
 	StageService "cicd/pipeci/worker/services/stage"
+	StatusService "cicd/pipeci/worker/services/status"
 	"cicd/pipeci/worker/types"
 	"context"
 	"errors"
 	"log"
-	"slices"
+	"os"
+	"sort"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
-/* Enqueue task into job_queue */
-func enqueue(jobExecutionId string, pipelineId, stageId, jobId int,
-	dependency map[string][]string, body types.JobExecutor_RequestBody,
-	jobService *JobService.JobService) error {
-	// Connect to RabbitMQ
-	conn, ch, err := queue.ConnectRabbitMQ()
-	if err != nil {
-		return err
+/* Match execution key-value pair, the database keeps the execution id once the key expires */
+func matchExecutionIdToPipeline(executionId string, pipelineId int) {
+	ctx := context.Background()
+	cache.Set(ctx, executionId, pipelineId, 24*time.Hour)
+}
+
+/* State shared by the stages of a pipeline execution */
+type pipelineRun struct {
+	pipelineReportId  int
+	repository        models.Repository
+	stageService      *StageService.StageService
+	jobService        *JobService.JobService
+	dependencyService *DependencyService.DependencyService
+	statusService     *StatusService.StatusService
+	queue             queue.Queue          // Job queue and job events backend
+	queueName         string               // Job queue name
+	priority          uint8                // Message priority of the jobs, from the pipeline priority
+	jobTimeout        time.Duration        // Time given to an enqueued job to report its terminal status
+	events            <-chan queue.Message // Job events of this pipeline execution
+	canceled          <-chan struct{}      // Closed once a newer run of the concurrency group canceled this one
+	interrupted       <-chan struct{}      // Closed once the worker stops waiting for this run, e.g. on shutdown
+}
+
+/* Error of a run interrupted by the worker, its task is requeued and the execution resumed on redelivery */
+var errInterrupted = errors.New("pipeline execution interrupted")
+
+/* Time given to a job to finish: the pipeline timeout, else JOB_TIMEOUT, 1 hour by default */
+func jobTimeout(pipeline models.PipelineConfiguration) time.Duration {
+	if timeout := pipeline.Timeout(); timeout > 0 {
+		return timeout
+	}
+	timeout, err := time.ParseDuration(os.Getenv("JOB_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		return time.Hour
+	}
+	return timeout
+}
+
+// Jobs whose deadline passed, sorted
+func expiredJobs(deadlines map[string]time.Time, now time.Time) []string {
+	var expired []string
+	for name, deadline := range deadlines {
+		if !now.Before(deadline) {
+			expired = append(expired, name)
+		}
+	}
+	sort.Strings(expired)
+	return expired
+}
+
+// Earliest deadline of the enqueued jobs, false without any
+func nextDeadline(deadlines map[string]time.Time) (time.Time, bool) {
+	var next time.Time
+	for _, deadline := range deadlines {
+		if next.IsZero() || deadline.Before(next) {
+			next = deadline
+		}
 	}
-	defer conn.Close()
-	defer ch.Close()
+	return next, !next.IsZero()
+}
+
+// Check if the pipeline execution was canceled, without waiting
+func (run *pipelineRun) isCanceled() bool {
+	select {
+	case <-run.canceled:
+		return true
+	default:
+		return false
+	}
+}
 
-	// Declare the queue
-	q, err := queue.DeclareQueue(ch)
+// Check if the worker stopped waiting for the pipeline execution, without waiting
+func (run *pipelineRun) isInterrupted() bool {
+	select {
+	case <-run.interrupted:
+		return true
+	default:
+		return false
+	}
+}
+
+/*
+Set a status decided by the worker on a job, e.g. past its deadline.
+A job finished by its executor meanwhile keeps the status reported by the executor, which is returned instead.
+*/
+func (run *pipelineRun) finishJob(jobReportId int, status models.ExecStatus) models.ExecStatus {
+	_, _, err := run.statusService.UpdateJobStatus(jobReportId, status)
+	if errors.Is(err, StatusService.ErrJobFinished) {
+		stored, err := run.jobService.GetJobStatus(jobReportId)
+		if err != nil {
+			log.Printf("%v\n", err)
+			return status
+		}
+		return models.ExecStatus(stored)
+	}
 	if err != nil {
-		return err
+		log.Printf("%v\n", err)
 	}
+	return status
+}
 
-	// Generate UUID as Task ID
-	queueItem := queue.QueueItem{
+/* Enqueue task into job_queue */
+func (run *pipelineRun) enqueue(jobExecutionId string, stageId, jobId int, body types.JobExecutor_RequestBody) error {
+	queueItem := types.QueueItem{
 		Id:         jobExecutionId,
-		PipelineId: pipelineId,
+		PipelineId: run.pipelineReportId,
 		StageId:    stageId,
 		JobId:      jobId,
 		Message:    body,
-		Dependency: dependency,
 	}
 
-	// dq := queue.NewDependencyQueue(ch, "job_queue")
-	// dq.EnqueueWithDependencies(queueItem)
-
-	if err := queue.EnqueueJob(ch, q.Name, queueItem, jobService); err != nil {
+	if err := queue.Enqueue(context.Background(), run.queue, run.queueName, queueItem, run.priority); err != nil {
 		log.Printf("Error enqueuing task: %v", err)
 		return err
 	}
-
+	log.Printf("Job enqueued: %v", queueItem.Id)
 	return nil
 }
 
-/* Match execution key-value pair  */
-func matchExecutionIdToPipeline(executionId string, pipelineId int) {
-	ctx := context.Background()
-	cache.Set(ctx, executionId, pipelineId, 0)
-}
-
-/* Job Execution Result models */
-type JobExecResult struct {
-	Job models.JobConfiguration
-	Err error
-}
-
 /*
-Execute a pipeline and store reports
-TODO #1: Allow failures and update status for failed jobs
-TODO #2: Force stop job(s)
+Execute a stage: jobs are enqueued as soon as all their parents succeed,
+and descendants of a failed job are canceled, driven by the job events published by executors.
+A stage started by a previous delivery of the task is resumed from its job reports,
+unfinished jobs are enqueued again with the same execution id and deduplicated by executors.
+Every unfinished job is canceled once the pipeline execution is canceled.
+A job without terminal status within the job timeout of the run fails, e.g. when its executor died.
+Returns the stage status once every job reached a terminal status.
 */
-func Execute(pipelineExecutionId string, pipeline models.PipelineConfiguration, repository models.Repository) error {
-	// Service instance
-	var pipelineService = PipelineService.NewPipelineService(db.Instance)
-	var stageService = StageService.NewStageService(db.Instance)
-	var jobService = JobService.NewJobService(db.Instance)
-
-	// Topological order
-	var execOrder = pipeline.ExecOrder
-	var stageOrder = pipeline.StageOrder
-
-	// Jobs being canceled because their parents failed
-	var terminatedJobs []string = make([]string, 0)
-
-	// Pipeline execution report
-	var pipelineReport models.Pipeline = models.Pipeline{
-		Repository: removeTokenFromURL(repository.Url),
-		CommitHash: repository.CommitHash,
-		IPAddress:  "0.0.0.0",
-		Name:       pipeline.Pipeline.Value.Name.Value,
-		StageOrder: strings.Join(pipeline.StageOrder, ","),
-		Status:     models.PENDING,
-	}
-	var pipelineReportId, err = pipelineService.CreatePipeline(pipelineReport)
+func (run *pipelineRun) executeStage(stage string, levels [][]string, jobs map[string]*models.JobConfiguration) (models.ExecStatus, error) {
+	// Stage execution report, reused when resuming
+	stageReport, err := run.stageService.GetStageByName(run.pipelineReportId, stage)
 	if err != nil {
-		return err
+		return models.FAILED, err
+	}
+	if stageReport != nil && stageReport.Status.IsTerminal() {
+		log.Printf("REPORT: Stage `%v` already finished with status %v", stage, stageReport.Status)
+		return stageReport.Status, nil
 	}
 
-	// Put K-V pair to Redis
-	matchExecutionIdToPipeline(pipelineExecutionId, pipelineReportId)
-
-	var pipelineHasFailedStages bool = false
-	var pipelineHasCanceledStages bool = false
-
-	// Execute stage
-	for _, stage := range stageOrder {
-		// Job's execution id dependency map.
-		// This map is in the exact order as job dependency map, but instead using the generated execution uuid.
-		// REASON: The mapping/queueing in operator is asynchronous events -> can't check for real job id.
-		var jobExecIdDependency map[string][]string = make(map[string][]string) // execId1 -> [execId2, execId3]
-		var jobExecIdMap map[string]string = make(map[string]string)            // execId1 -> compile, execId2 -> build
-
-		// Stage execution report
-		var stageReport models.Stage = models.Stage{
-			PipelineId: pipelineReportId,
+	var stageReportId int
+	var jobReports map[string]models.Job = make(map[string]models.Job) // compile -> existing job report
+	if stageReport == nil {
+		stageReportId, err = run.stageService.CreateStage(models.Stage{
+			PipelineId: run.pipelineReportId,
 			Name:       stage,
 			Status:     models.PENDING,
+		})
+		if err != nil {
+			return models.FAILED, err
 		}
-		var stageReportId, err = stageService.CreateStage(stageReport)
+	} else {
+		log.Printf("REPORT: Resuming stage `%v`", stage)
+		stageReportId = stageReport.StageId
+		existing, err := run.jobService.GetJobsByStage(stageReportId)
 		if err != nil {
-			return err
+			return models.FAILED, err
+		}
+		for _, jobReport := range existing {
+			jobReports[jobReport.Name] = jobReport
 		}
+	}
 
-		var stageHasFailedJobs bool = false
-		var stageHasCanceledJobs bool = false
+	var jobReportIds map[string]int = make(map[string]int)           // compile -> jobReportId
+	var jobExecutionIds map[string]string = make(map[string]string)  // compile -> job_<uuid>
+	var jobNames map[int]string = make(map[int]string)               // jobReportId -> compile
+	var dependencies map[string][]string = make(map[string][]string) // compile -> [checkout]
+	var statuses map[string]models.ExecStatus = make(map[string]models.ExecStatus)
+	var deadlines map[string]time.Time = make(map[string]time.Time) // compile -> time its event is due
+
+	// Job execution reports, parents are always in an earlier level
+	for _, level := range levels {
+		for _, name := range level {
+			var job models.JobConfiguration = *jobs[name]
+			dependencies[name] = make([]string, 0)
+			if job.Dependencies != nil {
+				dependencies[name] = append(dependencies[name], job.Dependencies.Value...)
+			}
 
-		levels := execOrder[stage]
+			if jobReport, ok := jobReports[name]; ok {
+				jobReportIds[name] = jobReport.JobId
+				jobExecutionIds[name] = jobReport.ExecutionId
+				jobNames[jobReport.JobId] = name
+				statuses[name] = jobReport.Status
+				continue
+			}
 
-		// Allocate Job Execution ID
-		for _, level := range levels {
-			for _, name := range level {
-				// Job Async-execution id
-				var jobExecutionId = "job_" + uuid.New().String()
-				jobExecIdDependency[jobExecutionId] = make([]string, 0)
+			var jobReport models.Job = models.Job{
+				StageId:     stageReportId,
+				Name:        job.Name.Value,
+				Image:       job.Image.Value,
+				Script:      strings.Join(job.Script.Value, " && "),
+				Status:      models.PENDING,
+				ContainerId: "",
+				ExecutionId: "job_" + uuid.New().String(),
+			}
+			jobReportId, err := run.jobService.CreateJob(jobReport)
+			if err != nil {
+				log.Printf("%v\n", err)
+				run.stageService.UpdateStageStatusAndEndTime(stageReportId, models.FAILED)
+				return models.FAILED, errors.New("insert job report into database failed")
+			}
+			jobReportIds[name] = jobReportId
+			jobExecutionIds[name] = jobReport.ExecutionId
+			jobNames[jobReportId] = name
+
+			// Persist parent -> child edges
+			for _, dep := range dependencies[name] {
+				err := run.dependencyService.CreateDependency(models.Dependency{ParentId: jobReportIds[dep], ChildId: jobReportId})
+				if err != nil {
+					log.Printf("%v\n", err)
+				}
+			}
+		}
+	}
 
-				var job models.JobConfiguration = *pipeline.Stages.Value[stage].Value[name]
-				jobExecIdMap[job.Name.Value] = jobExecutionId
+	jobScheduler := scheduler.NewScheduler(dependencies)
 
-				if job.Dependencies != nil {
-					for _, dep := range job.Dependencies.Value {
-						jobExecIdDependency[jobExecutionId] = append(jobExecIdDependency[jobExecutionId], jobExecIdMap[dep])
-					}
-				}
+	// Cancel descendants of a failed job
+	cancel := func(canceled []string) {
+		for _, name := range canceled {
+			if status := run.finishJob(jobReportIds[name], models.CANCELED); status != models.CANCELED {
+				log.Printf("REPORT: Job `%v` already finished with status %v", name, status)
+				continue
+			}
+			log.Printf("REPORT: Job `%v` is canceled because a parent job failed or was canceled!", name)
+		}
+	}
+
+	// Jobs finished before a redelivery are not executed again
+	pending, canceled := jobScheduler.Resume(statuses)
+	for _, name := range canceled {
+		if !statuses[name].IsTerminal() {
+			cancel([]string{name})
+		}
+	}
+	for {
+		// Enqueue released jobs, a job that cannot be enqueued fails
+		for len(pending) > 0 {
+			name := pending[0]
+			pending = pending[1:]
+
+			err := run.enqueue(
+				jobExecutionIds[name],
+				stageReportId,
+				jobReportIds[name],
+				types.JobExecutor_RequestBody{
+					Job:        *jobs[name],
+					Repository: run.repository,
+				},
+			)
+			if err != nil {
+				log.Printf("REPORT: Job `%v` enqueue failed!\nCaused by: %v", name, err)
+				status := run.finishJob(jobReportIds[name], models.FAILED)
+				ready, canceled := jobScheduler.Complete(name, status)
+				cancel(canceled)
+				pending = append(pending, ready...)
+			} else {
+				log.Printf("REPORT: Job `%v` enqueue successfully!\n", name)
+				deadlines[name] = time.Now().Add(run.jobTimeout)
 			}
 		}
 
-		for _, level := range levels {
-			/* Parallel execution */
-			var wg sync.WaitGroup
-			// Buffered channel to avoid blocking
-			errCh := make(chan JobExecResult, len(level))
-			// Execute job
-			for _, name := range level {
-				wg.Add(1)
-				go func(name string) {
-					defer wg.Done()
-					var job models.JobConfiguration = *pipeline.Stages.Value[stage].Value[name]
-					// Check if parent jobs is terminated
-					var isTerminated bool = false
-					if job.Dependencies != nil {
-						for _, dep := range job.Dependencies.Value {
-							if slices.Contains(terminatedJobs, dep) {
-								isTerminated = true
-								break
-							}
-						}
-					}
-
-					// Job execution report
-					var jobReport models.Job = models.Job{
-						StageId:     stageReportId,
-						Name:        job.Name.Value,
-						Image:       job.Image.Value,
-						Script:      strings.Join(job.Script.Value, " && "),
-						Status:      models.PENDING,
-						ContainerId: "",
-					}
-					var jobReportId, err = jobService.CreateJob(jobReport)
-					if err != nil {
-						log.Printf("JobExecResult: %v", err)
-						errCh <- JobExecResult{Job: job, Err: errors.New("insert job report into database failed")}
-						// ? How to handle this error?
-						// ? What to do if failed because of database insertion?
-					}
-
-					if isTerminated {
-						stageHasCanceledJobs = true
-						errCh <- JobExecResult{Job: job, Err: errors.New("parent job failed or terminated")} // Send error to channel
-						err = jobService.UpdateJobStatusAndEndTime(jobReportId, "", models.CANCELED)
-						if err != nil {
-							log.Printf("%v\n", err)
-							errCh <- JobExecResult{Job: job, Err: errors.New("unexpected UpdateJobStatusAndEndTime failed")}
-						}
-						log.Printf("REPORT: Job `%v` is terminated!", job.Name.Value)
-					} else {
-						// Execute job then send result to channel
-						err := enqueue(
-							jobExecIdMap[job.Name.Value],
-							pipelineReportId,
-							stageReportId,
-							jobReportId,
-							jobExecIdDependency,
-							types.JobExecutor_RequestBody{
-								Job:        job,
-								Repository: repository,
-							},
-							jobService,
-						)
-
-						if err != nil {
-							errCh <- JobExecResult{Job: job, Err: err} // Send result to channel
-							log.Printf("REPORT: Job `%v` enqueue failed!\nCaused by: %v", job.Name.Value, err)
-						} else {
-							log.Printf("REPORT: Job `%v` enqueue successfully!\n", job.Name.Value)
-						}
-					}
-				}(name)
+		if jobScheduler.Done() {
+			break
+		}
+
+		// Wait for the next job to finish, for the deadline of a job, or for the cancellation of the pipeline execution
+		var deadline *time.Timer
+		var expired <-chan time.Time
+		if next, ok := nextDeadline(deadlines); ok {
+			deadline = time.NewTimer(time.Until(next))
+			expired = deadline.C
+		}
+		var msg queue.Message
+		var ok, timedOut bool
+		select {
+		case msg, ok = <-run.events:
+		case <-expired:
+			timedOut = true
+		case <-run.interrupted:
+			return models.PENDING, errInterrupted
+		case <-run.canceled:
+			for _, name := range jobScheduler.CancelAll() {
+				if status := run.finishJob(jobReportIds[name], models.CANCELED); status != models.CANCELED {
+					log.Printf("REPORT: Job `%v` already finished with status %v", name, status)
+					continue
+				}
+				log.Printf("REPORT: Job `%v` is canceled because the pipeline execution was canceled!", name)
 			}
+			return models.CANCELED, nil
+		}
+		if deadline != nil {
+			deadline.Stop()
+		}
 
-			wg.Wait()    // Wait for all goroutines to finish
-			close(errCh) // Close the channel after all goroutines are done
-
-			for result := range errCh {
-				if result.Err != nil {
-					terminatedJobs = append(terminatedJobs, result.Job.Name.Value)
-					// stageHasFailedJobs = true // golangci-lint: ineffassign
-					// ! NOTE: If no job's `allow-failure`, update stage & pipeline status before quit
-					if err = stageService.UpdateStageStatusAndEndTime(stageReportId, models.FAILED); err != nil {
-						log.Printf("%v\n", err)
-					}
-					if err = pipelineService.UpdatePipelineStatusAndEndTime(pipelineReportId, models.FAILED); err != nil {
-						log.Printf("%v\n", err)
-					}
-					return result.Err // Return the first error encountered
+		// Jobs without status past their deadline fail, their descendants are canceled
+		if timedOut {
+			for _, name := range expiredJobs(deadlines, time.Now()) {
+				delete(deadlines, name)
+				status := run.finishJob(jobReportIds[name], models.FAILED)
+				if status == models.FAILED {
+					log.Printf("REPORT: Job `%v` failed, no status received within %v", name, run.jobTimeout)
+				} else {
+					log.Printf("REPORT: Job `%v` finished with status %v at its deadline", name, status)
 				}
+				ready, canceled := jobScheduler.Complete(name, status)
+				cancel(canceled)
+				pending = append(pending, ready...)
+			}
+			continue
+		}
+		if !ok {
+			// Closed with the run when interrupted
+			if run.isInterrupted() {
+				return models.PENDING, errInterrupted
 			}
+			run.stageService.UpdateStageStatusAndEndTime(stageReportId, models.FAILED)
+			return models.FAILED, errors.New("job events subscription closed")
+		}
+		if err := run.queue.Ack(msg); err != nil {
+			log.Printf("%v\n", err)
+		}
+		event, err := queue.ParseJobEvent(msg)
+		if err != nil {
+			log.Printf("%v\n", err)
+			continue
+		}
+		name, ok := jobNames[event.JobId]
+		if !ok || event.StageId != stageReportId {
+			continue
 		}
 
-		// * Update stage execution status
-		if stageHasFailedJobs {
-			stageService.UpdateStageStatusAndEndTime(stageReportId, models.FAILED)
-			pipelineHasFailedStages = true
-		} else if stageHasCanceledJobs {
-			stageService.UpdateStageStatusAndEndTime(stageReportId, models.CANCELED)
-			pipelineHasCanceledStages = true
-		} else {
-			stageService.UpdateStageStatusAndEndTime(stageReportId, models.SUCCESS)
+		if _, ok := deadlines[name]; !ok {
+			continue // Failed by its deadline, or duplicated event
 		}
+		delete(deadlines, name)
+		status := models.ExecStatus(event.Status)
+		log.Printf("REPORT: Job `%v` finished with status %v", name, status)
+		ready, canceled := jobScheduler.Complete(name, status)
+		cancel(canceled)
+		pending = append(pending, ready...)
 	}
 
-	// * Update pipeline execution status
-	if pipelineHasFailedStages {
-		pipelineService.UpdatePipelineStatusAndEndTime(pipelineReportId, models.FAILED)
-	} else if pipelineHasCanceledStages {
-		pipelineService.UpdatePipelineStatusAndEndTime(pipelineReportId, models.CANCELED)
+	// Stage and pipeline statuses are aggregated by the status service on every job update
+	return jobScheduler.Status(), nil
+}
+
+/*
+Execute a pipeline and store reports
+Stages run in order, a stage that does not succeed stops the pipeline.
+Stage and pipeline statuses follow from the job statuses, see StatusService.
+Executions are keyed by the task id: a redelivered task resumes its execution, or is skipped once finished.
+An execution starts once it holds the concurrency slots of its repository and group, otherwise the task is deferred.
+An execution interrupted through the context is left unfinished, to be resumed by a redelivery.
+A canceled execution cancels its unfinished jobs, jobs already running finish (see TODO #2).
+TODO #1: Allow failures and update status for failed jobs
+TODO #2: Force stop job(s)
+*/
+func Execute(ctx context.Context, pipelineExecutionId string, pipeline models.PipelineConfiguration, repository models.Repository) error {
+	// Service instance
+	var pipelineService = PipelineService.NewPipelineService(db.Instance)
+
+	// Pipeline execution report of a previous delivery
+	existing, err := pipelineService.GetPipelineByExecutionId(pipelineExecutionId)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.Status.IsTerminal() {
+		log.Printf("REPORT: Pipeline execution `%v` already finished with status %v, skipping", pipelineExecutionId, existing.Status)
+		return nil
+	}
+
+	// Wait for the concurrency slots before the execution starts or resumes
+	slots, err := acquireConcurrencySlots(pipelineExecutionId, removeTokenFromURL(repository.Url), pipeline)
+	if err != nil {
+		return err
+	}
+	defer releaseConcurrencySlots(pipelineExecutionId, slots)
+
+	// No longer waiting in the task queue
+	if err := cache.RemovePendingTask(context.Background(), pipelineExecutionId); err != nil {
+		log.Printf("%v\n", err)
+	}
+
+	var pipelineReportId int
+	if existing != nil {
+		log.Printf("REPORT: Resuming pipeline execution `%v`", pipelineExecutionId)
+		pipelineReportId = existing.PipelineId
 	} else {
-		pipelineService.UpdatePipelineStatusAndEndTime(pipelineReportId, models.SUCCESS)
+		// Pipeline execution report, the unique execution id rejects a concurrent duplicate
+		var pipelineReport models.Pipeline = models.Pipeline{
+			Repository:  removeTokenFromURL(repository.Url),
+			CommitHash:  repository.CommitHash,
+			IPAddress:   "0.0.0.0",
+			Name:        pipeline.Pipeline.Value.Name.Value,
+			StageOrder:  strings.Join(pipeline.StageOrder, ","),
+			Status:      models.PENDING,
+			ExecutionId: pipelineExecutionId,
+		}
+		pipelineReportId, err = pipelineService.CreatePipeline(pipelineReport)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Put K-V pair to Redis
+	matchExecutionIdToPipeline(pipelineExecutionId, pipelineReportId)
+
+	// Subscribe to job events before any job is enqueued
+	run, closeRun, err := newPipelineRun(ctx, pipelineExecutionId, pipelineReportId, repository, queue.MessagePriority(pipeline.Priority()), jobTimeout(pipeline), slots)
+	if err != nil {
+		pipelineService.UpdatePipelineStatusAndEndTime(pipelineReportId, models.FAILED)
+		return err
+	}
+	defer closeRun()
+
+	// Execute stage
+	for _, stage := range pipeline.StageOrder {
+		if run.isCanceled() {
+			log.Printf("REPORT: Pipeline execution `%v` is canceled, skipping the remaining stages", pipelineExecutionId)
+			pipelineService.UpdatePipelineStatusAndEndTime(pipelineReportId, models.CANCELED)
+			break
+		}
+		stageStatus, err := run.executeStage(stage, pipeline.ExecOrder[stage], pipeline.Stages.Value[stage].Value)
+		if errors.Is(err, errInterrupted) {
+			log.Printf("REPORT: Pipeline execution `%v` is interrupted, it resumes on redelivery", pipelineExecutionId)
+			return err
+		}
+		if err != nil {
+			pipelineService.UpdatePipelineStatusAndEndTime(pipelineReportId, models.FAILED)
+			return err
+		}
+		if stageStatus != models.SUCCESS {
+			log.Printf("REPORT: Stage `%v` finished with status %v, skipping the remaining stages", stage, stageStatus)
+			break
+		}
 	}
 
 	return nil
 }
 
+/*
+Connect to the job queue and subscribe to the job events of a pipeline execution,
+its concurrency slots are renewed until the run is closed or the context is done.
+*/
+func newPipelineRun(ctx context.Context, executionId string, pipelineReportId int, repository models.Repository, priority uint8, jobTimeout time.Duration, slots []cache.Slot) (*pipelineRun, func(), error) {
+	q, queueName, err := queue.OpenJobQueue()
+	if err != nil {
+		return nil, nil, err
+	}
+	interrupted := ctx.Done()
+	ctx, stop := context.WithCancel(ctx)
+	closeRun := func() {
+		stop()
+		q.Close()
+	}
+
+	events, err := queue.SubscribeJobEvents(ctx, q, pipelineReportId)
+	if err != nil {
+		closeRun()
+		return nil, nil, err
+	}
+
+	canceled := make(chan struct{})
+	go watchRun(ctx, executionId, slots, canceled)
+
+	return &pipelineRun{
+		pipelineReportId:  pipelineReportId,
+		repository:        repository,
+		stageService:      StageService.NewStageService(db.Instance),
+		jobService:        JobService.NewJobService(db.Instance),
+		dependencyService: DependencyService.NewDependencyService(db.Instance),
+		statusService:     StatusService.NewStatusService(db.Instance),
+		queue:             q,
+		queueName:         queueName,
+		priority:          priority,
+		jobTimeout:        jobTimeout,
+		events:            events,
+		canceled:          canceled,
+		interrupted:       interrupted,
+	}, closeRun, nil
+}
+
 // Remove Personal Access Token from URL if exists
 func removeTokenFromURL(url string) string {
 	// Split the URL by "@"
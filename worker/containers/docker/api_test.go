@@ -0,0 +1,40 @@
+package DockerService
+
+import (
+	"cicd/pipeci/worker/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobTimeout(t *testing.T) {
+	t.Setenv("JOB_TIMEOUT", "")
+	assert.Equal(t, time.Hour, jobTimeout(models.PipelineConfiguration{}))
+
+	t.Setenv("JOB_TIMEOUT", "10m")
+	assert.Equal(t, 10*time.Minute, jobTimeout(models.PipelineConfiguration{}))
+
+	// The pipeline timeout comes first
+	pipeline := models.PipelineConfiguration{Pipeline: &models.ConfigurationNode[models.PipelineInfo]{Value: models.PipelineInfo{
+		Timeout: &models.ConfigurationNode[string]{Value: "30m"},
+	}}}
+	assert.Equal(t, 30*time.Minute, jobTimeout(pipeline))
+}
+
+func TestJobDeadlines(t *testing.T) {
+	now := time.Now()
+	deadlines := map[string]time.Time{
+		"test":    now.Add(time.Minute),
+		"compile": now.Add(-time.Second),
+		"lint":    now,
+	}
+
+	next, ok := nextDeadline(deadlines)
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(-time.Second), next)
+	assert.Equal(t, []string{"compile", "lint"}, expiredJobs(deadlines, now))
+
+	_, ok = nextDeadline(map[string]time.Time{})
+	assert.False(t, ok)
+}
@@ -0,0 +1,132 @@
+package DockerService
+
+import (
+	"cicd/pipeci/queue"
+	"cicd/pipeci/worker/cache"
+	"cicd/pipeci/worker/models"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// Slots of a crashed worker are freed once their lease expires
+	slotLease = time.Minute
+	// Leases are renewed well before they expire
+	slotRenewInterval = 20 * time.Second
+	// Time for a run to notice that a newer run of its group canceled it
+	cancelPollInterval = 5 * time.Second
+	// Cancellation requests outlive a redelivery of the canceled run
+	cancelRequestExpiration = time.Hour
+)
+
+// Maximum concurrent runs of a repository, unlimited when MAX_CONCURRENT_RUNS_PER_REPOSITORY is not set
+func maxRunsPerRepository() int {
+	limit, err := strconv.Atoi(os.Getenv("MAX_CONCURRENT_RUNS_PER_REPOSITORY"))
+	if err != nil || limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// Semaphore of the runs of a repository
+func repositorySlotKey(repository string) string {
+	return "concurrency:" + repository
+}
+
+// Semaphore of the runs of a concurrency group within a repository
+func groupSlotKey(repository, group string) string {
+	return "concurrency:" + repository + ":group:" + group
+}
+
+/*
+Semaphores a pipeline execution must hold while it runs:
+one per repository when limited, and one for the concurrency group of the pipeline.
+*/
+func concurrencySlots(repository string, pipeline models.PipelineConfiguration) []cache.Slot {
+	var slots []cache.Slot
+	if limit := maxRunsPerRepository(); limit > 0 {
+		slots = append(slots, cache.Slot{Key: repositorySlotKey(repository), Limit: limit})
+	}
+	if group, limit, _ := pipeline.ConcurrencyGroup(); group != "" {
+		slots = append(slots, cache.Slot{Key: groupSlotKey(repository, group), Limit: limit})
+	}
+	return slots
+}
+
+/*
+Take the concurrency slots of a pipeline execution.
+When a slot is taken the task is deferred, and with cancel_in_progress
+the runs of its group are asked to cancel so that the newest run goes next.
+*/
+func acquireConcurrencySlots(executionId, repository string, pipeline models.PipelineConfiguration) ([]cache.Slot, error) {
+	ctx := context.Background()
+	slots := concurrencySlots(repository, pipeline)
+	acquired, err := cache.AcquireSlots(ctx, executionId, slots, slotLease)
+	if err != nil {
+		return nil, err
+	}
+	if acquired {
+		return slots, nil
+	}
+
+	if group, limit, cancelInProgress := pipeline.ConcurrencyGroup(); cancelInProgress {
+		holders, err := cache.SlotHolders(ctx, groupSlotKey(repository, group))
+		if err != nil {
+			log.Printf("%v\n", err)
+		}
+		if len(holders) >= limit {
+			for _, holder := range holders {
+				log.Printf("REPORT: Canceling pipeline execution `%v` in progress in concurrency group `%v`", holder, group)
+				if err := cache.RequestCancel(ctx, holder, cancelRequestExpiration); err != nil {
+					log.Printf("%v\n", err)
+				}
+			}
+		}
+	}
+	return nil, queue.Deferred(fmt.Errorf("pipeline execution `%v` is waiting for a concurrency slot", executionId))
+}
+
+// Give the concurrency slots of a finished pipeline execution back
+func releaseConcurrencySlots(executionId string, slots []cache.Slot) {
+	if err := cache.ReleaseSlots(context.Background(), executionId, slots); err != nil {
+		log.Printf("%v\n", err)
+	}
+}
+
+/*
+Renew the concurrency slots of a running pipeline execution until the context is done,
+and close `canceled` once its cancellation is requested.
+*/
+func watchRun(ctx context.Context, executionId string, slots []cache.Slot, canceled chan<- struct{}) {
+	renew := time.NewTicker(slotRenewInterval)
+	defer renew.Stop()
+	poll := time.NewTicker(cancelPollInterval)
+	defer poll.Stop()
+	polls := poll.C
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-renew.C:
+			if err := cache.RenewSlots(ctx, executionId, slots, slotLease); err != nil {
+				log.Printf("%v\n", err)
+			}
+		case <-polls:
+			requested, err := cache.IsCancelRequested(ctx, executionId)
+			if err != nil {
+				log.Printf("%v\n", err)
+				continue
+			}
+			if requested {
+				// Slots are kept until the run has canceled its jobs
+				close(canceled)
+				polls = nil
+			}
+		}
+	}
+}
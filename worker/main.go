@@ -1,18 +1,27 @@
 package main
 
 import (
+	"cicd/pipeci/queue"
 	"cicd/pipeci/worker/cache"
 	DockerService "cicd/pipeci/worker/containers/docker"
 	"cicd/pipeci/worker/db"
 	"cicd/pipeci/worker/storage"
 	"cicd/pipeci/worker/types"
+	"context"
 	"encoding/json"
 	"flag"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Time between two attempts of a one-shot task waiting for a concurrency slot
+const slotRetryInterval = 10 * time.Second
+
 // Task struct
 type Task struct {
 	Id      string                         `json:"id"`
@@ -20,18 +29,18 @@ type Task struct {
 }
 
 /* Process task received from message queue */
-func processTask(jsonInput string) error {
+func processTask(ctx context.Context, jsonInput string) error {
 	// Parse the JSON input
 	var task Task
 	if err := json.Unmarshal([]byte(jsonInput), &task); err != nil {
 		log.Printf("[Worker] Error JSON Unmarshalling: %v", err)
-		return err
+		return queue.Permanent(err)
 	}
 
 	log.Printf("[Worker] JSON parsed done.")
 
 	// Execute the Docker service
-	err := DockerService.Execute(task.Id, task.Message.Pipeline, task.Message.Repository)
+	err := DockerService.Execute(ctx, task.Id, task.Message.Pipeline, task.Message.Repository)
 	if err != nil {
 		log.Printf("[Worker] Error executing pipeline: %v", err)
 		return err
@@ -41,6 +50,35 @@ func processTask(jsonInput string) error {
 	return nil
 }
 
+/* Queue priority of a task, the default priority when it cannot be parsed */
+func taskPriority(jsonInput string) uint8 {
+	var task Task
+	if err := json.Unmarshal([]byte(jsonInput), &task); err != nil {
+		return queue.MessagePriority("")
+	}
+	return queue.MessagePriority(task.Message.Pipeline.Priority())
+}
+
+/*
+Process a task until it is no longer deferred, e.g. waiting in the pod for a concurrency slot,
+as a one-shot task deferred to the queue would start a new pod every cooldown.
+Returns the deferral once the context is done.
+*/
+func processWaiting(ctx context.Context, retryInterval time.Duration, process func() error) error {
+	for {
+		err := process()
+		if !queue.IsDeferred(err) {
+			return err
+		}
+		log.Printf("[Worker] Task waiting, retrying in %v: %v", retryInterval, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
 func main() {
 	err := godotenv.Load(".env")
 	if err != nil {
@@ -60,11 +98,58 @@ func main() {
 
 	// Parse command-line flags
 	jsonInput := flag.String("input", "", "Task JSON input")
+	inputFile := flag.String("input-file", "", "File containing the task JSON input, e.g. a mounted Secret")
+	consume := flag.Bool("consume", false, "Consume tasks from RabbitMQ until SIGTERM")
+	prefetch := flag.Int("prefetch", 1, "Maximum number of tasks processed at once in --consume mode")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 25*time.Second, "Time given to in-flight tasks on SIGTERM before they are requeued")
 	flag.Parse()
 
-	// JSON passed via CLI flag
+	// Long-running consumer, tasks are acked once their pipeline is terminal
+	if *consume {
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+		defer stop()
+
+		config := queue.TaskConsumerConfig(*prefetch, *shutdownTimeout)
+		err := queue.Consume(ctx, config, func(ctx context.Context, body []byte) error {
+			return processTask(ctx, string(body))
+		})
+		if err != nil {
+			log.Fatalf("[Worker] Consumer failed: %v", err)
+		}
+		log.Println("[Worker] Consumer stopped.")
+		return
+	}
+
+	// JSON read from a file, keeps the payload out of the pod spec
+	if *inputFile != "" {
+		content, err := os.ReadFile(*inputFile)
+		if err != nil {
+			log.Fatalf("[Worker] Failed to read input file: %v", err)
+		}
+		*jsonInput = string(content)
+	}
+
+	// JSON passed via CLI flag or file, the message was acked before this pod started
 	if *jsonInput != "" {
-		if err := processTask(*jsonInput); err != nil {
+		// A waiting task is deferred to the queue only when the pod stops
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+		defer stop()
+
+		err := processWaiting(ctx, slotRetryInterval, func() error {
+			return processTask(context.Background(), *jsonInput)
+		})
+		if err != nil {
+			config := queue.TaskConsumerConfig(*prefetch, *shutdownTimeout)
+			if queue.IsDeferred(err) {
+				if deferErr := queue.DeferInput(config, []byte(*jsonInput), taskPriority(*jsonInput)); deferErr != nil {
+					log.Fatalf("[Worker] Failed to defer task: %v", deferErr)
+				}
+				log.Printf("[Worker] Task deferred: %v", err)
+				return
+			}
+			if dlqErr := queue.DeadLetterInput(config, []byte(*jsonInput), err); dlqErr != nil {
+				log.Printf("[Worker] Failed to dead-letter task: %v", dlqErr)
+			}
 			log.Fatalf("[Worker] Task failed: %v", err)
 		}
 	}
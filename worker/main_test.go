@@ -1,12 +1,16 @@
 package main
 
 import (
+	"cicd/pipeci/queue"
 	"cicd/pipeci/worker/cache"
 	"cicd/pipeci/worker/db"
 	"cicd/pipeci/worker/storage"
+	"context"
+	"errors"
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
@@ -40,7 +44,7 @@ func TestProcessTaskWithJsonInput(t *testing.T) {
 	jsonInput := `{"id":"id","message":{"pipeline":{"Version":{"Value":"v0","Location":{"Line":1,"Column":1}},"Pipeline":{"Value":{"Name":{"Value":"test_pipeline","Location":{"Line":5,"Column":3}}},"Location":{"Line":4,"Column":1}},"Stages":{"Value":{"build":{"Value":{"compile":{"Name":{"Value":"compile","Location":{"Line":13,"Column":5}},"Stage":{"Value":"build","Location":{"Line":14,"Column":5}},"Image":{"Value":"maven","Location":{"Line":15,"Column":5}},"Script":{"Value":["ls -la","mvn -v"],"Location":{"Line":16,"Column":5}},"Dependencies":null}},"Location":{"Line":9,"Column":5}}},"Location":{"Line":8,"Column":1}},"StageOrder":["build"],"ExecOrder":{"build":[["compile"]]}},"repository":{"Url":"https://github.com/CS6510-SEA-SP25/t3-cicd.git","CommitHash":"729d2fbb87d47fc770cdbe287e28bbe4ab2faa42"}}}`
 
 	// Call the processTask function directly (this is testable now)
-	err := processTask(jsonInput)
+	err := processTask(context.Background(), jsonInput)
 
 	// Assertions to verify that the task processing was done correctly
 	assert.NoError(t, err, "Task should be processed without errors")
@@ -54,8 +58,39 @@ func TestProcessTask_Failed(t *testing.T) {
 	storage.Init()
 
 	// Call the processTask function directly (this is testable now)
-	err := processTask("")
+	err := processTask(context.Background(), "")
 
 	// Assertions to verify that the task processing was done correctly
 	assert.Error(t, err, "Task should be processed without errors")
 }
+
+// One-shot tasks wait in the pod while they are deferred
+func TestProcessWaiting(t *testing.T) {
+	attempts := 0
+	err := processWaiting(context.Background(), time.Millisecond, func() error {
+		attempts++
+		if attempts < 3 {
+			return queue.Deferred(errors.New("waiting for a concurrency slot"))
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	// Deferred to the queue once the pod stops
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = processWaiting(ctx, time.Hour, func() error {
+		return queue.Deferred(errors.New("waiting for a concurrency slot"))
+	})
+	assert.True(t, queue.IsDeferred(err))
+
+	// Failures are not retried
+	attempts = 0
+	err = processWaiting(context.Background(), time.Millisecond, func() error {
+		attempts++
+		return errors.New("failed")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
@@ -3,6 +3,8 @@
  */
 package models
 
+import "time"
+
 // Location of ConfigurationNode in YAML file.
 type YAMLFileLocation struct {
 	Line   int
@@ -31,7 +33,16 @@ type JobConfiguration struct {
 
 // Pipeline identifier info.
 type PipelineInfo struct {
-	Name *ConfigurationNode[string] // (required) Name of pipeline.
+	Name     *ConfigurationNode[string] // (required) Name of pipeline.
+	Priority *ConfigurationNode[string] // (optional) Queue priority: high, normal or low. Defaults to normal.
+	// (optional) Runs of a repository in the same group share the Concurrency limit. Defaults to the pipeline name.
+	ConcurrencyGroup *ConfigurationNode[string]
+	// (optional) Maximum concurrent runs of the group per repository. Defaults to 1 with a group, unlimited otherwise.
+	Concurrency *ConfigurationNode[int]
+	// (optional) Cancel the runs in progress of the group instead of waiting for them to finish.
+	CancelInProgress *ConfigurationNode[bool]
+	// (optional) Maximum duration of a run, e.g. `30m`. Runs past it are stopped by the operator.
+	Timeout *ConfigurationNode[string]
 }
 
 // Pipeline configuration
@@ -53,3 +64,52 @@ type Repository struct {
 	Url        string // Repository URL
 	CommitHash string // Git commit hash value
 }
+
+// Queue priority of a pipeline, empty when not set
+func (pipeline PipelineConfiguration) Priority() string {
+	if pipeline.Pipeline == nil || pipeline.Pipeline.Value.Priority == nil {
+		return ""
+	}
+	return pipeline.Pipeline.Value.Priority.Value
+}
+
+// Maximum duration of a pipeline run, zero when not set or invalid
+func (pipeline PipelineConfiguration) Timeout() time.Duration {
+	if pipeline.Pipeline == nil || pipeline.Pipeline.Value.Timeout == nil {
+		return 0
+	}
+	timeout, err := time.ParseDuration(pipeline.Pipeline.Value.Timeout.Value)
+	if err != nil || timeout <= 0 {
+		return 0
+	}
+	return timeout
+}
+
+/*
+Concurrency group of a pipeline, its limit of concurrent runs per repository,
+and whether runs in progress of the group are canceled by a new run.
+The group is empty for pipelines without concurrency settings.
+*/
+func (pipeline PipelineConfiguration) ConcurrencyGroup() (group string, limit int, cancelInProgress bool) {
+	if pipeline.Pipeline == nil {
+		return "", 0, false
+	}
+	info := pipeline.Pipeline.Value
+	if info.ConcurrencyGroup == nil && info.Concurrency == nil && info.CancelInProgress == nil {
+		return "", 0, false
+	}
+
+	if info.ConcurrencyGroup != nil {
+		group = info.ConcurrencyGroup.Value
+	} else if info.Name != nil {
+		group = info.Name.Value
+	}
+	limit = 1
+	if info.Concurrency != nil {
+		limit = info.Concurrency.Value
+	}
+	if info.CancelInProgress != nil {
+		cancelInProgress = info.CancelInProgress.Value
+	}
+	return group, limit, cancelInProgress
+}
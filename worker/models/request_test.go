@@ -2,6 +2,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -99,3 +100,51 @@ func TestRepository(t *testing.T) {
 	assert.Equal(t, "https://github.com/example/repo.git", repo.Url)
 	assert.Equal(t, "abc123", repo.CommitHash)
 }
+
+func TestPipelineConfiguration_Priority(t *testing.T) {
+	assert.Equal(t, "", PipelineConfiguration{}.Priority())
+
+	pipeline := PipelineConfiguration{Pipeline: &ConfigurationNode[PipelineInfo]{Value: PipelineInfo{
+		Name:     &ConfigurationNode[string]{Value: "deploy"},
+		Priority: &ConfigurationNode[string]{Value: "high"},
+	}}}
+	assert.Equal(t, "high", pipeline.Priority())
+}
+
+func TestPipelineConfiguration_ConcurrencyGroup(t *testing.T) {
+	group, limit, cancelInProgress := PipelineConfiguration{}.ConcurrencyGroup()
+	assert.Equal(t, "", group)
+	assert.Equal(t, 0, limit)
+	assert.False(t, cancelInProgress)
+
+	name := &ConfigurationNode[string]{Value: "deploy"}
+	pipeline := PipelineConfiguration{Pipeline: &ConfigurationNode[PipelineInfo]{Value: PipelineInfo{Name: name}}}
+	group, _, _ = pipeline.ConcurrencyGroup()
+	assert.Equal(t, "", group)
+
+	// Defaults to one run per pipeline name
+	pipeline.Pipeline.Value.CancelInProgress = &ConfigurationNode[bool]{Value: true}
+	group, limit, cancelInProgress = pipeline.ConcurrencyGroup()
+	assert.Equal(t, "deploy", group)
+	assert.Equal(t, 1, limit)
+	assert.True(t, cancelInProgress)
+
+	pipeline.Pipeline.Value.ConcurrencyGroup = &ConfigurationNode[string]{Value: "production"}
+	pipeline.Pipeline.Value.Concurrency = &ConfigurationNode[int]{Value: 2}
+	group, limit, _ = pipeline.ConcurrencyGroup()
+	assert.Equal(t, "production", group)
+	assert.Equal(t, 2, limit)
+}
+
+func TestPipelineConfiguration_Timeout(t *testing.T) {
+	assert.Equal(t, time.Duration(0), PipelineConfiguration{}.Timeout())
+
+	pipeline := PipelineConfiguration{Pipeline: &ConfigurationNode[PipelineInfo]{Value: PipelineInfo{}}}
+	assert.Equal(t, time.Duration(0), pipeline.Timeout())
+
+	pipeline.Pipeline.Value.Timeout = &ConfigurationNode[string]{Value: "30m"}
+	assert.Equal(t, 30*time.Minute, pipeline.Timeout())
+
+	pipeline.Pipeline.Value.Timeout = &ConfigurationNode[string]{Value: "soon"}
+	assert.Equal(t, time.Duration(0), pipeline.Timeout())
+}
@@ -0,0 +1,190 @@
+/*
+Event-driven job scheduling within a stage.
+Jobs are released the moment all their parents succeed, and every descendant
+of a failed or canceled job is canceled right away.
+*/
+package scheduler
+
+import (
+	"cicd/pipeci/worker/models"
+	"sort"
+)
+
+// Scheduling state of a job
+type state int
+
+const (
+	waiting  state = iota // Parents not finished yet
+	queued                // Released for execution
+	finished              // Terminal status received
+)
+
+// Dependency DAG of a stage and the status of its jobs
+type Scheduler struct {
+	parents  map[string][]string // job -> jobs it needs
+	children map[string][]string // job -> jobs that need it
+	states   map[string]state
+	statuses map[string]models.ExecStatus // terminal status of finished jobs
+}
+
+/*
+Create a scheduler from the `needs` of every job in a stage.
+Dependencies outside the stage are ignored, the configuration is validated beforehand.
+*/
+func NewScheduler(dependencies map[string][]string) *Scheduler {
+	scheduler := &Scheduler{
+		parents:  make(map[string][]string),
+		children: make(map[string][]string),
+		states:   make(map[string]state),
+		statuses: make(map[string]models.ExecStatus),
+	}
+	for job := range dependencies {
+		scheduler.states[job] = waiting
+	}
+	for job, parents := range dependencies {
+		for _, parent := range parents {
+			if _, ok := dependencies[parent]; !ok {
+				continue
+			}
+			scheduler.parents[job] = append(scheduler.parents[job], parent)
+			scheduler.children[parent] = append(scheduler.children[parent], job)
+		}
+	}
+	return scheduler
+}
+
+// Release the jobs without dependencies
+func (scheduler *Scheduler) Start() []string {
+	var ready []string
+	for job := range scheduler.states {
+		if len(scheduler.parents[job]) == 0 {
+			scheduler.states[job] = queued
+			ready = append(ready, job)
+		}
+	}
+	sort.Strings(ready)
+	return ready
+}
+
+/*
+Record the terminal status of a job.
+Returns the children that became ready and the descendants canceled as a result.
+Unknown jobs and duplicated events are ignored.
+*/
+func (scheduler *Scheduler) Complete(job string, status models.ExecStatus) (ready []string, canceled []string) {
+	if current, ok := scheduler.states[job]; !ok || current == finished {
+		return nil, nil
+	}
+	scheduler.states[job] = finished
+	scheduler.statuses[job] = status
+
+	if status != models.SUCCESS {
+		canceled = scheduler.cancelDescendants(job)
+		sort.Strings(canceled)
+		return nil, canceled
+	}
+
+	for _, child := range scheduler.children[job] {
+		if scheduler.states[child] == waiting && scheduler.parentsSucceeded(child) {
+			scheduler.states[child] = queued
+			ready = append(ready, child)
+		}
+	}
+	sort.Strings(ready)
+	return ready, nil
+}
+
+/*
+Start a stage that was partially executed, from the terminal status of its finished jobs.
+Returns the released jobs still to be executed and the descendants canceled as a result,
+like Start and Complete would have for the same sequence of events.
+*/
+func (scheduler *Scheduler) Resume(statuses map[string]models.ExecStatus) (ready []string, canceled []string) {
+	released := scheduler.Start()
+	for len(released) > 0 {
+		job := released[0]
+		released = released[1:]
+
+		status, ok := statuses[job]
+		if !ok || !status.IsTerminal() {
+			ready = append(ready, job)
+			continue
+		}
+		children, descendants := scheduler.Complete(job, status)
+		released = append(released, children...)
+		canceled = append(canceled, descendants...)
+	}
+	sort.Strings(ready)
+	sort.Strings(canceled)
+	return ready, canceled
+}
+
+// Cancel every job that transitively depends on the given one
+func (scheduler *Scheduler) cancelDescendants(job string) []string {
+	var canceled []string
+	for _, child := range scheduler.children[job] {
+		if scheduler.states[child] == finished {
+			continue
+		}
+		scheduler.states[child] = finished
+		scheduler.statuses[child] = models.CANCELED
+		canceled = append(canceled, child)
+		canceled = append(canceled, scheduler.cancelDescendants(child)...)
+	}
+	return canceled
+}
+
+// Cancel every job not finished yet, returns the canceled jobs
+func (scheduler *Scheduler) CancelAll() []string {
+	var canceled []string
+	for job, current := range scheduler.states {
+		if current == finished {
+			continue
+		}
+		scheduler.states[job] = finished
+		scheduler.statuses[job] = models.CANCELED
+		canceled = append(canceled, job)
+	}
+	sort.Strings(canceled)
+	return canceled
+}
+
+// Check if all parents of a job succeeded
+func (scheduler *Scheduler) parentsSucceeded(job string) bool {
+	for _, parent := range scheduler.parents[job] {
+		if scheduler.statuses[parent] != models.SUCCESS {
+			return false
+		}
+	}
+	return true
+}
+
+// Check if every job reached a terminal status
+func (scheduler *Scheduler) Done() bool {
+	for _, current := range scheduler.states {
+		if current != finished {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Stage status from the status of its jobs.
+FAILED if any job failed, CANCELED if any job was canceled, SUCCESS otherwise.
+*/
+func (scheduler *Scheduler) Status() models.ExecStatus {
+	if !scheduler.Done() {
+		return models.PENDING
+	}
+	status := models.SUCCESS
+	for _, jobStatus := range scheduler.statuses {
+		if jobStatus == models.FAILED {
+			return models.FAILED
+		}
+		if jobStatus == models.CANCELED {
+			status = models.CANCELED
+		}
+	}
+	return status
+}
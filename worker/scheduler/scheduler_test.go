@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"cicd/pipeci/worker/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// compile -> unit-test -> coverage, compile -> lint
+func newTestScheduler() *Scheduler {
+	return NewScheduler(map[string][]string{
+		"compile":   {},
+		"unit-test": {"compile"},
+		"coverage":  {"unit-test"},
+		"lint":      {"compile"},
+	})
+}
+
+func TestScheduler_Success(t *testing.T) {
+	scheduler := newTestScheduler()
+	assert.Equal(t, []string{"compile"}, scheduler.Start())
+
+	ready, canceled := scheduler.Complete("compile", models.SUCCESS)
+	assert.Equal(t, []string{"lint", "unit-test"}, ready)
+	assert.Empty(t, canceled)
+
+	ready, _ = scheduler.Complete("lint", models.SUCCESS)
+	assert.Empty(t, ready)
+	ready, _ = scheduler.Complete("unit-test", models.SUCCESS)
+	assert.Equal(t, []string{"coverage"}, ready)
+	assert.False(t, scheduler.Done())
+	assert.Equal(t, models.PENDING, scheduler.Status())
+
+	scheduler.Complete("coverage", models.SUCCESS)
+	assert.True(t, scheduler.Done())
+	assert.Equal(t, models.SUCCESS, scheduler.Status())
+}
+
+func TestScheduler_FailureCancelsDescendants(t *testing.T) {
+	scheduler := newTestScheduler()
+	scheduler.Start()
+
+	ready, canceled := scheduler.Complete("compile", models.FAILED)
+	assert.Empty(t, ready)
+	assert.Equal(t, []string{"coverage", "lint", "unit-test"}, canceled)
+	assert.True(t, scheduler.Done())
+	assert.Equal(t, models.FAILED, scheduler.Status())
+}
+
+func TestScheduler_WaitsForAllParents(t *testing.T) {
+	scheduler := NewScheduler(map[string][]string{
+		"a":    {},
+		"b":    {},
+		"join": {"a", "b"},
+	})
+	assert.Equal(t, []string{"a", "b"}, scheduler.Start())
+
+	ready, _ := scheduler.Complete("a", models.SUCCESS)
+	assert.Empty(t, ready)
+	ready, _ = scheduler.Complete("b", models.SUCCESS)
+	assert.Equal(t, []string{"join"}, ready)
+}
+
+func TestScheduler_CanceledParent(t *testing.T) {
+	scheduler := NewScheduler(map[string][]string{
+		"a":    {},
+		"b":    {},
+		"join": {"a", "b"},
+	})
+	scheduler.Start()
+
+	_, canceled := scheduler.Complete("a", models.CANCELED)
+	assert.Equal(t, []string{"join"}, canceled)
+	assert.False(t, scheduler.Done())
+
+	// A late success does not revive the canceled child
+	ready, _ := scheduler.Complete("b", models.SUCCESS)
+	assert.Empty(t, ready)
+	assert.True(t, scheduler.Done())
+	assert.Equal(t, models.CANCELED, scheduler.Status())
+}
+
+func TestScheduler_IgnoresDuplicatedAndUnknownEvents(t *testing.T) {
+	scheduler := newTestScheduler()
+	scheduler.Start()
+
+	ready, _ := scheduler.Complete("compile", models.SUCCESS)
+	assert.Len(t, ready, 2)
+	ready, canceled := scheduler.Complete("compile", models.FAILED)
+	assert.Empty(t, ready)
+	assert.Empty(t, canceled)
+	ready, canceled = scheduler.Complete("deploy", models.SUCCESS)
+	assert.Empty(t, ready)
+	assert.Empty(t, canceled)
+}
+
+func TestScheduler_Resume(t *testing.T) {
+	scheduler := newTestScheduler()
+
+	// compile and lint finished before the worker restarted, unit-test was in progress
+	ready, canceled := scheduler.Resume(map[string]models.ExecStatus{
+		"compile":   models.SUCCESS,
+		"lint":      models.SUCCESS,
+		"unit-test": models.PENDING,
+	})
+	assert.Equal(t, []string{"unit-test"}, ready)
+	assert.Empty(t, canceled)
+
+	ready, _ = scheduler.Complete("unit-test", models.SUCCESS)
+	assert.Equal(t, []string{"coverage"}, ready)
+}
+
+func TestScheduler_ResumeAfterFailure(t *testing.T) {
+	scheduler := newTestScheduler()
+
+	// Descendants of unit-test were not canceled yet
+	ready, canceled := scheduler.Resume(map[string]models.ExecStatus{
+		"compile":   models.SUCCESS,
+		"unit-test": models.FAILED,
+	})
+	assert.Equal(t, []string{"lint"}, ready)
+	assert.Equal(t, []string{"coverage"}, canceled)
+
+	scheduler.Complete("lint", models.SUCCESS)
+	assert.True(t, scheduler.Done())
+	assert.Equal(t, models.FAILED, scheduler.Status())
+}
+
+func TestScheduler_CancelAll(t *testing.T) {
+	scheduler := newTestScheduler()
+	scheduler.Start()
+	ready, _ := scheduler.Complete("compile", models.SUCCESS)
+	assert.Equal(t, []string{"lint", "unit-test"}, ready)
+
+	assert.Equal(t, []string{"coverage", "lint", "unit-test"}, scheduler.CancelAll())
+	assert.True(t, scheduler.Done())
+	assert.Equal(t, models.CANCELED, scheduler.Status())
+	assert.Empty(t, scheduler.CancelAll())
+}
@@ -0,0 +1,27 @@
+package DependencyService
+
+import (
+	"cicd/pipeci/worker/models"
+	"database/sql"
+	"fmt"
+)
+
+type DependencyService struct {
+	db *sql.DB
+}
+
+func NewDependencyService(db *sql.DB) *DependencyService {
+	return &DependencyService{db: db}
+}
+
+// Create a parent -> child job dependency edge
+func (service *DependencyService) CreateDependency(dependency models.Dependency) error {
+	_, err := service.db.Exec(
+		"INSERT INTO Dependencies (parent_id, child_id) VALUES (?, ?)",
+		dependency.ParentId, dependency.ChildId,
+	)
+	if err != nil {
+		return fmt.Errorf("CreateDependency: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package DependencyService
+
+import (
+	"cicd/pipeci/worker/models"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateDependency(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewDependencyService(db)
+
+	// Expect the exec and return a mock result
+	mock.ExpectExec("INSERT INTO Dependencies").
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Call the method under test
+	err = service.CreateDependency(models.Dependency{ParentId: 1, ChildId: 2})
+
+	// Assert that no error occurred
+	assert.NoError(t, err)
+
+	// Ensure all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateDependency_DBError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewDependencyService(db)
+
+	// Expect the exec to return an error
+	mock.ExpectExec("INSERT INTO Dependencies").
+		WithArgs(1, 2).
+		WillReturnError(fmt.Errorf("database error"))
+
+	// Call the method under test
+	err = service.CreateDependency(models.Dependency{ParentId: 1, ChildId: 2})
+
+	// Assert that an error occurred
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "CreateDependency: database error")
+
+	// Ensure all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
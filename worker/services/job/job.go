@@ -21,8 +21,8 @@ func (service *JobService) CreateJob(job models.Job) (int, error) {
 	job.StartTime = time.Now()
 
 	result, err := service.db.Exec(
-		"INSERT INTO Jobs (stage_id, name, image, script, status, start_time, container_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		job.StageId, job.Name, job.Image, job.Script, job.Status, job.StartTime, job.ContainerId,
+		"INSERT INTO Jobs (stage_id, name, image, script, status, start_time, container_id, execution_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		job.StageId, job.Name, job.Image, job.Script, job.Status, job.StartTime, job.ContainerId, job.ExecutionId,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("CreateJob: %v", err)
@@ -37,6 +37,35 @@ func (service *JobService) CreateJob(job models.Job) (int, error) {
 	return int(jobID), nil
 }
 
+// Get the job reports of a stage
+func (service *JobService) GetJobsByStage(stageID int) ([]models.Job, error) {
+	rows, err := service.db.Query(
+		"SELECT job_id, stage_id, name, image, script, status, start_time, end_time, container_id, execution_id FROM Jobs WHERE stage_id = ?",
+		stageID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("GetJobsByStage: %v", err)
+	}
+	defer rows.Close()
+
+	var jobs []models.Job
+	for rows.Next() {
+		var job models.Job
+		if err := rows.Scan(
+			&job.JobId, &job.StageId, &job.Name,
+			&job.Image, &job.Script, &job.Status,
+			&job.StartTime, &job.EndTime, &job.ContainerId, &job.ExecutionId,
+		); err != nil {
+			return nil, fmt.Errorf("GetJobsByStage: %v", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetJobsByStage: %v", err)
+	}
+	return jobs, nil
+}
+
 // Update job status and end_time
 func (service *JobService) UpdateJobStatusAndEndTime(jobID int, containerId string, status models.ExecStatus) error {
 	var endTime time.Time = time.Now()
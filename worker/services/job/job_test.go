@@ -28,6 +28,7 @@ func TestCreateJob(t *testing.T) {
 		Status:      models.PENDING,
 		StartTime:   time.Now(),
 		ContainerId: "container1",
+		ExecutionId: "job_1",
 	}
 
 	// Expect the exec and return a mock result
@@ -40,6 +41,7 @@ func TestCreateJob(t *testing.T) {
 			job.Status,
 			sqlmock.AnyArg(), // Use AnyArg for the start_time argument
 			job.ContainerId,
+			job.ExecutionId,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -161,6 +163,7 @@ func TestCreateJob_DBError(t *testing.T) {
 			job.Status,
 			sqlmock.AnyArg(), // Use AnyArg for the start_time argument
 			job.ContainerId,
+			job.ExecutionId,
 		).
 		WillReturnError(fmt.Errorf("database error"))
 
@@ -253,3 +256,30 @@ func TestUpdateJobStatusAndEndTime_NoRowsAffectedError(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestGetJobsByStage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewJobService(db)
+
+	rows := sqlmock.NewRows([]string{"job_id", "stage_id", "name", "image", "script", "status", "start_time", "end_time", "container_id", "execution_id"}).
+		AddRow(1, 2, "compile", "golang", "go build", models.SUCCESS, time.Now(), time.Now(), "container1", "job_1").
+		AddRow(2, 2, "unit-test", "golang", "go test", models.PENDING, time.Now(), nil, "", "job_2")
+	mock.ExpectQuery("FROM Jobs WHERE stage_id = \\?").
+		WithArgs(2).
+		WillReturnRows(rows)
+
+	jobs, err := service.GetJobsByStage(2)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 2)
+	assert.Equal(t, "job_1", jobs[0].ExecutionId)
+	assert.Equal(t, models.PENDING, jobs[1].Status)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -20,8 +20,8 @@ func (service *PipelineService) CreatePipeline(pipeline models.Pipeline) (int, e
 	pipeline.StartTime = time.Now()
 
 	result, err := service.db.Exec(
-		"INSERT INTO Pipelines (repository, commit_hash, ip_address, name, stage_order, status, start_time) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		pipeline.Repository, pipeline.CommitHash, pipeline.IPAddress, pipeline.Name, pipeline.StageOrder, pipeline.Status, pipeline.StartTime,
+		"INSERT INTO Pipelines (repository, commit_hash, ip_address, name, stage_order, status, start_time, execution_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		pipeline.Repository, pipeline.CommitHash, pipeline.IPAddress, pipeline.Name, pipeline.StageOrder, pipeline.Status, pipeline.StartTime, pipeline.ExecutionId,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("CreatePipeline: %v", err)
@@ -37,6 +37,26 @@ func (service *PipelineService) CreatePipeline(pipeline models.Pipeline) (int, e
 	return int(pipelineId), nil
 }
 
+// Get the pipeline report of an execution, nil if the execution has not started
+func (service *PipelineService) GetPipelineByExecutionId(executionId string) (*models.Pipeline, error) {
+	var pipeline models.Pipeline
+	err := service.db.QueryRow(
+		"SELECT pipeline_id, repository, commit_hash, ip_address, name, stage_order, status, start_time, end_time, execution_id FROM Pipelines WHERE execution_id = ?",
+		executionId,
+	).Scan(
+		&pipeline.PipelineId, &pipeline.Repository, &pipeline.CommitHash, &pipeline.IPAddress,
+		&pipeline.Name, &pipeline.StageOrder,
+		&pipeline.Status, &pipeline.StartTime, &pipeline.EndTime, &pipeline.ExecutionId,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetPipelineByExecutionId: %v", err)
+	}
+	return &pipeline, nil
+}
+
 // Update pipeline status and end_time
 func (service *PipelineService) UpdatePipelineStatusAndEndTime(pipelineID int, status models.ExecStatus) error {
 	var endTime time.Time = time.Now()
@@ -22,13 +22,14 @@ func TestCreatePipeline(t *testing.T) {
 
 	// Define the pipeline
 	pipeline := models.Pipeline{
-		Repository: "repo1",
-		CommitHash: "abc123",
-		IPAddress:  "192.168.1.1",
-		Name:       "pipeline1",
-		StageOrder: "",
-		Status:     models.PENDING,
-		StartTime:  time.Now(),
+		Repository:  "repo1",
+		CommitHash:  "abc123",
+		IPAddress:   "192.168.1.1",
+		Name:        "pipeline1",
+		StageOrder:  "",
+		Status:      models.PENDING,
+		StartTime:   time.Now(),
+		ExecutionId: "task1",
 	}
 
 	// Expect the exec and return a mock result
@@ -41,6 +42,7 @@ func TestCreatePipeline(t *testing.T) {
 			pipeline.StageOrder,
 			pipeline.Status,
 			sqlmock.AnyArg(), // Use AnyArg for the time argument
+			pipeline.ExecutionId,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -156,6 +158,7 @@ func TestCreatePipeline_DBError(t *testing.T) {
 			pipeline.StageOrder,
 			pipeline.Status,
 			sqlmock.AnyArg(), // Use AnyArg for the start_time argument
+			"",
 		).
 		WillReturnError(fmt.Errorf("database error"))
 
@@ -269,3 +272,44 @@ func TestCleanUpTestPipelines(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestGetPipelineByExecutionId(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewPipelineService(db)
+
+	rows := sqlmock.NewRows([]string{"pipeline_id", "repository", "commit_hash", "ip_address", "name", "stage_order", "status", "start_time", "end_time", "execution_id"}).
+		AddRow(1, "repo1", "abc123", "0.0.0.0", "pipeline1", "build,test", models.PENDING, time.Now(), nil, "task1")
+	mock.ExpectQuery(regexp.QuoteMeta("FROM Pipelines WHERE execution_id = ?")).
+		WithArgs("task1").
+		WillReturnRows(rows)
+
+	pipeline, err := service.GetPipelineByExecutionId("task1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pipeline.PipelineId)
+	assert.Equal(t, models.PENDING, pipeline.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPipelineByExecutionId_NotStarted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewPipelineService(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM Pipelines WHERE execution_id = ?")).
+		WithArgs("task1").
+		WillReturnRows(sqlmock.NewRows([]string{"pipeline_id"}))
+
+	pipeline, err := service.GetPipelineByExecutionId("task1")
+	assert.NoError(t, err)
+	assert.Nil(t, pipeline)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
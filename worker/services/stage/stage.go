@@ -36,6 +36,22 @@ func (service *StageService) CreateStage(stage models.Stage) (int, error) {
 	return int(stageId), nil
 }
 
+// Get the report of a stage in a pipeline execution, nil if the stage has not started
+func (service *StageService) GetStageByName(pipelineID int, name string) (*models.Stage, error) {
+	var stage models.Stage
+	err := service.db.QueryRow(
+		"SELECT stage_id, pipeline_id, name, status, start_time, end_time FROM Stages WHERE pipeline_id = ? AND name = ?",
+		pipelineID, name,
+	).Scan(&stage.StageId, &stage.PipelineId, &stage.Name, &stage.Status, &stage.StartTime, &stage.EndTime)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetStageByName: %v", err)
+	}
+	return &stage, nil
+}
+
 // Update stage status and end_time
 func (service *StageService) UpdateStageStatusAndEndTime(stageID int, status models.ExecStatus) error {
 	var endTime time.Time = time.Now()
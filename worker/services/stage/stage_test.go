@@ -233,3 +233,52 @@ func TestUpdateStageStatusAndEndTime_NoRowsAffectedError(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestGetStageByName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewStageService(db)
+
+	mock.ExpectQuery("SELECT stage_id, pipeline_id, name, status, start_time, end_time FROM Stages WHERE pipeline_id = \\? AND name = \\?").
+		WithArgs(1, "build").
+		WillReturnRows(sqlmock.NewRows([]string{"stage_id", "pipeline_id", "name", "status", "start_time", "end_time"}).
+			AddRow(2, 1, "build", models.SUCCESS, time.Now(), time.Now()))
+	mock.ExpectQuery("SELECT stage_id, pipeline_id, name, status, start_time, end_time FROM Stages WHERE pipeline_id = \\? AND name = \\?").
+		WithArgs(1, "test").
+		WillReturnRows(sqlmock.NewRows([]string{"stage_id"}))
+
+	stage, err := service.GetStageByName(1, "build")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stage.StageId)
+	assert.Equal(t, models.SUCCESS, stage.Status)
+
+	stage, err = service.GetStageByName(1, "test")
+	assert.NoError(t, err)
+	assert.Nil(t, stage)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetStageByName_DBError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	service := NewStageService(db)
+
+	mock.ExpectQuery("FROM Stages WHERE pipeline_id = \\? AND name = \\?").
+		WithArgs(1, "build").
+		WillReturnError(fmt.Errorf("database error"))
+
+	_, err = service.GetStageByName(1, "build")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "GetStageByName: database error")
+}
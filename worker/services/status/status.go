@@ -0,0 +1,181 @@
+/*
+Status aggregation: a job status change is propagated to its stage and pipeline
+in one transaction. The pipeline and stage rows are locked (always in that order)
+so that executors finishing at the same time see each other's updates.
+*/
+package StatusService
+
+import (
+	"cicd/pipeci/worker/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A job keeps its first terminal status, e.g. when the worker failed it past its deadline before the executor reported
+var ErrJobFinished = errors.New("job already finished")
+
+type StatusService struct {
+	db *sql.DB
+}
+
+func NewStatusService(db *sql.DB) *StatusService {
+	return &StatusService{db: db}
+}
+
+/*
+Stage status from the status of all its jobs.
+PENDING until every job is terminal, then FAILED > CANCELED > SUCCESS.
+*/
+func AggregateStageStatus(jobStatuses []models.ExecStatus) models.ExecStatus {
+	status := models.SUCCESS
+	for _, jobStatus := range jobStatuses {
+		if !jobStatus.IsTerminal() {
+			return models.PENDING
+		}
+	}
+	for _, jobStatus := range jobStatuses {
+		if jobStatus == models.FAILED {
+			return models.FAILED
+		}
+		if jobStatus == models.CANCELED {
+			status = models.CANCELED
+		}
+	}
+	return status
+}
+
+/*
+Pipeline status from the status of its stages.
+A failed or canceled stage ends the pipeline since later stages never start,
+SUCCESS needs every stage of the stage order to have succeeded.
+*/
+func AggregatePipelineStatus(stageOrder []string, stageStatuses map[string]models.ExecStatus) models.ExecStatus {
+	for _, stageStatus := range stageStatuses {
+		if stageStatus == models.FAILED {
+			return models.FAILED
+		}
+	}
+	for _, stageStatus := range stageStatuses {
+		if stageStatus == models.CANCELED {
+			return models.CANCELED
+		}
+	}
+	for _, stage := range stageOrder {
+		if stageStatuses[stage] != models.SUCCESS {
+			return models.PENDING
+		}
+	}
+	return models.SUCCESS
+}
+
+// End time is only set once the execution is terminal
+func endTime(status models.ExecStatus) sql.NullTime {
+	if status.IsTerminal() {
+		return sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	return sql.NullTime{}
+}
+
+/*
+Update the status of a job, then recompute the status of its stage and pipeline.
+The container id recorded by the executor is kept.
+Returns the resulting stage and pipeline statuses, or ErrJobFinished without any change once the job is terminal.
+*/
+func (service *StatusService) UpdateJobStatus(jobId int, status models.ExecStatus) (models.ExecStatus, models.ExecStatus, error) {
+	tx, err := service.db.Begin()
+	if err != nil {
+		return "", "", fmt.Errorf("UpdateJobStatus: %v", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	// Parents of the job
+	var stageId, pipelineId int
+	err = tx.QueryRow(
+		"SELECT s.stage_id, s.pipeline_id FROM Jobs j JOIN Stages s ON j.stage_id = s.stage_id WHERE j.job_id = ?",
+		jobId,
+	).Scan(&stageId, &pipelineId)
+	if err != nil {
+		return "", "", fmt.Errorf("UpdateJobStatus: %v", err)
+	}
+
+	// Lock pipeline then stage
+	var stageOrder string
+	err = tx.QueryRow("SELECT stage_order FROM Pipelines WHERE pipeline_id = ? FOR UPDATE", pipelineId).Scan(&stageOrder)
+	if err != nil {
+		return "", "", fmt.Errorf("UpdateJobStatus: %v", err)
+	}
+	err = tx.QueryRow("SELECT stage_id FROM Stages WHERE stage_id = ? FOR UPDATE", stageId).Scan(&stageId)
+	if err != nil {
+		return "", "", fmt.Errorf("UpdateJobStatus: %v", err)
+	}
+
+	// Job
+	result, err := tx.Exec(
+		"UPDATE Jobs SET status = ?, end_time = ? WHERE job_id = ? AND status = ?",
+		status, endTime(status), jobId, models.PENDING,
+	)
+	if err != nil {
+		return "", "", fmt.Errorf("UpdateJobStatus: %v", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return "", "", fmt.Errorf("UpdateJobStatus: %v", err)
+	}
+	if updated == 0 {
+		return "", "", fmt.Errorf("UpdateJobStatus: job %d: %w", jobId, ErrJobFinished)
+	}
+
+	// Stage
+	jobStatuses, err := queryStatuses(tx, "SELECT name, status FROM Jobs WHERE stage_id = ?", stageId)
+	if err != nil {
+		return "", "", fmt.Errorf("UpdateJobStatus: %v", err)
+	}
+	var statuses []models.ExecStatus
+	for _, jobStatus := range jobStatuses {
+		statuses = append(statuses, jobStatus)
+	}
+	stageStatus := AggregateStageStatus(statuses)
+	_, err = tx.Exec("UPDATE Stages SET status = ?, end_time = ? WHERE stage_id = ?", stageStatus, endTime(stageStatus), stageId)
+	if err != nil {
+		return "", "", fmt.Errorf("UpdateJobStatus: %v", err)
+	}
+
+	// Pipeline
+	stageStatuses, err := queryStatuses(tx, "SELECT name, status FROM Stages WHERE pipeline_id = ?", pipelineId)
+	if err != nil {
+		return "", "", fmt.Errorf("UpdateJobStatus: %v", err)
+	}
+	pipelineStatus := AggregatePipelineStatus(strings.Split(stageOrder, ","), stageStatuses)
+	_, err = tx.Exec("UPDATE Pipelines SET status = ?, end_time = ? WHERE pipeline_id = ?", pipelineStatus, endTime(pipelineStatus), pipelineId)
+	if err != nil {
+		return "", "", fmt.Errorf("UpdateJobStatus: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", "", fmt.Errorf("UpdateJobStatus: %v", err)
+	}
+	return stageStatus, pipelineStatus, nil
+}
+
+// Name -> status of the rows returned by a query
+func queryStatuses(tx *sql.Tx, query string, id int) (map[string]models.ExecStatus, error) {
+	rows, err := tx.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := make(map[string]models.ExecStatus)
+	for rows.Next() {
+		var name string
+		var status models.ExecStatus
+		if err := rows.Scan(&name, &status); err != nil {
+			return nil, err
+		}
+		statuses[name] = status
+	}
+	return statuses, rows.Err()
+}
@@ -11,6 +11,15 @@ type ExecuteLocal_RequestBody struct {
 	Repository models.Repository            `json:"repository"`
 }
 
+// Job published to the job queue, consumed by an executor
+type QueueItem struct {
+	Id         string                  `json:"id"`
+	JobId      int                     `json:"jobId"`
+	StageId    int                     `json:"stageId"`
+	PipelineId int                     `json:"pipelineId"`
+	Message    JobExecutor_RequestBody `json:"message"`
+}
+
 type ReportPastExecutionsLocal_CurrentRepo_RequestBody struct {
 	Repository   models.Repository `json:"repository"`
 	IPAddress    string            `json:"ip_address"`